@@ -17,10 +17,14 @@ package app
 import (
 	"context"
 	"net/http"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/alecthomas/kong"
 	"github.com/funlessdev/fl-cli/internal/command/admin"
+	"github.com/funlessdev/fl-cli/internal/command/builder"
+	"github.com/funlessdev/fl-cli/internal/command/cache"
 	"github.com/funlessdev/fl-cli/internal/command/cfg"
 	"github.com/funlessdev/fl-cli/internal/command/fn"
 	"github.com/funlessdev/fl-cli/internal/command/mod"
@@ -29,6 +33,8 @@ import (
 	"github.com/funlessdev/fl-cli/pkg/build"
 	"github.com/funlessdev/fl-cli/pkg/client"
 	"github.com/funlessdev/fl-cli/pkg/deploy"
+	"github.com/funlessdev/fl-cli/pkg/docker"
+	"github.com/funlessdev/fl-cli/pkg/homedir"
 	"github.com/funlessdev/fl-cli/pkg/log"
 )
 
@@ -38,8 +44,13 @@ type CLI struct {
 	Admin    admin.Admin       `cmd:"" aliases:"a" help:"Deploy and manage the platform"`
 	Template template.Template `cmd:"" help:"Pull function templates"`
 	Cfg      cfg.Cfg           `cmd:"" aliases:"c,config" help:"Manage local configuration"`
+	Cache    cache.Cache       `cmd:"" help:"Manage the local build cache"`
+	Build    builder.Builder   `cmd:"" help:"Pin the builder images"`
 
 	Version kong.VersionFlag `short:"v" cmd:"" passthrough:"" help:"Show fl version"`
+	Runtime string           `name:"runtime" enum:"auto,docker,podman" default:"auto" env:"FL_RUNTIME" help:"Container runtime to use (auto, docker, podman)"`
+
+	RegistryAuth map[string]string `name:"registry-auth" placeholder:"REGISTRY=USER:PASSWORD" env:"FL_REGISTRY_AUTH" help:"Credentials to pull images from a registry, instead of the ones of ~/.docker/config.json"`
 }
 
 func ParseCMD(version string) (*kong.Context, error) {
@@ -47,12 +58,15 @@ func ParseCMD(version string) (*kong.Context, error) {
 	ctx := context.Background()
 
 	logger, err := buildLogger()
-	dockerShell := buildDockerShell()
 
 	kubernetesDeployer := deploy.NewKubernetesDeployer()
 	kubernetesRemover := deploy.NewKubernetesRemover()
+	kubernetesForwarder := deploy.NewKubernetesForwarder()
+	kubernetesBackupper := deploy.NewKubernetesBackupper()
+	kubernetesUpgrader := deploy.NewKubernetesUpgrader()
 
 	wasmBuilder := build.NewWasmBuilder()
+	nativeBuilder := build.NewNativeBuilder()
 
 	if err != nil {
 		return nil, err
@@ -62,7 +76,13 @@ func ParseCMD(version string) (*kong.Context, error) {
 	if err != nil {
 		return nil, err
 	}
-	flClient, err := client.NewClient(http.DefaultClient, flConfig)
+	// a broken ca_cert must not prevent fixing it with "fl cfg set"
+	httpClient, err := client.NewHTTPClient(flConfig)
+	if err != nil {
+		logger.Infof("Warning: %v, using the system certificates only\n", err)
+		httpClient = http.DefaultClient
+	}
+	flClient, err := client.NewClient(httpClient, flConfig)
 	validator := client.InputValidator{}
 	if err != nil {
 		return nil, err
@@ -71,6 +91,11 @@ func ParseCMD(version string) (*kong.Context, error) {
 	modSvc := &client.ModService{Client: flClient, InputValidatorHandler: &validator}
 	userSvc := &client.UserService{Client: flClient}
 
+	// the languages end up in the enums of the flags, so the registry is loaded before parsing
+	if err := loadLanguages(); err != nil {
+		return nil, err
+	}
+
 	kong_ctx := kong.Parse(&cli,
 		kong.Name("fl"),
 		kong.Description("FunLess CLI - fl"),
@@ -85,19 +110,42 @@ func ParseCMD(version string) (*kong.Context, error) {
 		kong.BindTo(modSvc, (*client.ModHandler)(nil)),
 		kong.BindTo(userSvc, (*client.UserHandler)(nil)),
 		kong.BindTo(logger, (*log.FLogger)(nil)),
-		kong.BindTo(dockerShell, (*deploy.DockerShell)(nil)),
 		kong.BindTo(kubernetesDeployer, (*deploy.KubernetesDeployer)(nil)),
 		kong.BindTo(kubernetesRemover, (*deploy.KubernetesRemover)(nil)),
+		kong.BindTo(kubernetesForwarder, (*deploy.KubernetesForwarder)(nil)),
+		kong.BindTo(kubernetesBackupper, (*deploy.KubernetesBackupper)(nil)),
+		kong.BindTo(kubernetesUpgrader, (*deploy.KubernetesUpgrader)(nil)),
 		kong.BindTo(wasmBuilder, (*build.DockerBuilder)(nil)),
+		kong.BindTo(nativeBuilder, (*build.NativeBuilder)(nil)),
 		kong.BindTo(flConfig, (*client.Config)(nil)),
+		kong.Bind(pkg.CLIVersion(version)),
 		kong.Vars{
 			"version":              version,
 			"config_keys":          pkg.ConfigKeys,
 			"default_core_image":   pkg.CoreImg,
 			"default_worker_image": pkg.WorkerImg,
+			"languages":            strings.Join(pkg.LanguageIDs(), ","),
 		},
 		kong.UsageOnError(),
 	)
+
+	// the runtime comes from the flags, so what depends on it is bound after parsing
+	runtime, err := docker.DetectRuntime(cli.Runtime)
+	if err != nil {
+		return nil, err
+	}
+	kong_ctx.Bind(runtime)
+
+	// the docker clients read the registry credentials of the flags from the context
+	ctx, err = docker.WithRegistryAuth(ctx, cli.RegistryAuth)
+	if err != nil {
+		return nil, err
+	}
+	kong_ctx.BindTo(ctx, (*context.Context)(nil))
+	kong_ctx.BindTo(deploy.NewDockerShell(runtime), (*deploy.DockerShell)(nil))
+	kong_ctx.BindTo(deploy.NewImageBundler(runtime), (*deploy.ImageBundler)(nil))
+	kong_ctx.BindTo(deploy.NewEnvironmentProber(runtime), (*deploy.EnvironmentProber)(nil))
+
 	return kong_ctx, nil
 }
 
@@ -105,12 +153,18 @@ func Run(kong_ctx *kong.Context) error {
 	return kong_ctx.Run()
 }
 
+// loadLanguages extends the language registry with ~/.fl/languages.yaml, then with the fl.languages.yaml
+// file of the working directory
+func loadLanguages() error {
+	home, err := homedir.GetHomeDir()
+	if err != nil {
+		return err
+	}
+	return pkg.LoadLanguages(filepath.Join(home, pkg.ConfigDir, pkg.LanguagesFileName), pkg.ProjectLanguagesFileName)
+}
+
 func buildLogger() (log.FLogger, error) {
 	b := log.NewLoggerBuilder()
 	logger, err := b.WithDebug(true).SpinnerFrequency(150 * time.Millisecond).SpinnerCharSet(59).Build()
 	return logger, err
 }
-
-func buildDockerShell() deploy.DockerShell {
-	return &deploy.FLDockerShell{}
-}
@@ -19,7 +19,9 @@ go 1.19
 require (
 	github.com/alecthomas/kong v0.7.1
 	github.com/docker/docker v20.10.23+incompatible
+	github.com/docker/go-units v0.5.0
 	github.com/funlessdev/fl-client-sdk-go v0.0.0-20230312081443-2c80f8dc5ba5
+	github.com/mattn/go-isatty v0.0.17
 	github.com/theckman/yacspin v0.13.12
 	golang.org/x/exp v0.0.0-20230223210539-50820d90acfd
 	gopkg.in/yaml.v2 v2.4.0
@@ -37,9 +39,9 @@ require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/docker/distribution v2.8.1+incompatible // indirect
 	github.com/docker/go-connections v0.4.0 // indirect
-	github.com/docker/go-units v0.5.0 // indirect
 	github.com/emicklei/go-restful/v3 v3.10.1 // indirect
 	github.com/emirpasic/gods v1.18.1 // indirect
+	github.com/evanphx/json-patch v4.12.0+incompatible // indirect
 	github.com/fatih/color v1.14.1 // indirect
 	github.com/go-git/gcfg v1.5.0 // indirect
 	github.com/go-git/go-billy/v5 v5.4.0 // indirect
@@ -59,7 +61,6 @@ require (
 	github.com/kevinburke/ssh_config v1.2.0 // indirect
 	github.com/mailru/easyjson v0.7.7 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
-	github.com/mattn/go-isatty v0.0.17 // indirect
 	github.com/mattn/go-runewidth v0.0.14 // indirect
 	github.com/moby/spdystream v0.2.0 // indirect
 	github.com/moby/term v0.0.0-20210619224110-3f7ff695adc6 // indirect
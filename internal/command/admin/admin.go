@@ -15,11 +15,19 @@
 package admin
 
 import (
+	backup "github.com/funlessdev/fl-cli/internal/command/admin/backup"
 	deploy "github.com/funlessdev/fl-cli/internal/command/admin/deploy"
+	doctor "github.com/funlessdev/fl-cli/internal/command/admin/doctor"
+	images "github.com/funlessdev/fl-cli/internal/command/admin/images"
+	secret "github.com/funlessdev/fl-cli/internal/command/admin/secret"
 	user "github.com/funlessdev/fl-cli/internal/command/admin/user"
 )
 
 type Admin struct {
+	Backup backup.Backup `cmd:"" name:"backup" aliases:"b" help:"Back up and restore the data of a FunLess deployment"`
 	Deploy deploy.Deploy `cmd:"" name:"deploy" aliases:"d" help:"Deploy FunLess on different setups"`
+	Doctor doctor.Doctor `cmd:"" name:"doctor" help:"Check the environment fl runs in and report problems"`
+	Images images.Images `cmd:"" name:"images" aliases:"i" help:"Save and load the images used by FunLess, for air-gapped hosts"`
+	Secret secret.Secret `cmd:"" name:"secret" aliases:"s" help:"Manage the secret_key_base of a FunLess deployment"`
 	User   user.User     `cmd:"" name:"user" aliases:"u" help:"Create/delete FunLess users"`
 }
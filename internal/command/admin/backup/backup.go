@@ -0,0 +1,171 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin_backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/funlessdev/fl-cli/pkg"
+	"github.com/funlessdev/fl-cli/pkg/backup"
+	"github.com/funlessdev/fl-cli/pkg/client"
+	"github.com/funlessdev/fl-cli/pkg/deploy"
+	"github.com/funlessdev/fl-cli/pkg/docker"
+	"github.com/funlessdev/fl-cli/pkg/log"
+)
+
+const dumpFormat = "pg_dump-custom"
+
+type Backup struct {
+	Create  CreateBackup  `cmd:"" name:"create" aliases:"c" help:"Dump the deployment database to a local archive"`
+	Restore RestoreBackup `cmd:"" name:"restore" aliases:"r" help:"Restore the deployment database from a local archive"`
+
+	Backend    string `name:"backend" short:"b" enum:"docker,kubernetes" default:"docker" help:"The kind of deployment to back up or restore (docker, kubernetes)"`
+	KubeConfig string `name:"kubeconfig" short:"k" help:"Absolute path to the kubeconfig file (kubernetes backend only)"`
+	Name       string `name:"name" short:"n" help:"Name of the local instance (docker backend only)" default:"fl"`
+}
+
+func (b *Backup) Help() string {
+	return `
+DESCRIPTION
+
+	Back up and restore the data of a FunLess deployment (functions,
+	modules and users), stored in its PostgreSQL database.
+	The dump is taken with pg_dump inside the postgres container (docker)
+	or pod (kubernetes), and saved to a gzipped tar archive together with
+	metadata about the source deployment.
+	Restoring replaces the existing data with the content of the archive,
+	so it can be used to move data to a new deployment.
+	With the docker backend, "--name" selects the instance created with
+	"fl admin deploy docker up --name".
+	An external database configured with "--database-url" is not managed
+	by FunLess, use its own backup tooling instead.
+
+EXAMPLES
+
+	$ fl admin backup create fl-backup.tar.gz
+	$ fl admin backup restore fl-backup.tar.gz --backend kubernetes
+	$ fl admin backup create fl-next.tar.gz --name fl-next`
+}
+
+type CreateBackup struct {
+	File string `arg:"" name:"file" type:"path" help:"Path of the archive to create"`
+}
+
+func (c *CreateBackup) Run(ctx context.Context, dk deploy.DockerShell, backupper deploy.KubernetesBackupper, logger log.FLogger, config client.Config, parent *Backup) error {
+	logger.Info("Creating FunLess backup...\n\n")
+
+	if err := setupBackend(backupper, logger, parent); err != nil {
+		return err
+	}
+
+	dump, err := os.CreateTemp("", "fl-backup-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(dump.Name())
+	defer dump.Close()
+
+	_ = logger.StartSpinner("Dumping the database...")
+	var source string
+	switch parent.Backend {
+	case "kubernetes":
+		source, err = backupper.DumpDatabase(ctx, dump)
+	default:
+		source = deploy.DockerContainerName(parent.Name, "postgres")
+		err = dk.Exec(dockerHostContext(ctx, parent.Name), source, nil, dump, "sh", "-c", deploy.PostgresDumpCmd)
+	}
+	if err := logger.StopSpinner(err); err != nil {
+		return err
+	}
+
+	meta := backup.Metadata{
+		Backend:    parent.Backend,
+		Source:     source,
+		APIHost:    config.Host,
+		DumpFormat: dumpFormat,
+		CreatedAt:  time.Now().UTC(),
+	}
+
+	_ = logger.StartSpinner("Writing the archive...")
+	if err := logger.StopSpinner(backup.Write(c.File, meta, dump)); err != nil {
+		return err
+	}
+
+	logger.Infof("\nBackup saved to %s 💾\n", c.File)
+	return nil
+}
+
+type RestoreBackup struct {
+	File string `arg:"" name:"file" type:"existingfile" help:"Path of the archive to restore"`
+}
+
+func (r *RestoreBackup) Run(ctx context.Context, dk deploy.DockerShell, backupper deploy.KubernetesBackupper, logger log.FLogger, parent *Backup) error {
+	logger.Info("Restoring FunLess backup...\n\n")
+
+	archive, err := backup.Open(r.File)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	if archive.Metadata.DumpFormat != dumpFormat {
+		return fmt.Errorf("unsupported dump format %q", archive.Metadata.DumpFormat)
+	}
+	logger.Infof("Backup of %s (%s) taken at %s.\n\n", archive.Metadata.Source, archive.Metadata.Backend, archive.Metadata.CreatedAt.Format(time.RFC3339))
+
+	if err := setupBackend(backupper, logger, parent); err != nil {
+		return err
+	}
+
+	_ = logger.StartSpinner("Restoring the database...")
+	switch parent.Backend {
+	case "kubernetes":
+		err = backupper.RestoreDatabase(ctx, archive.Dump)
+	default:
+		err = dk.Exec(dockerHostContext(ctx, parent.Name), deploy.DockerContainerName(parent.Name, "postgres"), archive.Dump, io.Discard, "sh", "-c", deploy.PostgresRestoreCmd)
+	}
+	if err := logger.StopSpinner(err); err != nil {
+		return err
+	}
+
+	logger.Info("\nBackup restored! 🎉\n")
+	return nil
+}
+
+// dockerHostContext makes the docker commands target the Docker host the instance was deployed to
+func dockerHostContext(ctx context.Context, name string) context.Context {
+	host := deploy.RecordedDockerHost(name)
+	if host == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, pkg.FLContextKey("env"), map[string]string{docker.HostEnv: host})
+}
+
+func setupBackend(backupper deploy.KubernetesBackupper, logger log.FLogger, parent *Backup) error {
+	if parent.Backend != "kubernetes" {
+		if parent.KubeConfig != "" {
+			return errors.New("--kubeconfig can only be used with the kubernetes backend")
+		}
+		return deploy.ValidateDockerInstanceName(parent.Name)
+	}
+
+	_ = logger.StartSpinner("Setting things up...")
+	return logger.StopSpinner(backupper.WithConfig(parent.KubeConfig))
+}
@@ -0,0 +1,140 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin_backup
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/funlessdev/fl-cli/pkg/backup"
+	"github.com/funlessdev/fl-cli/pkg/client"
+	"github.com/funlessdev/fl-cli/pkg/deploy"
+	"github.com/funlessdev/fl-cli/pkg/log"
+	"github.com/funlessdev/fl-cli/test/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testDump = "PGDMP fake dump"
+
+func TestBackupDocker(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "backup.tar.gz")
+	config := client.Config{Host: "http://localhost:4000"}
+
+	t.Run("create should dump the postgres container into the archive", func(t *testing.T) {
+		var outbuf bytes.Buffer
+		logger, _ := log.NewLoggerBuilder().WithWriter(&outbuf).DisableAnimation().Build()
+
+		dk := mocks.NewDockerShell(t)
+		dk.On("Exec", ctx, "fl-postgres-1", nil, mock.Anything, "sh", "-c", deploy.PostgresDumpCmd).
+			Run(func(args mock.Arguments) {
+				_, _ = args.Get(3).(io.Writer).Write([]byte(testDump))
+			}).Return(nil)
+
+		cmd := CreateBackup{File: path}
+		err := cmd.Run(ctx, dk, mocks.NewKubernetesBackupper(t), logger, config, &Backup{Backend: "docker", Name: "fl"})
+		require.NoError(t, err)
+
+		archive, err := backup.Open(path)
+		require.NoError(t, err)
+		defer archive.Close()
+
+		require.Equal(t, "docker", archive.Metadata.Backend)
+		require.Equal(t, "fl-postgres-1", archive.Metadata.Source)
+		require.Equal(t, "http://localhost:4000", archive.Metadata.APIHost)
+		content, err := io.ReadAll(archive.Dump)
+		require.NoError(t, err)
+		require.Equal(t, testDump, string(content))
+
+		expected := "Creating FunLess backup...\n\n" +
+			"Dumping the database...\ndone\n" +
+			"Writing the archive...\ndone\n" +
+			"\nBackup saved to " + path + " 💾\n"
+		require.Equal(t, expected, outbuf.String())
+	})
+
+	t.Run("restore should stream the dump into the postgres container", func(t *testing.T) {
+		var outbuf bytes.Buffer
+		logger, _ := log.NewLoggerBuilder().WithWriter(&outbuf).DisableAnimation().Build()
+
+		var restored []byte
+		dk := mocks.NewDockerShell(t)
+		dk.On("Exec", ctx, "fl-postgres-1", mock.Anything, io.Discard, "sh", "-c", deploy.PostgresRestoreCmd).
+			Run(func(args mock.Arguments) {
+				restored, _ = io.ReadAll(args.Get(2).(io.Reader))
+			}).Return(nil)
+
+		cmd := RestoreBackup{File: path}
+		err := cmd.Run(ctx, dk, mocks.NewKubernetesBackupper(t), logger, &Backup{Backend: "docker", Name: "fl"})
+		require.NoError(t, err)
+		require.Equal(t, testDump, string(restored))
+		require.Contains(t, outbuf.String(), "Restoring the database...\ndone\n")
+	})
+
+	t.Run("should reject kubeconfig with the docker backend", func(t *testing.T) {
+		logger, _ := log.NewLoggerBuilder().WithWriter(io.Discard).DisableAnimation().Build()
+
+		cmd := CreateBackup{File: path}
+		err := cmd.Run(ctx, mocks.NewDockerShell(t), mocks.NewKubernetesBackupper(t), logger, config, &Backup{Backend: "docker", Name: "fl", KubeConfig: "/kube/config"})
+		require.Error(t, err)
+	})
+}
+
+func TestBackupKubernetes(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "backup.tar.gz")
+	parent := &Backup{Backend: "kubernetes", KubeConfig: "/kube/config"}
+
+	t.Run("create should dump the postgres pod into the archive", func(t *testing.T) {
+		logger, _ := log.NewLoggerBuilder().WithWriter(io.Discard).DisableAnimation().Build()
+
+		backupper := mocks.NewKubernetesBackupper(t)
+		backupper.On("WithConfig", "/kube/config").Return(nil)
+		backupper.On("DumpDatabase", ctx, mock.Anything).
+			Run(func(args mock.Arguments) {
+				_, _ = args.Get(1).(io.Writer).Write([]byte(testDump))
+			}).Return("fl/postgres-7d9c5b6f4-x2kq8", nil)
+
+		cmd := CreateBackup{File: path}
+		err := cmd.Run(ctx, mocks.NewDockerShell(t), backupper, logger, client.Config{}, parent)
+		require.NoError(t, err)
+
+		archive, err := backup.Open(path)
+		require.NoError(t, err)
+		defer archive.Close()
+		require.Equal(t, "fl/postgres-7d9c5b6f4-x2kq8", archive.Metadata.Source)
+	})
+
+	t.Run("restore should stream the dump into the postgres pod", func(t *testing.T) {
+		logger, _ := log.NewLoggerBuilder().WithWriter(io.Discard).DisableAnimation().Build()
+
+		var restored []byte
+		backupper := mocks.NewKubernetesBackupper(t)
+		backupper.On("WithConfig", "/kube/config").Return(nil)
+		backupper.On("RestoreDatabase", ctx, mock.Anything).
+			Run(func(args mock.Arguments) {
+				restored, _ = io.ReadAll(args.Get(1).(io.Reader))
+			}).Return(nil)
+
+		cmd := RestoreBackup{File: path}
+		err := cmd.Run(ctx, mocks.NewDockerShell(t), backupper, logger, parent)
+		require.NoError(t, err)
+		require.Equal(t, testDump, string(restored))
+	})
+}
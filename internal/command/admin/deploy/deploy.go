@@ -22,16 +22,20 @@ import (
 type Deploy struct {
 	Docker     deploy_docker     `cmd:"" name:"docker" aliases:"d" help:"Deploy locally with 1 core and 1 worker docker containers"`
 	Kubernetes deploy_kubernetes `cmd:"" name:"kubernetes" aliases:"k,k8s" help:"Deploy on an existing kubernetes cluster"`
+	List       List              `cmd:"" name:"list" aliases:"l,ls" help:"List the deployments created with fl"`
 }
 
 type deploy_docker struct {
-	Up   docker.Up   `cmd:"" name:"up" aliases:"u" help:"Spin up Docker-based FunLess deployment"`
-	Down docker.Down `cmd:"" name:"down" aliases:"d" help:"Tear down Docker-based FunLess deployment"`
+	Up      docker.Up      `cmd:"" name:"up" aliases:"u" help:"Spin up Docker-based FunLess deployment"`
+	Down    docker.Down    `cmd:"" name:"down" aliases:"d" help:"Tear down Docker-based FunLess deployment"`
+	Upgrade docker.Upgrade `cmd:"" name:"upgrade" help:"Upgrade core and worker of a running Docker-based FunLess deployment"`
 }
 
 type deploy_kubernetes struct {
-	Up   kubernetes.Up   `cmd:"" name:"up" aliases:"u" help:"Spin up Kubernetes-based FunLess deployment"`
-	Down kubernetes.Down `cmd:"" name:"down" aliases:"d" help:"Tear down Kubernetes-based FunLess deployment"`
+	Up          kubernetes.Up          `cmd:"" name:"up" aliases:"u" help:"Spin up Kubernetes-based FunLess deployment"`
+	Down        kubernetes.Down        `cmd:"" name:"down" aliases:"d" help:"Tear down Kubernetes-based FunLess deployment"`
+	Upgrade     kubernetes.Upgrade     `cmd:"" name:"upgrade" help:"Upgrade core and worker of a running Kubernetes-based FunLess deployment"`
+	PortForward kubernetes.PortForward `cmd:"" name:"port-forward" aliases:"pf" help:"Forward a local port to the Kubernetes-based FunLess Core API"`
 }
 
 func (f *Deploy) Help() string {
@@ -18,24 +18,34 @@ import (
 	"context"
 	"errors"
 	"os"
+	"path"
+	"strings"
 
+	"github.com/funlessdev/fl-cli/pkg"
 	"github.com/funlessdev/fl-cli/pkg/deploy"
+	"github.com/funlessdev/fl-cli/pkg/docker"
 	"github.com/funlessdev/fl-cli/pkg/homedir"
 	"github.com/funlessdev/fl-cli/pkg/log"
 	"golang.org/x/exp/slices"
 )
 
-type Down struct{}
+type Down struct {
+	Name       string `name:"name" short:"n" help:"Name of the local instance to remove" default:"fl"`
+	DockerHost string `name:"docker-host" env:"DOCKER_HOST" help:"Docker host the instance runs on (the one it was deployed to by default)"`
+}
 
 func (r *Down) Run(ctx context.Context, dk deploy.DockerShell, logger log.FLogger) error {
+	if err := deploy.ValidateDockerInstanceName(r.Name); err != nil {
+		return err
+	}
+
 	logger.Info("Removing local FunLess deployment...\n")
 
-	_, composeFilePath, err := homedir.ReadFromConfigDir("docker-compose.yml")
+	_, composeFilePath, err := homedir.ReadFromConfigDir(path.Join(deploy.DockerInstanceDir(r.Name), "docker-compose.yml"))
 	if err != nil {
 		errorMsg := "unable to read docker-compose.yml file"
 		if os.IsNotExist(err) {
-			lines, _ := dk.ComposeList(ctx)
-			if slices.Contains(lines, "fl") {
+			if isRecorded(r.Name) || composeProjectExists(ctx, dk, r.Name) {
 				errorMsg = "unable to locate docker-compose.yml, but a local deployment was found. The file might have been moved or deleted."
 			} else {
 				errorMsg = "no local deployment found, nothing to remove. Use \"fl admin deploy docker up\" to create one."
@@ -45,12 +55,45 @@ func (r *Down) Run(ctx context.Context, dk deploy.DockerShell, logger log.FLogge
 	}
 	defer os.Remove(composeFilePath)
 
-	err = dk.ComposeDown(ctx, composeFilePath)
+	dockerHost := r.DockerHost
+	if dockerHost == "" {
+		dockerHost = deploy.RecordedDockerHost(r.Name)
+	}
+
+	// every stack is removed, not only the ones enabled by the last up
+	cmdEnv := map[string]string{composeProfilesKey: strings.Join(deploy.Stacks, ","), docker.HostEnv: dockerHost}
+	ctx = context.WithValue(ctx, pkg.FLContextKey("env"), cmdEnv)
+
+	err = dk.ComposeDown(ctx, r.Name, composeFilePath)
 	if err != nil {
 		return err
 	}
 
+	if err := deploy.ForgetDeployment(dockerDeploymentID(r.Name)); err != nil {
+		logger.Infof("\nCouldn't remove the deployment from ~/.fl/%s: %v\n", deploy.RegistryFileName, err)
+	}
+
 	logger.Info("\nAll clear! 👍\n")
 
 	return nil
 }
+
+func dockerDeploymentID(name string) string {
+	return deploy.Deployment{Backend: "docker", Project: name}.ID()
+}
+
+// isRecorded reports whether the registry knows about the local instance
+func isRecorded(name string) bool {
+	registry, err := deploy.LoadRegistry()
+	if err != nil {
+		return false
+	}
+	_, found := registry.Find(dockerDeploymentID(name))
+	return found
+}
+
+// composeProjectExists reports whether docker compose knows about the project of the instance
+func composeProjectExists(ctx context.Context, dk deploy.DockerShell, name string) bool {
+	lines, _ := dk.ComposeList(ctx)
+	return slices.Contains(lines, name)
+}
@@ -20,6 +20,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/funlessdev/fl-cli/pkg/deploy"
 	"github.com/funlessdev/fl-cli/pkg/homedir"
 	"github.com/funlessdev/fl-cli/test/mocks"
 	"github.com/stretchr/testify/mock"
@@ -38,7 +39,7 @@ func TestDockerDownRun(t *testing.T) {
 		os.RemoveAll(homedirPath)
 	}()
 
-	down := Down{}
+	down := Down{Name: "fl"}
 	ctx := context.TODO()
 
 	mockDockerShell := mocks.NewDockerShell(t)
@@ -57,11 +58,11 @@ func TestDockerDownRun(t *testing.T) {
 	})
 
 	t.Run("should return error when compose down fails", func(t *testing.T) {
-		path, err := downloadFile("docker-compose.yml", dockerComposeYmlUrl)
+		path, err := downloadFile("", "docker-compose.yml", dockerComposeYmlUrl)
 		require.NoError(t, err)
 
 		out.Reset()
-		mockDockerShell.On("ComposeDown", mock.Anything, path).Return(errors.New("some compose down error")).Once()
+		mockDockerShell.On("ComposeDown", mock.Anything, "fl", path).Return(errors.New("some compose down error")).Once()
 
 		err = down.Run(ctx, mockDockerShell, logger)
 		require.Error(t, err)
@@ -108,11 +109,11 @@ func TestDockerDownRun(t *testing.T) {
 	})
 
 	t.Run("should remove docker-compose.yml when succeds", func(t *testing.T) {
-		path, err := downloadFile("docker-compose.yml", dockerComposeYmlUrl)
+		path, err := downloadFile("", "docker-compose.yml", dockerComposeYmlUrl)
 		require.NoError(t, err)
 
 		out.Reset()
-		mockDockerShell.On("ComposeDown", mock.Anything, path).Return(nil)
+		mockDockerShell.On("ComposeDown", mock.Anything, "fl", path).Return(nil)
 
 		err = down.Run(ctx, mockDockerShell, logger)
 		require.NoError(t, err)
@@ -121,3 +122,40 @@ func TestDockerDownRun(t *testing.T) {
 		require.NoFileExists(t, path)
 	})
 }
+
+func TestDockerDownRegistry(t *testing.T) {
+	homedirPath, err := os.MkdirTemp("", "funless-test-homedir-")
+	require.NoError(t, err)
+
+	homedir.GetHomeDir = func() (string, error) {
+		return homedirPath, nil
+	}
+	defer func() {
+		homedir.GetHomeDir = os.UserHomeDir
+		os.RemoveAll(homedirPath)
+	}()
+
+	ctx := context.TODO()
+	require.NoError(t, deploy.RecordDeployment(deploy.Deployment{Backend: "docker", Project: deploy.DockerProject}))
+
+	t.Run("should use the registry to detect a deployment without docker-compose.yml", func(t *testing.T) {
+		_, logger := testLogger()
+		err := (&Down{Name: "fl"}).Run(ctx, mocks.NewDockerShell(t), logger)
+		require.EqualError(t, err, "unable to locate docker-compose.yml, but a local deployment was found. The file might have been moved or deleted.")
+	})
+
+	t.Run("should forget the deployment once removed", func(t *testing.T) {
+		path, err := homedir.WriteToConfigDir("docker-compose.yml", []byte(testComposeYml), true)
+		require.NoError(t, err)
+
+		mockDockerShell := mocks.NewDockerShell(t)
+		mockDockerShell.On("ComposeDown", mock.Anything, "fl", path).Return(nil).Once()
+
+		_, logger := testLogger()
+		require.NoError(t, (&Down{Name: "fl"}).Run(ctx, mockDockerShell, logger))
+
+		registry, err := deploy.LoadRegistry()
+		require.NoError(t, err)
+		require.Empty(t, registry.Deployments)
+	})
+}
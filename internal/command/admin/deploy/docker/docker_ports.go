@@ -0,0 +1,136 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin_deploy_docker
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"path"
+
+	"github.com/funlessdev/fl-cli/pkg/deploy"
+	"github.com/funlessdev/fl-cli/pkg/homedir"
+)
+
+const (
+	defaultCorePort = 4000
+	maxPortOffset   = 1000
+)
+
+// isPortFree is swapped in tests
+var isPortFree = func(port int) bool {
+	l, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return false
+	}
+	l.Close()
+	return true
+}
+
+// allocatePorts moves the host ports published in the instance compose file by the smallest offset
+// making them all free and not reserved by other recorded instances. An instance that was already
+// allocated keeps its offset. It returns the offset.
+func allocatePorts(dir string, name string) (int, error) {
+	compose, err := readCompose(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	if compose.PortOffset != 0 {
+		return compose.PortOffset, nil
+	}
+
+	reserved := map[int]bool{0: true}
+	registry, err := deploy.LoadRegistry()
+	if err != nil {
+		return 0, err
+	}
+	for _, d := range registry.Deployments {
+		if d.Backend == "docker" && d.Project != name {
+			reserved[d.PortOffset] = true
+		}
+	}
+
+	offset, err := findPortOffset(compose.HostPorts(), reserved)
+	if err != nil {
+		return 0, err
+	}
+
+	compose.ShiftPorts(offset)
+	compose.PortOffset = offset
+	return offset, writeCompose(dir, compose)
+}
+
+// coreHostPort returns the host port the core API is published on
+func coreHostPort(dir string) (int, error) {
+	compose, err := readCompose(dir)
+	if err != nil {
+		return 0, err
+	}
+	if port, ok := serviceHostPort(compose, "core"); ok {
+		return port, nil
+	}
+	return defaultCorePort, nil
+}
+
+// serviceHostPort returns the first host port a service is published on
+func serviceHostPort(compose *deploy.Compose, service string) (int, bool) {
+	svc, found := compose.Services[service]
+	if !found {
+		return 0, false
+	}
+	for _, p := range svc.Ports {
+		if host, ok := p.HostPort(); ok {
+			return host, true
+		}
+	}
+	return 0, false
+}
+
+func findPortOffset(ports []int, reserved map[int]bool) (int, error) {
+	for offset := 1; offset <= maxPortOffset; offset++ {
+		if reserved[offset] {
+			continue
+		}
+		free := true
+		for _, p := range ports {
+			if p+offset > 65535 || !isPortFree(p+offset) {
+				free = false
+				break
+			}
+		}
+		if free {
+			return offset, nil
+		}
+	}
+	return 0, errors.New("unable to find free host ports for the instance")
+}
+
+func readCompose(dir string) (*deploy.Compose, error) {
+	content, _, err := homedir.ReadFromConfigDir(path.Join(dir, "docker-compose.yml"))
+	if err != nil {
+		return nil, errors.New("unable to read docker-compose.yml")
+	}
+	return deploy.ParseCompose(content)
+}
+
+func writeCompose(dir string, compose *deploy.Compose) error {
+	content, err := compose.Marshal()
+	if err != nil {
+		return err
+	}
+	_, err = homedir.WriteToConfigDir(path.Join(dir, "docker-compose.yml"), content, true)
+	return err
+}
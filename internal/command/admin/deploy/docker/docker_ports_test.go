@@ -0,0 +1,101 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin_deploy_docker
+
+import (
+	"os"
+	"testing"
+
+	"github.com/funlessdev/fl-cli/pkg/deploy"
+	"github.com/funlessdev/fl-cli/pkg/homedir"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+const testPortsComposeYml = `services:
+  core:
+    image: ghcr.io/funlessdev/core:latest
+    ports:
+      - "4000:4000"
+  prometheus:
+    image: prom/prometheus
+    ports:
+      - "127.0.0.1:9090:9090"
+  kibana:
+    image: kibana
+    ports:
+      - target: 5601
+        published: 5601
+  worker:
+    image: ghcr.io/funlessdev/worker:latest
+    ports:
+      - "4369"
+`
+
+func Test_allocatePorts(t *testing.T) {
+	homedirPath, err := os.MkdirTemp("", "funless-test-homedir-")
+	require.NoError(t, err)
+
+	homedir.GetHomeDir = func() (string, error) {
+		return homedirPath, nil
+	}
+	defaultIsPortFree := isPortFree
+	busy := map[int]bool{4001: true}
+	isPortFree = func(port int) bool {
+		return !busy[port]
+	}
+	defer func() {
+		homedir.GetHomeDir = os.UserHomeDir
+		isPortFree = defaultIsPortFree
+		os.RemoveAll(homedirPath)
+	}()
+
+	dir := deploy.DockerInstanceDir("fl-next")
+	_, err = homedir.CreateDirInConfigDir(dir)
+	require.NoError(t, err)
+	_, err = homedir.WriteToConfigDir(dir+"/docker-compose.yml", []byte(testPortsComposeYml), true)
+	require.NoError(t, err)
+
+	// offset 1 is taken by a busy port, offset 2 by another instance
+	require.NoError(t, deploy.RecordDeployment(deploy.Deployment{Backend: "docker", Project: "fl-other", PortOffset: 2}))
+
+	offset, err := allocatePorts(dir, "fl-next")
+	require.NoError(t, err)
+	require.Equal(t, 3, offset)
+
+	corePort, err := coreHostPort(dir)
+	require.NoError(t, err)
+	require.Equal(t, 4003, corePort)
+
+	content, _, err := homedir.ReadFromConfigDir(dir + "/docker-compose.yml")
+	require.NoError(t, err)
+	var compose struct {
+		Services map[string]map[string]interface{} `yaml:"services"`
+		Offset   int                               `yaml:"x-fl-port-offset"`
+	}
+	require.NoError(t, yaml.Unmarshal(content, &compose))
+	require.Equal(t, 3, compose.Offset)
+	require.Equal(t, []interface{}{"127.0.0.1:9093:9090"}, compose.Services["prometheus"]["ports"])
+	require.Equal(t, []interface{}{map[interface{}]interface{}{"target": 5601, "published": 5604}}, compose.Services["kibana"]["ports"])
+	require.Equal(t, []interface{}{"4369"}, compose.Services["worker"]["ports"])
+
+	// running up again keeps the ports of the instance
+	offset, err = allocatePorts(dir, "fl-next")
+	require.NoError(t, err)
+	require.Equal(t, 3, offset)
+	corePort, err = coreHostPort(dir)
+	require.NoError(t, err)
+	require.Equal(t, 4003, corePort)
+}
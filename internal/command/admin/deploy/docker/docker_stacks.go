@@ -0,0 +1,140 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin_deploy_docker
+
+import (
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/funlessdev/fl-cli/pkg/deploy"
+	"github.com/funlessdev/fl-cli/pkg/homedir"
+	"golang.org/x/exp/slices"
+)
+
+// composeProfilesKey is read by docker compose from the .env file next to the compose file
+const composeProfilesKey = "COMPOSE_PROFILES"
+
+// composeStacks wires the optional stacks in the compose file of the instance, each under the compose profile
+// of the same name, so every compose command of the instance only handles the enabled ones. The services of
+// disabled stacks stay in the file and are not started, so the ones of a previous deployment can be removed.
+func composeStacks(dir string, enabled []string) error {
+	compose, err := readCompose(dir)
+	if err != nil {
+		return err
+	}
+
+	if _, found := compose.Services[deploy.PrometheusService]; found {
+		if err := compose.SetProfile(deploy.PrometheusService, deploy.StackMetrics); err != nil {
+			return err
+		}
+	} else if slices.Contains(enabled, deploy.StackMetrics) {
+		return fmt.Errorf("no %s service found in docker-compose.yml, unable to deploy the %s stack", deploy.PrometheusService, deploy.StackMetrics)
+	}
+	content, _, err := homedir.ReadFromConfigDir(path.Join(dir, "filebeat", "filebeat.compose.yml"))
+	if err != nil {
+		return fmt.Errorf("unable to read filebeat.compose.yml: %w", err)
+	}
+	logging, err := deploy.ParseCompose(content)
+	if err != nil {
+		return fmt.Errorf("filebeat.compose.yml: %w", err)
+	}
+	compose.AddStack(logging, deploy.StackLogging, "filebeat")
+
+	if err := writeGrafanaAssets(dir); err != nil {
+		return err
+	}
+	dashboards, err := deploy.ParseCompose(deploy.GrafanaComposeYml)
+	if err != nil {
+		return err
+	}
+	compose.AddStack(dashboards, deploy.StackDashboards, ".")
+
+	// nothing may depend on a service that is not started
+	if !slices.Contains(enabled, deploy.StackMetrics) {
+		compose.RemoveDependencies(deploy.PrometheusService)
+	}
+
+	return writeCompose(dir, compose)
+}
+
+// disabledStackServices returns the profiles of the stacks that are not enabled and the services under them,
+// sorted, for their containers to be removed: compose up leaves them running.
+func disabledStackServices(dir string, enabled []string) ([]string, []string, error) {
+	compose, err := readCompose(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	profiles := []string{}
+	services := []string{}
+	for _, name := range compose.ServiceNames() {
+		svcProfiles := compose.Services[name].Profiles
+		if len(svcProfiles) == 0 || slices.ContainsFunc(svcProfiles, func(p string) bool { return slices.Contains(enabled, p) }) {
+			continue
+		}
+		services = append(services, name)
+		for _, p := range svcProfiles {
+			if !slices.Contains(profiles, p) {
+				profiles = append(profiles, p)
+			}
+		}
+	}
+	sort.Strings(profiles)
+	return profiles, services, nil
+}
+
+// writeGrafanaAssets writes the Grafana provisioning files next to the compose file, replacing older versions
+func writeGrafanaAssets(dir string) error {
+	return deploy.WalkGrafanaAssets(func(p string, content []byte) error {
+		target := path.Join(dir, deploy.GrafanaDir, p)
+		if _, err := homedir.CreateDirInConfigDir(path.Dir(target)); err != nil {
+			return err
+		}
+		_, err := homedir.WriteToConfigDir(target, content, true)
+		return err
+	})
+}
+
+// stackUI is the web UI of an optional stack, as published on the host
+type stackUI struct {
+	Name string
+	URL  string
+}
+
+// stackUIs returns the web UIs of the enabled stacks, published on the Docker host at address
+func stackUIs(dir string, enabled []string, address string) ([]stackUI, error) {
+	compose, err := readCompose(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	uis := []struct{ stack, service, name string }{
+		{deploy.StackMetrics, deploy.PrometheusService, "Prometheus"},
+		{deploy.StackLogging, "kibana", "Kibana"},
+		{deploy.StackDashboards, "grafana", "Grafana dashboards"},
+	}
+
+	published := []stackUI{}
+	for _, ui := range uis {
+		if !slices.Contains(enabled, ui.stack) {
+			continue
+		}
+		if port, ok := serviceHostPort(compose, ui.service); ok {
+			published = append(published, stackUI{Name: ui.name, URL: fmt.Sprintf("http://%s:%d", address, port)})
+		}
+	}
+	return published, nil
+}
@@ -0,0 +1,153 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin_deploy_docker
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/funlessdev/fl-cli/pkg/deploy"
+	"github.com/funlessdev/fl-cli/pkg/homedir"
+	"github.com/stretchr/testify/require"
+)
+
+const testStacksComposeYml = `services:
+  core:
+    image: ghcr.io/funlessdev/core:latest
+    depends_on:
+      - postgres
+      - prometheus
+    ports:
+      - "4000:4000"
+  postgres:
+    image: postgres:latest
+  prometheus:
+    image: prom/prometheus
+    volumes:
+      - ./prometheus/config.yml:/etc/prometheus/prometheus.yml
+    ports:
+      - "9090:9090"
+`
+
+const testFilebeatComposeYml = `services:
+  kibana:
+    image: kibana:8.6.0
+    ports:
+      - "5601:5601"
+  filebeat:
+    image: elastic/filebeat:8.6.0
+    volumes:
+      - ./filebeat.yml:/usr/share/filebeat/filebeat.yml:ro
+`
+
+func Test_composeStacks(t *testing.T) {
+	homedirPath, err := os.MkdirTemp("", "funless-test-homedir-")
+	require.NoError(t, err)
+
+	homedir.GetHomeDir = func() (string, error) {
+		return homedirPath, nil
+	}
+	defer func() {
+		homedir.GetHomeDir = os.UserHomeDir
+		os.RemoveAll(homedirPath)
+	}()
+
+	dir := deploy.DockerInstanceDir("fl")
+	setup := func(t *testing.T) {
+		t.Helper()
+		_, err := homedir.CreateDirInConfigDir(path.Join(dir, "filebeat"))
+		require.NoError(t, err)
+		_, err = homedir.WriteToConfigDir(path.Join(dir, "docker-compose.yml"), []byte(testStacksComposeYml), true)
+		require.NoError(t, err)
+		_, err = homedir.WriteToConfigDir(path.Join(dir, "filebeat", "filebeat.compose.yml"), []byte(testFilebeatComposeYml), true)
+		require.NoError(t, err)
+	}
+
+	t.Run("should put prometheus under the metrics profile", func(t *testing.T) {
+		setup(t)
+		require.NoError(t, composeStacks(dir, []string{deploy.StackMetrics}))
+
+		compose, err := readCompose(dir)
+		require.NoError(t, err)
+		require.Equal(t, []string{deploy.StackMetrics}, compose.Services["prometheus"].Profiles)
+		require.Nil(t, compose.Services["core"].Profiles)
+		require.Equal(t, []string{deploy.StackLogging}, compose.Services["kibana"].Profiles)
+		require.Equal(t, []string{deploy.StackDashboards}, compose.Services["grafana"].Profiles)
+
+		uis, err := stackUIs(dir, []string{deploy.StackMetrics}, "localhost")
+		require.NoError(t, err)
+		require.Equal(t, []stackUI{{Name: "Prometheus", URL: "http://localhost:9090"}}, uis)
+	})
+
+	t.Run("should drop the dependencies on prometheus without the metrics stack", func(t *testing.T) {
+		setup(t)
+		require.NoError(t, composeStacks(dir, []string{}))
+
+		compose, err := readCompose(dir)
+		require.NoError(t, err)
+		out, err := compose.Marshal()
+		require.NoError(t, err)
+		require.Contains(t, string(out), "- postgres")
+		require.NotContains(t, string(out), "- prometheus")
+	})
+
+	t.Run("should add the logging and dashboards stacks", func(t *testing.T) {
+		setup(t)
+		enabled := []string{deploy.StackLogging, deploy.StackMetrics, deploy.StackDashboards}
+		require.NoError(t, composeStacks(dir, enabled))
+
+		compose, err := readCompose(dir)
+		require.NoError(t, err)
+		require.Equal(t, []string{deploy.StackLogging}, compose.Services["kibana"].Profiles)
+		require.Equal(t, "./filebeat/filebeat.yml", compose.Services["filebeat"].Volumes[0].Source)
+		require.Equal(t, []string{deploy.StackDashboards}, compose.Services["grafana"].Profiles)
+
+		_, _, err = homedir.ReadFromConfigDir(path.Join(dir, deploy.GrafanaDir, "provisioning", "datasources", "prometheus.yml"))
+		require.NoError(t, err)
+		_, _, err = homedir.ReadFromConfigDir(path.Join(dir, deploy.GrafanaDir, "dashboards", "funless.json"))
+		require.NoError(t, err)
+
+		uis, err := stackUIs(dir, enabled, "localhost")
+		require.NoError(t, err)
+		require.Equal(t, []stackUI{
+			{Name: "Prometheus", URL: "http://localhost:9090"},
+			{Name: "Kibana", URL: "http://localhost:5601"},
+			{Name: "Grafana dashboards", URL: "http://localhost:3000"},
+		}, uis)
+	})
+
+	t.Run("should list the services of the disabled stacks", func(t *testing.T) {
+		setup(t)
+		require.NoError(t, composeStacks(dir, []string{deploy.StackMetrics}))
+
+		profiles, services, err := disabledStackServices(dir, []string{deploy.StackMetrics})
+		require.NoError(t, err)
+		require.Equal(t, []string{deploy.StackDashboards, deploy.StackLogging}, profiles)
+		require.Equal(t, []string{"filebeat", "grafana", "kibana"}, services)
+
+		profiles, services, err = disabledStackServices(dir, deploy.Stacks)
+		require.NoError(t, err)
+		require.Empty(t, profiles)
+		require.Empty(t, services)
+	})
+
+	t.Run("should fail when metrics is enabled without a prometheus service", func(t *testing.T) {
+		setup(t)
+		_, err := homedir.WriteToConfigDir(path.Join(dir, "docker-compose.yml"), []byte("services:\n  core:\n    image: core\n"), true)
+		require.NoError(t, err)
+		require.ErrorContains(t, composeStacks(dir, []string{deploy.StackMetrics}), "no prometheus service found")
+	})
+}
@@ -19,11 +19,13 @@ import (
 	"context"
 	"errors"
 	"os"
+	"path"
 	"path/filepath"
 	"testing"
 
 	"github.com/funlessdev/fl-cli/pkg"
 	"github.com/funlessdev/fl-cli/pkg/client"
+	"github.com/funlessdev/fl-cli/pkg/deploy"
 	"github.com/funlessdev/fl-cli/pkg/homedir"
 	"github.com/funlessdev/fl-cli/pkg/log"
 	"github.com/funlessdev/fl-cli/test/mocks"
@@ -41,7 +43,7 @@ func TestDockerUpRun(t *testing.T) {
 		os.RemoveAll(homedirPath)
 	}()
 
-	up := Up{}
+	up := Up{Name: "fl"}
 	ctx := context.TODO()
 
 	mockDockerShell := mocks.NewDockerShell(t)
@@ -59,8 +61,9 @@ func TestDockerUpRun(t *testing.T) {
 		homedir.GetHomeDir = func() (string, error) {
 			return homedirPath, nil
 		}
-		mockDockerShell.On("ComposeUp", mock.Anything, mock.Anything).Return(nil).Once()
-		mockDockerShell.On("LogTokens", mock.Anything).Return(nil).Once()
+		mockDockerShell.On("ComposeRemove", mock.Anything, "fl", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+		mockDockerShell.On("ComposeUp", mock.Anything, "fl", mock.Anything).Return(nil).Once()
+		mockDockerShell.On("LogTokens", mock.Anything, "fl").Return(nil).Once()
 		err := up.Run(ctx, mockDockerShell, logger, client.Config{})
 		require.NoError(t, err)
 
@@ -69,15 +72,17 @@ func TestDockerUpRun(t *testing.T) {
 
 	t.Run("should return error when compose up fails", func(t *testing.T) {
 		out.Reset()
-		mockDockerShell.On("ComposeUp", mock.Anything, mock.Anything).Return(errors.New("compose up error")).Once()
+		mockDockerShell.On("ComposeRemove", mock.Anything, "fl", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+		mockDockerShell.On("ComposeUp", mock.Anything, "fl", mock.Anything).Return(errors.New("compose up error")).Once()
 		err := up.Run(ctx, mockDockerShell, logger, client.Config{})
 		require.Error(t, err)
 	})
 
 	t.Run("should modify docker-compose.yml when given custom core/worker", func(t *testing.T) {
 		out.Reset()
-		mockDockerShell.On("ComposeUp", mock.Anything, mock.Anything).Return(nil).Once()
-		mockDockerShell.On("LogTokens", mock.Anything).Return(nil).Once()
+		mockDockerShell.On("ComposeRemove", mock.Anything, "fl", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+		mockDockerShell.On("ComposeUp", mock.Anything, "fl", mock.Anything).Return(nil).Once()
+		mockDockerShell.On("LogTokens", mock.Anything, "fl").Return(nil).Once()
 		_, path, err := homedir.ReadFromConfigDir("docker-compose.yml")
 		require.NoError(t, err)
 		os.Remove(path)
@@ -108,12 +113,12 @@ func Test_downloadFile(t *testing.T) {
 	}()
 
 	// Download it for the first time
-	path, err := downloadFile("docker-compose.yml", dockerComposeYmlUrl)
+	path, err := downloadFile("", "docker-compose.yml", dockerComposeYmlUrl)
 	require.NoError(t, err)
 	require.FileExists(t, path)
 
 	// Now that it exists it should not give errors
-	path, err = downloadFile("docker-compose.yml", dockerComposeYmlUrl)
+	path, err = downloadFile("", "docker-compose.yml", dockerComposeYmlUrl)
 	require.NoError(t, err)
 	require.FileExists(t, path)
 }
@@ -130,13 +135,13 @@ func Test_downloadPrometheusConfig(t *testing.T) {
 		os.RemoveAll(homedirPath)
 	}()
 
-	err = downloadFolderFile("prometheus", "config.yml", prometheusConfigYmlUrl)
+	err = downloadFolderFile("", "prometheus", "config.yml", prometheusConfigYmlUrl)
 	require.NoError(t, err)
 
 	require.DirExists(t, filepath.Join(homedirPath, ".fl", "prometheus"))
 	require.FileExists(t, filepath.Join(homedirPath, ".fl", "prometheus", "config.yml"))
 
-	err = downloadFolderFile("prometheus", "config.yml", prometheusConfigYmlUrl)
+	err = downloadFolderFile("", "prometheus", "config.yml", prometheusConfigYmlUrl)
 	require.NoError(t, err)
 }
 
@@ -153,16 +158,25 @@ func Test_replaceImages(t *testing.T) {
 	}()
 
 	t.Run("should return error when docker-compose.yml file is not found", func(t *testing.T) {
-		err := replaceImages("core-test", "worker-test")
+		err := replaceImages("", "core-test", "worker-test")
 		require.Error(t, err)
 	})
 
+	t.Run("should return error when the core or worker service is missing", func(t *testing.T) {
+		path, err := homedir.WriteToConfigDir("docker-compose.yml", []byte("services:\n  worker:\n    image: worker\n"), true)
+		require.NoError(t, err)
+		defer os.Remove(path)
+
+		err = replaceImages("", "core-test", "worker-test")
+		require.ErrorContains(t, err, `service "core" not found`)
+	})
+
 	t.Run("should swap core image when different from default", func(t *testing.T) {
-		path, err := downloadFile("docker-compose.yml", dockerComposeYmlUrl)
+		path, err := downloadFile("", "docker-compose.yml", dockerComposeYmlUrl)
 		require.NoError(t, err)
 		defer os.Remove(path)
 
-		err = replaceImages("core-test", pkg.WorkerImg)
+		err = replaceImages("", "core-test", pkg.WorkerImg)
 		require.NoError(t, err)
 
 		content, _, err := homedir.ReadFromConfigDir("docker-compose.yml")
@@ -186,11 +200,11 @@ func Test_replaceImages(t *testing.T) {
 	})
 
 	t.Run("should swap worker image when different from default", func(t *testing.T) {
-		path, err := downloadFile("docker-compose.yml", dockerComposeYmlUrl)
+		path, err := downloadFile("", "docker-compose.yml", dockerComposeYmlUrl)
 		require.NoError(t, err)
 		defer os.Remove(path)
 
-		err = replaceImages(pkg.CoreImg, "worker-test")
+		err = replaceImages("", pkg.CoreImg, "worker-test")
 		require.NoError(t, err)
 
 		content, _, err := homedir.ReadFromConfigDir("docker-compose.yml")
@@ -214,11 +228,11 @@ func Test_replaceImages(t *testing.T) {
 	})
 
 	t.Run("should swap both images when different from default", func(t *testing.T) {
-		path, err := downloadFile("docker-compose.yml", dockerComposeYmlUrl)
+		path, err := downloadFile("", "docker-compose.yml", dockerComposeYmlUrl)
 		require.NoError(t, err)
 		defer os.Remove(path)
 
-		err = replaceImages("core-test", "worker-test")
+		err = replaceImages("", "core-test", "worker-test")
 		require.NoError(t, err)
 
 		content, _, err := homedir.ReadFromConfigDir("docker-compose.yml")
@@ -248,3 +262,357 @@ func testLogger() (*bytes.Buffer, log.FLogger) {
 	testLogger, _ := log.NewLoggerBuilder().WithWriter(&outbuf).DisableAnimation().Build()
 	return &outbuf, testLogger
 }
+
+const testComposeYml = `services:
+  core:
+    image: ghcr.io/funlessdev/core:latest
+    depends_on:
+      - postgres
+      - prometheus
+    environment:
+      - PGHOST=postgres
+      - SECRET_KEY_BASE=${SECRET_KEY_BASE}
+  init:
+    image: ghcr.io/funlessdev/core:latest
+    depends_on:
+      postgres:
+        condition: service_healthy
+  worker:
+    image: ghcr.io/funlessdev/worker:latest
+    depends_on:
+      - core
+  postgres:
+    image: postgres:latest
+  prometheus:
+    image: prom/prometheus
+`
+
+func Test_useExternalDatabase(t *testing.T) {
+	homedirPath, err := os.MkdirTemp("", "funless-test-homedir-")
+	require.NoError(t, err)
+
+	homedir.GetHomeDir = func() (string, error) {
+		return homedirPath, nil
+	}
+	defer func() {
+		homedir.GetHomeDir = os.UserHomeDir
+		os.RemoveAll(homedirPath)
+	}()
+
+	t.Run("should return error when docker-compose.yml file is not found", func(t *testing.T) {
+		err := useExternalDatabase("", map[string]string{"DATABASE_URL": "postgres://db/fl"})
+		require.Error(t, err)
+	})
+
+	t.Run("should remove postgres and point its dependants to the .env values", func(t *testing.T) {
+		_, err := homedir.WriteToConfigDir("docker-compose.yml", []byte(testComposeYml), true)
+		require.NoError(t, err)
+
+		err = useExternalDatabase("", map[string]string{"DATABASE_URL": "postgres://db/fl", "PGHOST": "db"})
+		require.NoError(t, err)
+
+		content, _, err := homedir.ReadFromConfigDir("docker-compose.yml")
+		require.NoError(t, err)
+
+		var compose map[string]map[string]map[string]interface{}
+		require.NoError(t, yaml.Unmarshal(content, &compose))
+
+		services := compose["services"]
+		require.NotContains(t, services, "postgres")
+
+		require.Equal(t, []interface{}{"prometheus"}, services["core"]["depends_on"])
+		require.Equal(t, []interface{}{"SECRET_KEY_BASE=${SECRET_KEY_BASE}", "DATABASE_URL=${DATABASE_URL}", "PGHOST=${PGHOST}"}, services["core"]["environment"])
+
+		require.NotContains(t, services["init"], "depends_on")
+		require.Equal(t, map[interface{}]interface{}{"DATABASE_URL": "${DATABASE_URL}", "PGHOST": "${PGHOST}"}, services["init"]["environment"])
+
+		require.Equal(t, []interface{}{"core"}, services["worker"]["depends_on"])
+		require.NotContains(t, services["worker"], "environment")
+	})
+}
+
+func Test_resetExternalDatabase(t *testing.T) {
+	homedirPath := t.TempDir()
+	homedir.GetHomeDir = func() (string, error) {
+		return homedirPath, nil
+	}
+	defer func() {
+		homedir.GetHomeDir = os.UserHomeDir
+	}()
+
+	t.Run("should do nothing without a compose file", func(t *testing.T) {
+		require.NoError(t, resetExternalDatabase(""))
+	})
+
+	t.Run("should keep the files of the bundled database", func(t *testing.T) {
+		composePath, err := homedir.WriteToConfigDir("docker-compose.yml", []byte(testComposeYml), true)
+		require.NoError(t, err)
+		envPath, err := homedir.WriteToConfigDir(".env", []byte("PGHOST=postgres\n"), true)
+		require.NoError(t, err)
+
+		require.NoError(t, resetExternalDatabase(""))
+		require.FileExists(t, composePath)
+		require.FileExists(t, envPath)
+	})
+
+	t.Run("should remove the files edited for an external database", func(t *testing.T) {
+		composePath, err := homedir.WriteToConfigDir("docker-compose.yml", []byte(testComposeYml), true)
+		require.NoError(t, err)
+		require.NoError(t, useExternalDatabase("", map[string]string{"DATABASE_URL": "postgres://db/fl"}))
+		require.NoError(t, setEnvFileValues("", map[string]string{"DATABASE_URL": "postgres://db/fl"}))
+
+		require.NoError(t, resetExternalDatabase(""))
+		require.NoFileExists(t, composePath)
+		require.NoFileExists(t, filepath.Join(filepath.Dir(composePath), ".env"))
+	})
+}
+
+func Test_setEnvFileValues(t *testing.T) {
+	homedirPath, err := os.MkdirTemp("", "funless-test-homedir-")
+	require.NoError(t, err)
+
+	homedir.GetHomeDir = func() (string, error) {
+		return homedirPath, nil
+	}
+	defer func() {
+		homedir.GetHomeDir = os.UserHomeDir
+		os.RemoveAll(homedirPath)
+	}()
+
+	t.Run("should create the .env file when missing", func(t *testing.T) {
+		err := setEnvFileValues("", map[string]string{"PGHOST": "db"})
+		require.NoError(t, err)
+
+		content, _, err := homedir.ReadFromConfigDir(".env")
+		require.NoError(t, err)
+		require.Equal(t, "PGHOST=db\n", string(content))
+	})
+
+	t.Run("should replace existing values and keep the others", func(t *testing.T) {
+		_, err := homedir.WriteToConfigDir(".env", []byte("# comment\nPGHOST=postgres\nOTHER=value\n"), true)
+		require.NoError(t, err)
+
+		err = setEnvFileValues("", map[string]string{"PGHOST": "db", "PGUSER": "fl"})
+		require.NoError(t, err)
+
+		content, _, err := homedir.ReadFromConfigDir(".env")
+		require.NoError(t, err)
+		require.Equal(t, "# comment\nOTHER=value\nPGHOST=db\nPGUSER=fl\n", string(content))
+	})
+}
+
+func TestDockerUpInvalidDatabaseURL(t *testing.T) {
+	mockDockerShell := mocks.NewDockerShell(t)
+	_, logger := testLogger()
+
+	up := Up{DatabaseURL: "mysql://db/funless", Name: "fl"}
+	err := up.Run(context.TODO(), mockDockerShell, logger, client.Config{})
+	require.ErrorContains(t, err, "unsupported scheme")
+	mockDockerShell.AssertNotCalled(t, "ComposeUp", mock.Anything, mock.Anything)
+}
+
+func TestDockerUpNamedInstance(t *testing.T) {
+	homedirPath, err := os.MkdirTemp("", "funless-test-homedir-")
+	require.NoError(t, err)
+
+	homedir.GetHomeDir = func() (string, error) {
+		return homedirPath, nil
+	}
+	defaultIsPortFree := isPortFree
+	isPortFree = func(port int) bool { return true }
+	defer func() {
+		homedir.GetHomeDir = os.UserHomeDir
+		isPortFree = defaultIsPortFree
+		os.RemoveAll(homedirPath)
+	}()
+
+	// assets already in the instance dir are not downloaded again
+	dir := deploy.DockerInstanceDir("fl-next")
+	for file, content := range map[string]string{
+		"docker-compose.yml":            testPortsComposeYml,
+		"prometheus/config.yml":         "",
+		"filebeat/filebeat.compose.yml": testFilebeatComposeYml,
+		".env":                          "",
+	} {
+		_, err := homedir.CreateDirInConfigDir(path.Dir(path.Join(dir, file)))
+		require.NoError(t, err)
+		_, err = homedir.WriteToConfigDir(path.Join(dir, file), []byte(content), true)
+		require.NoError(t, err)
+	}
+
+	// the default stacks leave the logging and dashboards ones out
+	disabledProfiles := []string{deploy.StackDashboards, deploy.StackLogging}
+	disabledServices := []string{"filebeat", "grafana", "kibana"}
+
+	t.Run("should reject invalid instance names", func(t *testing.T) {
+		_, logger := testLogger()
+		up := Up{Name: "Not Valid"}
+		err := up.Run(context.TODO(), mocks.NewDockerShell(t), logger, client.Config{SecretKeyBase: "key"})
+		require.Error(t, err)
+	})
+
+	t.Run("should deploy the instance in its own project and ports", func(t *testing.T) {
+		out, logger := testLogger()
+		mockDockerShell := mocks.NewDockerShell(t)
+		composeFile := filepath.Join(homedirPath, pkg.ConfigDir, dir, "docker-compose.yml")
+		mockDockerShell.On("ComposeRemove", mock.Anything, "fl-next", composeFile, disabledProfiles, disabledServices).Return(nil).Once()
+		mockDockerShell.On("ComposeUp", mock.Anything, "fl-next", composeFile).Return(nil).Once()
+		mockDockerShell.On("LogTokens", mock.Anything, "fl-next").Return(nil).Once()
+
+		up := Up{Name: "fl-next", CoreImage: "core:next", WorkerImage: "worker:next"}
+		err := up.Run(context.TODO(), mockDockerShell, logger, client.Config{SecretKeyBase: "key"})
+		require.NoError(t, err)
+		require.Contains(t, out.String(), "The core API of fl-next is at http://localhost:4001")
+
+		registry, err := deploy.LoadRegistry()
+		require.NoError(t, err)
+		record, found := registry.Find("docker/fl-next")
+		require.True(t, found)
+		require.Equal(t, "http://localhost:4001", record.Host)
+		require.Equal(t, 1, record.PortOffset)
+		require.Equal(t, "core:next", record.CoreImage)
+
+		env, _, err := homedir.ReadFromConfigDir(path.Join(dir, ".env"))
+		require.NoError(t, err)
+		require.Contains(t, string(env), "COMPOSE_PROFILES=metrics")
+
+		// the default instance assets are untouched
+		_, _, err = homedir.ReadFromConfigDir("docker-compose.yml")
+		require.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("should remove the containers of the stacks dropped since the last up", func(t *testing.T) {
+		_, logger := testLogger()
+		mockDockerShell := mocks.NewDockerShell(t)
+		removeLogging := mockDockerShell.On("ComposeRemove", mock.Anything, "fl-next", mock.Anything, []string{deploy.StackLogging}, []string{"filebeat", "kibana"}).Return(nil).Once()
+		mockDockerShell.On("ComposeUp", mock.Anything, "fl-next", mock.Anything).Return(nil).Once().NotBefore(removeLogging)
+		mockDockerShell.On("LogTokens", mock.Anything, "fl-next").Return(nil).Twice()
+
+		up := Up{Name: "fl-next", CoreImage: "core:next", WorkerImage: "worker:next", With: []string{deploy.StackDashboards}}
+		require.NoError(t, up.Run(context.TODO(), mockDockerShell, logger, client.Config{SecretKeyBase: "key"}))
+
+		removeAll := mockDockerShell.On("ComposeRemove", mock.Anything, "fl-next", mock.Anything,
+			[]string{deploy.StackDashboards, deploy.StackLogging, deploy.StackMetrics},
+			[]string{"filebeat", "grafana", "kibana", "prometheus"}).Return(nil).Once()
+		mockDockerShell.On("ComposeUp", mock.Anything, "fl-next", mock.Anything).Return(nil).Once().NotBefore(removeAll)
+
+		up = Up{Name: "fl-next", CoreImage: "core:next", WorkerImage: "worker:next", Without: []string{deploy.StackMetrics}}
+		require.NoError(t, up.Run(context.TODO(), mockDockerShell, logger, client.Config{SecretKeyBase: "key"}))
+
+		env, _, err := homedir.ReadFromConfigDir(path.Join(dir, ".env"))
+		require.NoError(t, err)
+		require.Contains(t, string(env), "COMPOSE_PROFILES=\n")
+	})
+
+	t.Run("should apply the --set overrides last", func(t *testing.T) {
+		_, logger := testLogger()
+		mockDockerShell := mocks.NewDockerShell(t)
+		mockDockerShell.On("ComposeRemove", mock.Anything, "fl-next", mock.Anything, disabledProfiles, disabledServices).Return(nil).Once()
+		mockDockerShell.On("ComposeUp", mock.Anything, "fl-next", mock.Anything).Return(nil).Once()
+		mockDockerShell.On("LogTokens", mock.Anything, "fl-next").Return(nil).Once()
+
+		up := Up{Name: "fl-next", CoreImage: "core:next", WorkerImage: "worker:next", Set: []string{"core.image=core:patched", "core.ports=4100:4000"}}
+		err := up.Run(context.TODO(), mockDockerShell, logger, client.Config{SecretKeyBase: "key"})
+		require.NoError(t, err)
+
+		registry, err := deploy.LoadRegistry()
+		require.NoError(t, err)
+		record, _ := registry.Find("docker/fl-next")
+		require.Equal(t, "core:patched", record.CoreImage)
+		require.Equal(t, "http://localhost:4100", record.Host)
+	})
+
+	t.Run("should fail on invalid overrides without deploying", func(t *testing.T) {
+		_, logger := testLogger()
+		mockDockerShell := mocks.NewDockerShell(t)
+
+		up := Up{Name: "fl-next", CoreImage: "core:next", WorkerImage: "worker:next", Set: []string{"core.command=start"}}
+		err := up.Run(context.TODO(), mockDockerShell, logger, client.Config{SecretKeyBase: "key"})
+		require.ErrorContains(t, err, "unsupported override key")
+		mockDockerShell.AssertNotCalled(t, "ComposeUp", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("should copy the mounted assets to an ssh docker host", func(t *testing.T) {
+		out, logger := testLogger()
+		mockDockerShell := mocks.NewDockerShell(t)
+		localDir := filepath.Join(homedirPath, pkg.ConfigDir, dir)
+		mockDockerShell.On("UploadAssets", mock.Anything, "ssh://fl@test-box", localDir, []string{"filebeat/filebeat.yml", "grafana/dashboards", "grafana/provisioning", "prometheus/config.yml"}, ".fl/instances/fl-next").Return("/home/fl/.fl/instances/fl-next", nil).Once()
+		mockDockerShell.On("ComposeRemove", mock.Anything, "fl-next", mock.Anything, disabledProfiles, disabledServices).Return(nil).Once()
+		mockDockerShell.On("ComposeUp", mock.MatchedBy(func(ctx context.Context) bool {
+			env, _ := ctx.Value(pkg.FLContextKey("env")).(map[string]string)
+			return env["DOCKER_HOST"] == "ssh://fl@test-box"
+		}), "fl-next", mock.Anything).Return(nil).Once()
+		mockDockerShell.On("LogTokens", mock.Anything, "fl-next").Return(nil).Once()
+
+		up := Up{
+			Name:        "fl-next",
+			CoreImage:   "core:next",
+			WorkerImage: "worker:next",
+			DockerHost:  "ssh://fl@test-box",
+			Set:         []string{"prometheus.volumes=./prometheus/config.yml:/etc/prometheus/prometheus.yml"},
+		}
+		err := up.Run(context.TODO(), mockDockerShell, logger, client.Config{SecretKeyBase: "key"})
+		require.NoError(t, err)
+		require.Contains(t, out.String(), "The core API is at http://test-box:4100, saved as api_host in ~/.fl/config.")
+		require.Contains(t, out.String(), "Prometheus: http://test-box:9091")
+
+		compose, err := readCompose(dir)
+		require.NoError(t, err)
+		require.Equal(t, "/home/fl/.fl/instances/fl-next/prometheus/config.yml", compose.Services["prometheus"].Volumes[0].Source)
+
+		config, _, err := homedir.ReadFromConfigDir(pkg.ConfigFileName)
+		require.NoError(t, err)
+		require.Contains(t, string(config), "api_host=http://test-box:4100")
+
+		require.Equal(t, "ssh://fl@test-box", deploy.RecordedDockerHost("fl-next"))
+	})
+
+	t.Run("should deploy the settings of a spec file", func(t *testing.T) {
+		_, logger := testLogger()
+		mockDockerShell := mocks.NewDockerShell(t)
+		mockDockerShell.On("ComposeRemove", mock.Anything, "fl-next", mock.Anything, disabledProfiles, disabledServices).Return(nil).Once()
+		mockDockerShell.On("ComposeUp", mock.Anything, "fl-next", mock.Anything).Return(nil).Once()
+		mockDockerShell.On("LogTokens", mock.Anything, "fl-next").Return(nil).Once()
+
+		specFile := filepath.Join(t.TempDir(), "deploy.yaml")
+		err := os.WriteFile(specFile, []byte(`core:
+  image: core:spec
+worker:
+  replicas: 2
+  resources:
+    cpu: 500m
+exposure:
+  port: 4200
+`), 0644)
+		require.NoError(t, err)
+
+		up := Up{File: specFile, Name: "fl-next", CoreImage: "core:next", WorkerImage: "worker:next"}
+		err = up.Run(context.TODO(), mockDockerShell, logger, client.Config{SecretKeyBase: "key"})
+		require.NoError(t, err)
+
+		compose, err := readCompose(dir)
+		require.NoError(t, err)
+		require.Equal(t, "core:spec", compose.Services["core"].Image)
+		require.Equal(t, "worker:next", compose.Services["worker"].Image)
+		require.Equal(t, 2, *compose.Services["worker"].Deploy.Replicas)
+		require.Contains(t, compose.Services["worker"].Deploy.Extra, "resources")
+
+		registry, err := deploy.LoadRegistry()
+		require.NoError(t, err)
+		record, _ := registry.Find("docker/fl-next")
+		require.Equal(t, "http://localhost:4200", record.Host)
+	})
+
+	t.Run("should reject the settings docker does not support without deploying", func(t *testing.T) {
+		_, logger := testLogger()
+		mockDockerShell := mocks.NewDockerShell(t)
+
+		specFile := filepath.Join(t.TempDir(), "deploy.yaml")
+		require.NoError(t, os.WriteFile(specFile, []byte("exposure:\n  host: fl.example.com\n"), 0644))
+
+		up := Up{File: specFile, Name: "fl-next", CoreImage: "core:next", WorkerImage: "worker:next"}
+		err := up.Run(context.TODO(), mockDockerShell, logger, client.Config{SecretKeyBase: "key"})
+		require.ErrorContains(t, err, "the docker backend does not support exposure.host")
+		mockDockerShell.AssertNotCalled(t, "ComposeUp", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,157 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin_deploy_docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/funlessdev/fl-cli/pkg"
+	"github.com/funlessdev/fl-cli/pkg/client"
+	"github.com/funlessdev/fl-cli/pkg/deploy"
+	"github.com/funlessdev/fl-cli/pkg/docker"
+	"github.com/funlessdev/fl-cli/pkg/homedir"
+	"github.com/funlessdev/fl-cli/pkg/log"
+)
+
+type Upgrade struct {
+	CoreImage   string `name:"core" short:"c" help:"Core docker image to upgrade to" default:"${default_core_image}"`
+	WorkerImage string `name:"worker" short:"w" help:"Worker docker image to upgrade to" default:"${default_worker_image}"`
+	Name        string `name:"name" short:"n" help:"Name of the local instance to upgrade" default:"fl"`
+	DockerHost  string `name:"docker-host" env:"DOCKER_HOST" help:"Docker host the instance runs on (the one it was deployed to by default)"`
+}
+
+func (u *Upgrade) Help() string {
+	return `
+DESCRIPTION
+
+	It upgrades a running local Docker-based FunLess deployment in place,
+	keeping its data and configuration.
+	The "--core" and "--worker" flags can be used to choose the images
+	to upgrade to, the default ones are used otherwise.
+	Only the services whose image changed are recreated. When the core
+	changes, the database migrations are run with the new image first.
+	The "--name" flag selects the instance created with "up --name".
+	Instances deployed with "up --docker-host" are upgraded on the same
+	Docker host.
+
+EXAMPLES
+
+	$ fl admin deploy docker upgrade --core <your-core-image> --worker <your-worker-image>`
+}
+
+func (u *Upgrade) Run(ctx context.Context, dk deploy.DockerShell, logger log.FLogger, config client.Config) error {
+	if err := deploy.ValidateDockerInstanceName(u.Name); err != nil {
+		return err
+	}
+	dir := deploy.DockerInstanceDir(u.Name)
+
+	_, composeFilePath, err := homedir.ReadFromConfigDir(path.Join(dir, "docker-compose.yml"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return errors.New("no local deployment found, nothing to upgrade. Use \"fl admin deploy docker up\" to create one.")
+		}
+		return errors.New("unable to read docker-compose.yml file")
+	}
+
+	currentCore, currentWorker, err := composeImages(dir)
+	if err != nil {
+		return err
+	}
+
+	var changed []string
+	if currentCore != u.CoreImage {
+		changed = append(changed, "core")
+	}
+	if currentWorker != u.WorkerImage {
+		changed = append(changed, "worker")
+	}
+	if len(changed) == 0 {
+		logger.Info("Core and worker are already up to date, nothing to upgrade.\n")
+		return nil
+	}
+
+	logger.Info("Upgrading local FunLess deployment...\n\n")
+
+	dockerHost := u.DockerHost
+	if dockerHost == "" {
+		dockerHost = deploy.RecordedDockerHost(u.Name)
+	}
+
+	cmdEnv := map[string]string{"SECRET_KEY_BASE": config.SecretKeyBase, docker.HostEnv: dockerHost}
+	ctx = context.WithValue(ctx, pkg.FLContextKey("env"), cmdEnv)
+
+	_ = logger.StartSpinner("Updating images in docker-compose.yml...")
+	if err := logger.StopSpinner(replaceImages(dir, u.CoreImage, u.WorkerImage)); err != nil {
+		return err
+	}
+
+	if currentCore != u.CoreImage {
+		logger.Info("\nRunning database migrations...\n\n")
+		if err := dk.ComposeRun(ctx, u.Name, composeFilePath, "core", deploy.CoreMigrateCmd); err != nil {
+			// leave the compose file matching the running services
+			_ = replaceImages(dir, currentCore, currentWorker)
+			return err
+		}
+	}
+
+	logger.Infof("\nRecreating %s...\n\n", strings.Join(changed, " and "))
+	if err := dk.ComposeRecreate(ctx, u.Name, composeFilePath, changed...); err != nil {
+		return err
+	}
+
+	registry, err := deploy.LoadRegistry()
+	if err == nil {
+		record, found := registry.Find(dockerDeploymentID(u.Name))
+		if !found {
+			record = deploy.Deployment{Backend: "docker", Project: u.Name, DockerHost: dockerHost, AssetsRef: deploy.AssetsRef}
+			address, addressErr := deploy.DockerHostAddress(dockerHost)
+			if port, err := coreHostPort(dir); err == nil && addressErr == nil {
+				record.Host = fmt.Sprintf("http://%s:%d", address, port)
+			}
+		}
+		record.CoreImage = u.CoreImage
+		record.WorkerImage = u.WorkerImage
+		err = deploy.RecordDeployment(record)
+	}
+	if err != nil {
+		logger.Infof("\nCouldn't record the upgrade in ~/.fl/%s: %v\n", deploy.RegistryFileName, err)
+	}
+
+	logger.Info("\nUpgrade complete! 🎉\n")
+	return nil
+}
+
+// composeImages returns the core and worker images of the compose file in the instance dir
+func composeImages(dir string) (string, string, error) {
+	compose, err := readCompose(dir)
+	if err != nil {
+		return "", "", err
+	}
+
+	core, err := compose.Service("core")
+	if err != nil {
+		return "", "", err
+	}
+	worker, err := compose.Service("worker")
+	if err != nil {
+		return "", "", err
+	}
+	return core.Image, worker.Image, nil
+}
@@ -0,0 +1,117 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin_deploy_docker
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/funlessdev/fl-cli/pkg/client"
+	"github.com/funlessdev/fl-cli/pkg/deploy"
+	"github.com/funlessdev/fl-cli/pkg/homedir"
+	"github.com/funlessdev/fl-cli/test/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDockerUpgradeRun(t *testing.T) {
+	homedirPath, err := os.MkdirTemp("", "funless-test-homedir-")
+	require.NoError(t, err)
+
+	homedir.GetHomeDir = func() (string, error) {
+		return homedirPath, nil
+	}
+	defer func() {
+		homedir.GetHomeDir = os.UserHomeDir
+		os.RemoveAll(homedirPath)
+	}()
+
+	ctx := context.TODO()
+	config := client.Config{SecretKeyBase: "test-secret"}
+
+	t.Run("should return error when no deployment is found", func(t *testing.T) {
+		_, logger := testLogger()
+		upgrade := Upgrade{CoreImage: "core:new", WorkerImage: "worker:new", Name: "fl"}
+
+		err := upgrade.Run(ctx, mocks.NewDockerShell(t), logger, config)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "no local deployment found")
+	})
+
+	t.Run("should do nothing when the images are unchanged", func(t *testing.T) {
+		_, err := homedir.WriteToConfigDir("docker-compose.yml", []byte(testComposeYml), true)
+		require.NoError(t, err)
+
+		out, logger := testLogger()
+		upgrade := Upgrade{CoreImage: "ghcr.io/funlessdev/core:latest", WorkerImage: "ghcr.io/funlessdev/worker:latest", Name: "fl"}
+
+		err = upgrade.Run(ctx, mocks.NewDockerShell(t), logger, config)
+		require.NoError(t, err)
+		require.Contains(t, out.String(), "already up to date")
+	})
+
+	t.Run("should only recreate the worker when only the worker changes", func(t *testing.T) {
+		path, err := homedir.WriteToConfigDir("docker-compose.yml", []byte(testComposeYml), true)
+		require.NoError(t, err)
+
+		_, logger := testLogger()
+		dk := mocks.NewDockerShell(t)
+		dk.On("ComposeRecreate", mock.Anything, "fl", path, "worker").Return(nil).Once()
+
+		upgrade := Upgrade{CoreImage: "ghcr.io/funlessdev/core:latest", WorkerImage: "worker:new", Name: "fl"}
+		err = upgrade.Run(ctx, dk, logger, config)
+		require.NoError(t, err)
+
+		core, worker, err := composeImages("")
+		require.NoError(t, err)
+		require.Equal(t, "ghcr.io/funlessdev/core:latest", core)
+		require.Equal(t, "worker:new", worker)
+	})
+
+	t.Run("should run migrations before recreating a changed core", func(t *testing.T) {
+		path, err := homedir.WriteToConfigDir("docker-compose.yml", []byte(testComposeYml), true)
+		require.NoError(t, err)
+
+		out, logger := testLogger()
+		dk := mocks.NewDockerShell(t)
+		migrate := dk.On("ComposeRun", mock.Anything, "fl", path, "core", deploy.CoreMigrateCmd).Return(nil).Once()
+		dk.On("ComposeRecreate", mock.Anything, "fl", path, "core", "worker").Return(nil).Once().NotBefore(migrate)
+
+		upgrade := Upgrade{CoreImage: "core:new", WorkerImage: "worker:new", Name: "fl"}
+		err = upgrade.Run(ctx, dk, logger, config)
+		require.NoError(t, err)
+		require.Contains(t, out.String(), "Upgrade complete!")
+	})
+
+	t.Run("should restore the previous images when migrations fail", func(t *testing.T) {
+		path, err := homedir.WriteToConfigDir("docker-compose.yml", []byte(testComposeYml), true)
+		require.NoError(t, err)
+
+		_, logger := testLogger()
+		dk := mocks.NewDockerShell(t)
+		dk.On("ComposeRun", mock.Anything, "fl", path, "core", deploy.CoreMigrateCmd).Return(errors.New("migration error")).Once()
+
+		upgrade := Upgrade{CoreImage: "core:new", WorkerImage: "worker:new", Name: "fl"}
+		err = upgrade.Run(ctx, dk, logger, config)
+		require.Error(t, err)
+
+		core, worker, err := composeImages("")
+		require.NoError(t, err)
+		require.Equal(t, "ghcr.io/funlessdev/core:latest", core)
+		require.Equal(t, "ghcr.io/funlessdev/worker:latest", worker)
+	})
+}
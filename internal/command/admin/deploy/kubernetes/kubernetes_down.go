@@ -31,7 +31,7 @@ DESCRIPTION
 
 	It removes a Kubernetes FunLess deployment.
 	The "--kubeconfig" flag can be used to specify the absolute path 
-	to the kubeconfig file.
+	to the kubeconfig file, the one recorded by "up" is used otherwise.
 
 EXAMPLES
 
@@ -42,7 +42,7 @@ func (k *Down) Run(ctx context.Context, remover deploy.KubernetesRemover, logger
 	logger.Info("Removing Kubernetes FunLess deployment...\n\n")
 
 	_ = logger.StartSpinner("Setting things up...")
-	if err := logger.StopSpinner(remover.WithConfig(k.KubeConfig)); err != nil {
+	if err := logger.StopSpinner(remover.WithConfig(kubeConfigOrRecorded(k.KubeConfig))); err != nil {
 		return err
 	}
 
@@ -51,7 +51,28 @@ func (k *Down) Run(ctx context.Context, remover deploy.KubernetesRemover, logger
 		return err
 	}
 
+	if err := deploy.ForgetDeployment(kubernetesDeploymentID()); err != nil {
+		logger.Infof("\nCouldn't remove the deployment from ~/.fl/%s: %v\n", deploy.RegistryFileName, err)
+	}
+
 	logger.Info("\nAll clear!\n")
 
 	return nil
 }
+
+func kubernetesDeploymentID() string {
+	return deploy.Deployment{Backend: "kubernetes", Namespace: deploy.KubernetesNamespace}.ID()
+}
+
+// kubeConfigOrRecorded returns kubeConfig, or the kubeconfig recorded for the deployment when empty
+func kubeConfigOrRecorded(kubeConfig string) string {
+	if kubeConfig != "" {
+		return kubeConfig
+	}
+	registry, err := deploy.LoadRegistry()
+	if err != nil {
+		return ""
+	}
+	record, _ := registry.Find(kubernetesDeploymentID())
+	return record.KubeConfig
+}
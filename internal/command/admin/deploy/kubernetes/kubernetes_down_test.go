@@ -19,6 +19,7 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/funlessdev/fl-cli/pkg/deploy"
 	"github.com/funlessdev/fl-cli/test/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -26,6 +27,7 @@ import (
 )
 
 func TestKubernetesDownRun(t *testing.T) {
+	useTestHomeDir(t)
 	k8sRm := Down{}
 	ctx := context.TODO()
 
@@ -69,3 +71,23 @@ All clear!
 	})
 
 }
+
+func TestKubernetesDownRegistry(t *testing.T) {
+	useTestHomeDir(t)
+	ctx := context.TODO()
+
+	err := deploy.RecordDeployment(deploy.Deployment{Backend: "kubernetes", Namespace: deploy.KubernetesNamespace, KubeConfig: "/recorded/kubeconfig"})
+	require.NoError(t, err)
+
+	mockRemover := mocks.NewKubernetesRemover(t)
+	mockRemover.On("WithConfig", "/recorded/kubeconfig").Return(nil).Once()
+	mockRemover.On("RemoveNamespace", mock.Anything).Return(nil).Once()
+
+	_, logger := testLogger()
+	err = (&Down{}).Run(ctx, mockRemover, logger)
+	require.NoError(t, err)
+
+	registry, err := deploy.LoadRegistry()
+	require.NoError(t, err)
+	require.Empty(t, registry.Deployments)
+}
@@ -0,0 +1,119 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin_deploy_kubernetes
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/funlessdev/fl-cli/pkg/client"
+	"github.com/funlessdev/fl-cli/pkg/deploy"
+	"github.com/funlessdev/fl-cli/pkg/log"
+)
+
+type PortForward struct {
+	KubeConfig string `name:"kubeconfig" short:"k" help:"Absolute path to the kubeconfig file"`
+	LocalPort  int    `name:"local-port" short:"p" default:"4000" help:"Local port forwarded to the core API"`
+	SetHost    bool   `name:"set-host" help:"Set api_host in the config to the forwarded address while the forward is open"`
+}
+
+func (f *PortForward) Help() string {
+	return `
+DESCRIPTION
+
+	It forwards a local port to the FunLess Core API running in a
+	Kubernetes deployment, until interrupted with Ctrl+C.
+	The "--kubeconfig" flag can be used to specify the absolute path 
+	to the kubeconfig file.
+	The "--local-port" flag can be used to choose a local port other
+	than the default one (4000).
+	With "--set-host", api_host in ~/.fl/config points to the forwarded
+	address while the forward is open, and is restored afterwards (or
+	removed, when it was not set).
+
+EXAMPLES
+
+	$ fl admin deploy kubernetes port-forward --local-port 8080 --set-host`
+}
+
+func (p *PortForward) Run(ctx context.Context, forwarder deploy.KubernetesForwarder, logger log.FLogger, config client.Config) error {
+	logger.Info("Forwarding FunLess Core API...\n\n")
+
+	_ = logger.StartSpinner("Setting things up...")
+	if err := logger.StopSpinner(forwarder.WithConfig(p.KubeConfig)); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ready := make(chan struct{})
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- forwarder.ForwardCore(ctx, p.LocalPort, ready, io.Discard, os.Stderr)
+	}()
+
+	_ = logger.StartSpinner("Opening port-forward to fl-core...")
+	select {
+	case <-ready:
+		_ = logger.StopSpinner(nil)
+	case err := <-errCh:
+		if err == nil {
+			err = fmt.Errorf("port-forward closed before being ready")
+		}
+		return logger.StopSpinner(err)
+	}
+
+	host := fmt.Sprintf("http://localhost:%d", p.LocalPort)
+	if p.SetHost {
+		previousHost := config.Host
+		// a default api_host is removed afterwards rather than written, so it keeps following the default
+		hadHost, err := client.HasConfigValue(config, "api_host")
+		if err == nil {
+			_, err = client.SetConfigValue(config, "api_host", host)
+		}
+		if err != nil {
+			stop()
+			<-errCh
+			return err
+		}
+		defer func() {
+			if !hadHost {
+				if _, err := client.UnsetConfigValue(config, "api_host"); err != nil {
+					logger.Infof("Couldn't remove api_host from ~/.fl/config: %v\n", err)
+				} else {
+					logger.Info("api_host removed from ~/.fl/config, back to the default.\n")
+				}
+				return
+			}
+			if _, err := client.SetConfigValue(config, "api_host", previousHost); err != nil {
+				logger.Infof("Couldn't restore api_host to %s: %v\n", previousHost, err)
+			} else {
+				logger.Infof("api_host restored to %s.\n", previousHost)
+			}
+		}()
+		logger.Infof("api_host temporarily set to %s.\n", host)
+	}
+
+	logger.Infof("\nForwarding %s to the FunLess Core API. Press Ctrl+C to stop.\n", host)
+
+	err := <-errCh
+	logger.Info("\nPort-forward closed.\n")
+	return err
+}
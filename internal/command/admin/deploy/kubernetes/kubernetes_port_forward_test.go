@@ -0,0 +1,161 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin_deploy_kubernetes
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/funlessdev/fl-cli/pkg/client"
+	"github.com/funlessdev/fl-cli/pkg/homedir"
+	"github.com/funlessdev/fl-cli/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKubernetesPortForwardRun(t *testing.T) {
+	pf := PortForward{LocalPort: 8080}
+	ctx := context.TODO()
+
+	closeReady := func(args mock.Arguments) {
+		close(args.Get(2).(chan struct{}))
+	}
+
+	t.Run("should return error when setting up Forwarder fails", func(t *testing.T) {
+		mockForwarder := mocks.NewKubernetesForwarder(t)
+		mockForwarder.On("WithConfig", mock.Anything).Return(errors.New("error")).Once()
+
+		_, logger := testLogger()
+		err := pf.Run(ctx, mockForwarder, logger, client.Config{})
+		require.Error(t, err)
+		mockForwarder.AssertNotCalled(t, "ForwardCore")
+	})
+
+	t.Run("should return error when the forward fails before being ready", func(t *testing.T) {
+		mockForwarder := mocks.NewKubernetesForwarder(t)
+		mockForwarder.On("WithConfig", mock.Anything).Return(nil)
+		mockForwarder.On("ForwardCore", mock.Anything, 8080, mock.Anything, mock.Anything, mock.Anything).Return(errors.New("no pods")).Once()
+
+		_, logger := testLogger()
+		err := pf.Run(ctx, mockForwarder, logger, client.Config{})
+		require.EqualError(t, err, "no pods")
+	})
+
+	t.Run("successful prints when the forward is closed", func(t *testing.T) {
+		mockForwarder := mocks.NewKubernetesForwarder(t)
+		mockForwarder.On("WithConfig", mock.Anything).Return(nil)
+		mockForwarder.On("ForwardCore", mock.Anything, 8080, mock.Anything, mock.Anything, mock.Anything).Run(closeReady).Return(nil).Once()
+
+		outbuf, logger := testLogger()
+		err := pf.Run(ctx, mockForwarder, logger, client.Config{})
+
+		expectedOutput := `Forwarding FunLess Core API...
+
+Setting things up...
+done
+Opening port-forward to fl-core...
+done
+
+Forwarding http://localhost:8080 to the FunLess Core API. Press Ctrl+C to stop.
+
+Port-forward closed.
+`
+		assert.NoError(t, err)
+		assert.Equal(t, expectedOutput, outbuf.String())
+	})
+
+	t.Run("should set and restore api_host when set-host is given", func(t *testing.T) {
+		homedirPath, err := os.MkdirTemp("", "funless-test-homedir-")
+		require.NoError(t, err)
+		homedir.GetHomeDir = func() (string, error) {
+			return homedirPath, nil
+		}
+		defer func() {
+			homedir.GetHomeDir = os.UserHomeDir
+			os.RemoveAll(homedirPath)
+		}()
+
+		_, err = homedir.WriteToConfigDir("config", []byte("api_host=http://previous:4000\n"), true)
+		require.NoError(t, err)
+		config, err := client.NewConfig("config")
+		require.NoError(t, err)
+
+		mockForwarder := mocks.NewKubernetesForwarder(t)
+		mockForwarder.On("WithConfig", mock.Anything).Return(nil)
+		mockForwarder.On("ForwardCore", mock.Anything, 8080, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			closeReady(args)
+			<-args.Get(0).(context.Context).Done()
+		}).Return(nil).Once()
+
+		// read api_host while the forward is open, then close it
+		cancelCtx, cancel := context.WithCancel(ctx)
+		var hostDuringForward string
+		go func() {
+			defer cancel()
+			for i := 0; i < 200; i++ {
+				if c, err := client.NewConfig("config"); err == nil && c.Host != "http://previous:4000" {
+					hostDuringForward = c.Host
+					return
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+		}()
+
+		_, logger := testLogger()
+		withHost := PortForward{LocalPort: 8080, SetHost: true}
+		err = withHost.Run(cancelCtx, mockForwarder, logger, config)
+		require.NoError(t, err)
+
+		assert.Equal(t, "http://localhost:8080", hostDuringForward)
+		restored, err := client.NewConfig("config")
+		require.NoError(t, err)
+		assert.Equal(t, "http://previous:4000", restored.Host)
+	})
+
+	t.Run("should remove api_host on exit when it was not set", func(t *testing.T) {
+		homedirPath := t.TempDir()
+		homedir.GetHomeDir = func() (string, error) {
+			return homedirPath, nil
+		}
+		defer func() {
+			homedir.GetHomeDir = os.UserHomeDir
+		}()
+
+		_, err := homedir.WriteToConfigDir("config", []byte("api_token=token\n"), true)
+		require.NoError(t, err)
+		config, err := client.NewConfig("config")
+		require.NoError(t, err)
+
+		mockForwarder := mocks.NewKubernetesForwarder(t)
+		mockForwarder.On("WithConfig", mock.Anything).Return(nil)
+		mockForwarder.On("ForwardCore", mock.Anything, 8080, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			closeReady(args)
+		}).Return(nil).Once()
+
+		outbuf, logger := testLogger()
+		withHost := PortForward{LocalPort: 8080, SetHost: true}
+		err = withHost.Run(ctx, mockForwarder, logger, config)
+		require.NoError(t, err)
+
+		content, _, err := homedir.ReadFromConfigDir("config")
+		require.NoError(t, err)
+		assert.Equal(t, "api_token=token\n", string(content))
+		assert.Contains(t, outbuf.String(), "api_host removed from ~/.fl/config")
+	})
+}
@@ -17,16 +17,36 @@ package admin_deploy_kubernetes
 import (
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/funlessdev/fl-cli/pkg"
 	"github.com/funlessdev/fl-cli/pkg/client"
+	"github.com/funlessdev/fl-cli/pkg/deploy"
+	"github.com/funlessdev/fl-cli/pkg/homedir"
 	"github.com/funlessdev/fl-cli/test/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
+const testSecretKeyBase = "test-secret-key-base"
+
+// useTestHomeDir points the config dir to a temporary one for the duration of the test
+func useTestHomeDir(t *testing.T) string {
+	homedirPath := t.TempDir()
+	homedir.GetHomeDir = func() (string, error) {
+		return homedirPath, nil
+	}
+	t.Cleanup(func() {
+		homedir.GetHomeDir = os.UserHomeDir
+	})
+	return homedirPath
+}
+
 func TestKubernetesUpRun(t *testing.T) {
+	useTestHomeDir(t)
 	k8s := Up{}
 	ctx := context.TODO()
 
@@ -36,7 +56,7 @@ func TestKubernetesUpRun(t *testing.T) {
 	t.Run("should return error when setting up Deployer fails", func(t *testing.T) {
 		mockDeployer.On("WithConfig", mock.Anything).Return(errors.New("error")).Once()
 
-		err := k8s.Run(ctx, mockDeployer, logger, client.Config{})
+		err := k8s.Run(ctx, mockDeployer, logger, client.Config{SecretKeyBase: testSecretKeyBase})
 		require.Error(t, err)
 		mockDeployer.AssertNumberOfCalls(t, "WithConfig", 1)
 	})
@@ -45,7 +65,7 @@ func TestKubernetesUpRun(t *testing.T) {
 		mockDeployer.On("WithConfig", mock.Anything).Return(nil)
 		mockDeployer.On("CreateNamespace", mock.Anything).Return(errors.New("error")).Once()
 
-		err := k8s.Run(ctx, mockDeployer, logger, client.Config{})
+		err := k8s.Run(ctx, mockDeployer, logger, client.Config{SecretKeyBase: testSecretKeyBase})
 		require.Error(t, err)
 		mockDeployer.AssertNumberOfCalls(t, "CreateNamespace", 1)
 	})
@@ -54,7 +74,7 @@ func TestKubernetesUpRun(t *testing.T) {
 		mockDeployer.On("CreateNamespace", mock.Anything).Return(nil)
 		mockDeployer.On("CreateSvcAccount", mock.Anything).Return(errors.New("error")).Once()
 
-		err := k8s.Run(ctx, mockDeployer, logger, client.Config{})
+		err := k8s.Run(ctx, mockDeployer, logger, client.Config{SecretKeyBase: testSecretKeyBase})
 		require.Error(t, err)
 		mockDeployer.AssertNumberOfCalls(t, "CreateSvcAccount", 1)
 	})
@@ -63,7 +83,7 @@ func TestKubernetesUpRun(t *testing.T) {
 		mockDeployer.On("CreateSvcAccount", mock.Anything).Return(nil)
 		mockDeployer.On("CreateRole", mock.Anything).Return(errors.New("error")).Once()
 
-		err := k8s.Run(ctx, mockDeployer, logger, client.Config{})
+		err := k8s.Run(ctx, mockDeployer, logger, client.Config{SecretKeyBase: testSecretKeyBase})
 		require.Error(t, err)
 		mockDeployer.AssertNumberOfCalls(t, "CreateRole", 1)
 	})
@@ -72,7 +92,7 @@ func TestKubernetesUpRun(t *testing.T) {
 		mockDeployer.On("CreateRole", mock.Anything).Return(nil)
 		mockDeployer.On("CreateRoleBinding", mock.Anything).Return(errors.New("error")).Once()
 
-		err := k8s.Run(ctx, mockDeployer, logger, client.Config{})
+		err := k8s.Run(ctx, mockDeployer, logger, client.Config{SecretKeyBase: testSecretKeyBase})
 		require.Error(t, err)
 		mockDeployer.AssertNumberOfCalls(t, "CreateRoleBinding", 1)
 	})
@@ -81,7 +101,7 @@ func TestKubernetesUpRun(t *testing.T) {
 		mockDeployer.On("CreateRoleBinding", mock.Anything).Return(nil)
 		mockDeployer.On("CreatePrometheusConfigMap", mock.Anything).Return(errors.New("error")).Once()
 
-		err := k8s.Run(ctx, mockDeployer, logger, client.Config{})
+		err := k8s.Run(ctx, mockDeployer, logger, client.Config{SecretKeyBase: testSecretKeyBase})
 		require.Error(t, err)
 		mockDeployer.AssertNumberOfCalls(t, "CreatePrometheusConfigMap", 1)
 	})
@@ -90,7 +110,7 @@ func TestKubernetesUpRun(t *testing.T) {
 		mockDeployer.On("CreatePrometheusConfigMap", mock.Anything).Return(nil)
 		mockDeployer.On("DeployPrometheus", mock.Anything).Return(errors.New("error")).Once()
 
-		err := k8s.Run(ctx, mockDeployer, logger, client.Config{})
+		err := k8s.Run(ctx, mockDeployer, logger, client.Config{SecretKeyBase: testSecretKeyBase})
 		require.Error(t, err)
 		mockDeployer.AssertNumberOfCalls(t, "DeployPrometheus", 1)
 	})
@@ -99,7 +119,7 @@ func TestKubernetesUpRun(t *testing.T) {
 		mockDeployer.On("DeployPrometheus", mock.Anything).Return(nil)
 		mockDeployer.On("DeployPrometheusService", mock.Anything).Return(errors.New("error")).Once()
 
-		err := k8s.Run(ctx, mockDeployer, logger, client.Config{})
+		err := k8s.Run(ctx, mockDeployer, logger, client.Config{SecretKeyBase: testSecretKeyBase})
 		require.Error(t, err)
 		mockDeployer.AssertNumberOfCalls(t, "DeployPrometheusService", 1)
 	})
@@ -108,7 +128,7 @@ func TestKubernetesUpRun(t *testing.T) {
 		mockDeployer.On("DeployPrometheusService", mock.Anything).Return(nil)
 		mockDeployer.On("DeployPostgres", mock.Anything).Return(errors.New("error")).Once()
 
-		err := k8s.Run(ctx, mockDeployer, logger, client.Config{})
+		err := k8s.Run(ctx, mockDeployer, logger, client.Config{SecretKeyBase: testSecretKeyBase})
 		require.Error(t, err)
 		mockDeployer.AssertNumberOfCalls(t, "DeployPostgres", 1)
 	})
@@ -117,7 +137,7 @@ func TestKubernetesUpRun(t *testing.T) {
 		mockDeployer.On("DeployPostgres", mock.Anything).Return(nil)
 		mockDeployer.On("DeployPostgresService", mock.Anything).Return(errors.New("error")).Once()
 
-		err := k8s.Run(ctx, mockDeployer, logger, client.Config{})
+		err := k8s.Run(ctx, mockDeployer, logger, client.Config{SecretKeyBase: testSecretKeyBase})
 		require.Error(t, err)
 		mockDeployer.AssertNumberOfCalls(t, "DeployPostgresService", 1)
 	})
@@ -126,7 +146,7 @@ func TestKubernetesUpRun(t *testing.T) {
 		mockDeployer.On("DeployPostgresService", mock.Anything).Return(nil)
 		mockDeployer.On("StartInitPostgres", mock.Anything).Return(errors.New("error")).Once()
 
-		err := k8s.Run(ctx, mockDeployer, logger, client.Config{})
+		err := k8s.Run(ctx, mockDeployer, logger, client.Config{SecretKeyBase: testSecretKeyBase})
 		require.Error(t, err)
 		mockDeployer.AssertNumberOfCalls(t, "StartInitPostgres", 1)
 	})
@@ -135,43 +155,43 @@ func TestKubernetesUpRun(t *testing.T) {
 		mockDeployer.On("StartInitPostgres", mock.Anything).Return(nil)
 		mockDeployer.On("CreateCoreSecrets", mock.Anything).Return(errors.New("error")).Once()
 
-		err := k8s.Run(ctx, mockDeployer, logger, client.Config{})
+		err := k8s.Run(ctx, mockDeployer, logger, client.Config{SecretKeyBase: testSecretKeyBase})
 		require.Error(t, err)
 		mockDeployer.AssertNumberOfCalls(t, "CreateCoreSecrets", 1)
 	})
 
 	t.Run("should return error when deploying Core fails", func(t *testing.T) {
 		mockDeployer.On("CreateCoreSecrets", mock.Anything).Return(nil)
-		mockDeployer.On("DeployCore", mock.Anything).Return(errors.New("error")).Once()
+		mockDeployer.On("DeployCore", mock.Anything, mock.Anything).Return(errors.New("error")).Once()
 
-		err := k8s.Run(ctx, mockDeployer, logger, client.Config{})
+		err := k8s.Run(ctx, mockDeployer, logger, client.Config{SecretKeyBase: testSecretKeyBase})
 		require.Error(t, err)
 		mockDeployer.AssertNumberOfCalls(t, "DeployCore", 1)
 	})
 
 	t.Run("should return error when deploying Core Service fails", func(t *testing.T) {
-		mockDeployer.On("DeployCore", mock.Anything).Return(nil)
+		mockDeployer.On("DeployCore", mock.Anything, mock.Anything).Return(nil)
 		mockDeployer.On("DeployCoreService", mock.Anything).Return(errors.New("error")).Once()
 
-		err := k8s.Run(ctx, mockDeployer, logger, client.Config{})
+		err := k8s.Run(ctx, mockDeployer, logger, client.Config{SecretKeyBase: testSecretKeyBase})
 		require.Error(t, err)
 		mockDeployer.AssertNumberOfCalls(t, "DeployCoreService", 1)
 	})
 
 	t.Run("should return error when deploying Workers fails", func(t *testing.T) {
 		mockDeployer.On("DeployCoreService", mock.Anything).Return(nil)
-		mockDeployer.On("DeployWorker", mock.Anything).Return(errors.New("error")).Once()
+		mockDeployer.On("DeployWorker", mock.Anything, mock.Anything).Return(errors.New("error")).Once()
 
-		err := k8s.Run(ctx, mockDeployer, logger, client.Config{})
+		err := k8s.Run(ctx, mockDeployer, logger, client.Config{SecretKeyBase: testSecretKeyBase})
 		require.Error(t, err)
 		mockDeployer.AssertNumberOfCalls(t, "DeployWorker", 1)
 	})
 
 	t.Run("should show error and then complete deployment when extracting Tokens fails", func(t *testing.T) {
-		mockDeployer.On("DeployWorker", mock.Anything).Return(nil)
+		mockDeployer.On("DeployWorker", mock.Anything, mock.Anything).Return(nil)
 		mockDeployer.On("ExtractTokens", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("token error")).Once()
 		outbuf, testLogger := testLogger()
-		err := k8s.Run(ctx, mockDeployer, testLogger, client.Config{})
+		err := k8s.Run(ctx, mockDeployer, testLogger, client.Config{SecretKeyBase: testSecretKeyBase})
 
 		expectedOutput := `Deploying FunLess on Kubernetes...
 
@@ -224,7 +244,7 @@ You can now start using FunLess! 🎉
 		mockDeployer.On("ExtractTokens", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
 		outbuf, testLogger := testLogger()
-		err := k8s.Run(ctx, mockDeployer, testLogger, client.Config{})
+		err := k8s.Run(ctx, mockDeployer, testLogger, client.Config{SecretKeyBase: testSecretKeyBase})
 
 		expectedOutput := `Deploying FunLess on Kubernetes...
 
@@ -272,3 +292,235 @@ You can now start using FunLess! 🎉
 		assert.Equal(t, expectedOutput, outbuf.String())
 	})
 }
+
+func TestKubernetesUpIngress(t *testing.T) {
+	ctx := context.TODO()
+
+	homedirPath, err := os.MkdirTemp("", "funless-test-homedir-")
+	require.NoError(t, err)
+	homedir.GetHomeDir = func() (string, error) {
+		return homedirPath, nil
+	}
+	defer func() {
+		homedir.GetHomeDir = os.UserHomeDir
+		os.RemoveAll(homedirPath)
+	}()
+
+	newDeployer := func(t *testing.T) *mocks.KubernetesDeployer {
+		mockDeployer := mocks.NewKubernetesDeployer(t)
+		for _, m := range []string{"CreateNamespace", "CreateSvcAccount", "CreateRole", "CreateRoleBinding",
+			"CreatePrometheusConfigMap", "DeployPrometheus", "DeployPrometheusService", "DeployPostgres",
+			"DeployPostgresService", "StartInitPostgres", "CreateCoreSecrets", "DeployCoreService"} {
+			mockDeployer.On(m, mock.Anything).Return(nil)
+		}
+		mockDeployer.On("DeployCore", mock.Anything, mock.Anything).Return(nil)
+		mockDeployer.On("DeployWorker", mock.Anything, mock.Anything).Return(nil)
+		mockDeployer.On("WithConfig", mock.Anything).Return(nil)
+		mockDeployer.On("ExtractTokens", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		return mockDeployer
+	}
+
+	t.Run("should return error when TLS options are given without an ingress host", func(t *testing.T) {
+		mockDeployer := mocks.NewKubernetesDeployer(t)
+		_, logger := testLogger()
+
+		k8s := Up{SelfSigned: true}
+		err := k8s.Run(ctx, mockDeployer, logger, client.Config{SecretKeyBase: testSecretKeyBase})
+		require.Error(t, err)
+		mockDeployer.AssertNotCalled(t, "WithConfig", mock.Anything)
+	})
+
+	t.Run("should deploy the ingress with an existing secret and set an https api_host", func(t *testing.T) {
+		mockDeployer := newDeployer(t)
+		mockDeployer.On("DeployCoreIngress", mock.Anything, "fl.example.com", "my-tls", "nginx").Return(nil).Once()
+		_, logger := testLogger()
+
+		k8s := Up{IngressHost: "fl.example.com", TLSSecret: "my-tls", IngressClass: "nginx"}
+		err := k8s.Run(ctx, mockDeployer, logger, client.Config{SecretKeyBase: testSecretKeyBase})
+		require.NoError(t, err)
+		mockDeployer.AssertNotCalled(t, "CreateSelfSignedTLSSecret", mock.Anything, mock.Anything, mock.Anything)
+
+		config, err := client.NewConfig("config")
+		require.NoError(t, err)
+		assert.Equal(t, "https://fl.example.com", config.Host)
+	})
+
+	t.Run("should generate a self-signed secret when requested", func(t *testing.T) {
+		mockDeployer := newDeployer(t)
+		mockDeployer.On("CreateSelfSignedTLSSecret", mock.Anything, selfSignedSecretName, "fl.example.com").Return([]byte("cert"), nil).Once()
+		mockDeployer.On("DeployCoreIngress", mock.Anything, "fl.example.com", selfSignedSecretName, "").Return(nil).Once()
+		outbuf, logger := testLogger()
+
+		k8s := Up{IngressHost: "fl.example.com", SelfSigned: true}
+		err := k8s.Run(ctx, mockDeployer, logger, client.Config{SecretKeyBase: testSecretKeyBase})
+		require.NoError(t, err)
+		assert.Contains(t, outbuf.String(), "Creating self-signed TLS Secret...\ndone\nDeploying Core Ingress...\ndone\n")
+		assert.Contains(t, outbuf.String(), "api_host set to https://fl.example.com")
+
+		config, err := client.NewConfig("config")
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(homedirPath, ".fl", selfSignedCertFileName), config.CACert)
+		cert, err := os.ReadFile(config.CACert)
+		require.NoError(t, err)
+		assert.Equal(t, "cert", string(cert))
+	})
+
+	t.Run("should use plain http when no TLS is configured", func(t *testing.T) {
+		mockDeployer := newDeployer(t)
+		mockDeployer.On("DeployCoreIngress", mock.Anything, "fl.example.com", "", "").Return(nil).Once()
+		_, logger := testLogger()
+
+		k8s := Up{IngressHost: "fl.example.com"}
+		err := k8s.Run(ctx, mockDeployer, logger, client.Config{SecretKeyBase: testSecretKeyBase})
+		require.NoError(t, err)
+
+		config, err := client.NewConfig("config")
+		require.NoError(t, err)
+		assert.Equal(t, "http://fl.example.com", config.Host)
+	})
+
+	t.Run("should return error when deploying the ingress fails", func(t *testing.T) {
+		mockDeployer := mocks.NewKubernetesDeployer(t)
+		for _, m := range []string{"CreateNamespace", "CreateSvcAccount", "CreateRole", "CreateRoleBinding",
+			"CreatePrometheusConfigMap", "DeployPrometheus", "DeployPrometheusService", "DeployPostgres",
+			"DeployPostgresService", "StartInitPostgres", "CreateCoreSecrets", "DeployCoreService"} {
+			mockDeployer.On(m, mock.Anything).Return(nil)
+		}
+		mockDeployer.On("DeployCore", mock.Anything, mock.Anything).Return(nil)
+		mockDeployer.On("WithConfig", mock.Anything).Return(nil)
+		mockDeployer.On("DeployCoreIngress", mock.Anything, "fl.example.com", "", "").Return(errors.New("error")).Once()
+		_, logger := testLogger()
+
+		k8s := Up{IngressHost: "fl.example.com"}
+		err := k8s.Run(ctx, mockDeployer, logger, client.Config{SecretKeyBase: testSecretKeyBase})
+		require.Error(t, err)
+		mockDeployer.AssertNotCalled(t, "DeployWorker", mock.Anything, mock.Anything)
+	})
+}
+
+func TestKubernetesUpExternalDatabase(t *testing.T) {
+	useTestHomeDir(t)
+	ctx := context.TODO()
+
+	t.Run("should return error when the database url is invalid", func(t *testing.T) {
+		mockDeployer := mocks.NewKubernetesDeployer(t)
+		_, logger := testLogger()
+
+		k8s := Up{DatabaseURL: "not-a-postgres-url"}
+		err := k8s.Run(ctx, mockDeployer, logger, client.Config{SecretKeyBase: testSecretKeyBase})
+		require.Error(t, err)
+		mockDeployer.AssertNotCalled(t, "WithConfig", mock.Anything)
+	})
+
+	t.Run("should skip the bundled postgres and create the secrets before the init job", func(t *testing.T) {
+		mockDeployer := mocks.NewKubernetesDeployer(t)
+		for _, m := range []string{"CreateNamespace", "CreateSvcAccount", "CreateRole", "CreateRoleBinding",
+			"CreatePrometheusConfigMap", "DeployPrometheus", "DeployPrometheusService",
+			"StartInitPostgres", "CreateCoreSecrets", "DeployCoreService"} {
+			mockDeployer.On(m, mock.Anything).Return(nil)
+		}
+		mockDeployer.On("DeployCore", mock.Anything, mock.Anything).Return(nil)
+		mockDeployer.On("DeployWorker", mock.Anything, mock.Anything).Return(nil)
+		mockDeployer.On("WithConfig", mock.Anything).Return(nil)
+		mockDeployer.On("ExtractTokens", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		outbuf, logger := testLogger()
+
+		// the url from the config is used when the flag is missing
+		dbURL := "postgres://fl:pw@db.example.com:5432/funless"
+		k8s := Up{}
+		err := k8s.Run(ctx, mockDeployer, logger, client.Config{SecretKeyBase: testSecretKeyBase, DatabaseURL: dbURL})
+		require.NoError(t, err)
+
+		mockDeployer.AssertNotCalled(t, "DeployPostgres", mock.Anything)
+		mockDeployer.AssertNotCalled(t, "DeployPostgresService", mock.Anything)
+		assert.Contains(t, outbuf.String(), "Creating Core Secrets...\ndone\nStarting init-postgres Job on the external database...\ndone\n")
+
+		call := mockDeployer.Calls[len(mockDeployer.Calls)-1]
+		usedCtx := call.Arguments.Get(0).(context.Context)
+		assert.Equal(t, dbURL, usedCtx.Value(pkg.FLContextKey("database_url")))
+	})
+}
+
+func TestKubernetesUpGeneratesSecretKeyBase(t *testing.T) {
+	homedirPath, err := os.MkdirTemp("", "funless-test-homedir-")
+	require.NoError(t, err)
+
+	homedir.GetHomeDir = func() (string, error) {
+		return homedirPath, nil
+	}
+	defer func() {
+		homedir.GetHomeDir = os.UserHomeDir
+		os.RemoveAll(homedirPath)
+	}()
+
+	k8s := Up{}
+	ctx := context.TODO()
+	mockDeployer := mocks.NewKubernetesDeployer(t)
+	mockDeployer.On("WithConfig", mock.Anything).Return(errors.New("error")).Once()
+
+	outbuf, logger := testLogger()
+	err = k8s.Run(ctx, mockDeployer, logger, client.Config{})
+	require.Error(t, err)
+	require.Contains(t, outbuf.String(), "generated a new one and saved it in ~/.fl/config")
+
+	config, err := client.NewConfig(pkg.ConfigFileName)
+	require.NoError(t, err)
+	require.Len(t, config.SecretKeyBase, 64)
+}
+
+func TestKubernetesUpSpec(t *testing.T) {
+	useTestHomeDir(t)
+	ctx := context.TODO()
+
+	writeSpec := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), "deploy.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	t.Run("should pass the spec to the deployer and expose the core as it says", func(t *testing.T) {
+		mockDeployer := mocks.NewKubernetesDeployer(t)
+		for _, m := range []string{"CreateNamespace", "CreateSvcAccount", "CreateRole", "CreateRoleBinding",
+			"CreatePrometheusConfigMap", "DeployPrometheus", "DeployPrometheusService", "DeployPostgres",
+			"DeployPostgresService", "StartInitPostgres", "CreateCoreSecrets", "DeployCoreService"} {
+			mockDeployer.On(m, mock.Anything).Return(nil)
+		}
+		mockDeployer.On("DeployWorker", mock.Anything, mock.Anything).Return(nil)
+		mockDeployer.On("WithConfig", mock.Anything).Return(nil)
+		mockDeployer.On("DeployCore", mock.Anything, deploy.CoreSpec{Image: "core:spec", Resources: deploy.ResourcesSpec{Memory: "1Gi"}}).Return(nil).Once()
+		mockDeployer.On("CreateSelfSignedTLSSecret", mock.Anything, selfSignedSecretName, "fl.example.com").Return([]byte("cert"), nil).Once()
+		mockDeployer.On("DeployCoreIngress", mock.Anything, "fl.example.com", selfSignedSecretName, "").Return(nil).Once()
+		mockDeployer.On("ExtractTokens", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		_, logger := testLogger()
+
+		k8s := Up{File: writeSpec(t, `core:
+  image: core:spec
+  resources:
+    memory: 1Gi
+exposure:
+  host: fl.example.com
+  tls:
+    self_signed: true
+`), IngressHost: "ignored.example.com"}
+		err := k8s.Run(ctx, mockDeployer, logger, client.Config{SecretKeyBase: testSecretKeyBase})
+		require.NoError(t, err)
+
+		registry, err := deploy.LoadRegistry()
+		require.NoError(t, err)
+		record, found := registry.Find("kubernetes/" + deploy.KubernetesNamespace)
+		require.True(t, found)
+		assert.Equal(t, "https://fl.example.com", record.Host)
+		assert.Equal(t, "core:spec", record.CoreImage)
+	})
+
+	t.Run("should reject the settings kubernetes does not support without deploying", func(t *testing.T) {
+		mockDeployer := mocks.NewKubernetesDeployer(t)
+		_, logger := testLogger()
+
+		k8s := Up{File: writeSpec(t, "worker:\n  replicas: 3\nexposure:\n  port: 4100\n")}
+		err := k8s.Run(ctx, mockDeployer, logger, client.Config{SecretKeyBase: testSecretKeyBase})
+		require.ErrorContains(t, err, "the kubernetes backend does not support worker.replicas")
+		require.ErrorContains(t, err, "exposure.port")
+		mockDeployer.AssertNotCalled(t, "WithConfig", mock.Anything)
+	})
+}
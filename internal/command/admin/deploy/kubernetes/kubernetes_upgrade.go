@@ -0,0 +1,117 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin_deploy_kubernetes
+
+import (
+	"context"
+	"time"
+
+	"github.com/funlessdev/fl-cli/pkg/deploy"
+	"github.com/funlessdev/fl-cli/pkg/log"
+)
+
+type Upgrade struct {
+	KubeConfig  string        `name:"kubeconfig" short:"k" help:"Absolute path to the kubeconfig file"`
+	CoreImage   string        `name:"core" short:"c" help:"Core docker image to upgrade to" default:"${default_core_image}"`
+	WorkerImage string        `name:"worker" short:"w" help:"Worker docker image to upgrade to" default:"${default_worker_image}"`
+	Timeout     time.Duration `name:"timeout" short:"t" help:"Maximum time to wait for the migrations and each rollout" default:"5m"`
+}
+
+func (f *Upgrade) Help() string {
+	return `
+DESCRIPTION
+
+	It upgrades a running Kubernetes FunLess deployment in place.
+	The "--core" and "--worker" flags can be used to choose the images
+	to upgrade to, the default ones are used otherwise.
+	When the core image changes, the database migrations are run in a
+	Job with the new image before the core Deployment is updated.
+	Each step waits for the rollout to complete, up to "--timeout".
+	The "--kubeconfig" flag can be used to specify the absolute path 
+	to the kubeconfig file, the one recorded by "up" is used otherwise.
+
+EXAMPLES
+
+	$ fl admin deploy kubernetes upgrade --core <your-core-image> --worker <your-worker-image>`
+}
+
+func (u *Upgrade) Run(ctx context.Context, upgrader deploy.KubernetesUpgrader, logger log.FLogger) error {
+	logger.Info("Upgrading Kubernetes FunLess deployment...\n\n")
+
+	_ = logger.StartSpinner("Setting things up...")
+	if err := logger.StopSpinner(upgrader.WithConfig(kubeConfigOrRecorded(u.KubeConfig))); err != nil {
+		return err
+	}
+
+	_ = logger.StartSpinner("Reading current images...")
+	currentCore, currentWorker, err := upgrader.CurrentImages(ctx)
+	if err := logger.StopSpinner(err); err != nil {
+		return err
+	}
+
+	if currentCore == u.CoreImage && currentWorker == u.WorkerImage {
+		logger.Info("\nCore and worker are already up to date, nothing to upgrade.\n")
+		return nil
+	}
+
+	if currentCore != u.CoreImage {
+		_ = logger.StartSpinner("Running database migrations...")
+		if err := logger.StopSpinner(u.withTimeout(ctx, func(ctx context.Context) error {
+			return upgrader.RunMigrations(ctx, u.CoreImage)
+		})); err != nil {
+			return err
+		}
+
+		_ = logger.StartSpinner("Rolling out Core...")
+		if err := logger.StopSpinner(u.withTimeout(ctx, func(ctx context.Context) error {
+			return upgrader.UpgradeCore(ctx, u.CoreImage)
+		})); err != nil {
+			return err
+		}
+	}
+
+	if currentWorker != u.WorkerImage {
+		_ = logger.StartSpinner("Rolling out Workers...")
+		if err := logger.StopSpinner(u.withTimeout(ctx, func(ctx context.Context) error {
+			return upgrader.UpgradeWorker(ctx, u.WorkerImage)
+		})); err != nil {
+			return err
+		}
+	}
+
+	registry, err := deploy.LoadRegistry()
+	if err == nil {
+		record, found := registry.Find(kubernetesDeploymentID())
+		if !found {
+			record = deploy.Deployment{Backend: "kubernetes", Namespace: deploy.KubernetesNamespace, KubeConfig: u.KubeConfig, AssetsRef: deploy.AssetsRef}
+		}
+		record.CoreImage = u.CoreImage
+		record.WorkerImage = u.WorkerImage
+		err = deploy.RecordDeployment(record)
+	}
+	if err != nil {
+		logger.Infof("\nCouldn't record the upgrade in ~/.fl/%s: %v\n", deploy.RegistryFileName, err)
+	}
+
+	logger.Info("\nUpgrade complete! 🎉\n")
+
+	return nil
+}
+
+func (u *Upgrade) withTimeout(ctx context.Context, step func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, u.Timeout)
+	defer cancel()
+	return step(ctx)
+}
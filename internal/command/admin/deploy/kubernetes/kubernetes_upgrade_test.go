@@ -0,0 +1,107 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin_deploy_kubernetes
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/funlessdev/fl-cli/test/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKubernetesUpgradeRun(t *testing.T) {
+	useTestHomeDir(t)
+	ctx := context.TODO()
+	upgrade := Upgrade{CoreImage: "core:new", WorkerImage: "worker:new", Timeout: time.Minute}
+
+	t.Run("should return error when setting up Upgrader fails", func(t *testing.T) {
+		mockUpgrader := mocks.NewKubernetesUpgrader(t)
+		mockUpgrader.On("WithConfig", mock.Anything).Return(errors.New("error")).Once()
+
+		_, logger := testLogger()
+		err := upgrade.Run(ctx, mockUpgrader, logger)
+		require.Error(t, err)
+	})
+
+	t.Run("should do nothing when the images are unchanged", func(t *testing.T) {
+		mockUpgrader := mocks.NewKubernetesUpgrader(t)
+		mockUpgrader.On("WithConfig", mock.Anything).Return(nil)
+		mockUpgrader.On("CurrentImages", mock.Anything).Return("core:new", "worker:new", nil)
+
+		outbuf, logger := testLogger()
+		err := upgrade.Run(ctx, mockUpgrader, logger)
+		require.NoError(t, err)
+		require.Contains(t, outbuf.String(), "already up to date")
+	})
+
+	t.Run("should not touch the core when migrations fail", func(t *testing.T) {
+		mockUpgrader := mocks.NewKubernetesUpgrader(t)
+		mockUpgrader.On("WithConfig", mock.Anything).Return(nil)
+		mockUpgrader.On("CurrentImages", mock.Anything).Return("core:old", "worker:old", nil)
+		mockUpgrader.On("RunMigrations", mock.Anything, "core:new").Return(errors.New("migration error"))
+
+		_, logger := testLogger()
+		err := upgrade.Run(ctx, mockUpgrader, logger)
+		require.Error(t, err)
+		mockUpgrader.AssertNotCalled(t, "UpgradeCore", mock.Anything, mock.Anything)
+		mockUpgrader.AssertNotCalled(t, "UpgradeWorker", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should only upgrade the worker when only the worker changes", func(t *testing.T) {
+		mockUpgrader := mocks.NewKubernetesUpgrader(t)
+		mockUpgrader.On("WithConfig", mock.Anything).Return(nil)
+		mockUpgrader.On("CurrentImages", mock.Anything).Return("core:new", "worker:old", nil)
+		mockUpgrader.On("UpgradeWorker", mock.Anything, "worker:new").Return(nil)
+
+		_, logger := testLogger()
+		err := upgrade.Run(ctx, mockUpgrader, logger)
+		require.NoError(t, err)
+		mockUpgrader.AssertNotCalled(t, "RunMigrations", mock.Anything, mock.Anything)
+	})
+
+	t.Run("successful prints when everything goes well", func(t *testing.T) {
+		mockUpgrader := mocks.NewKubernetesUpgrader(t)
+		mockUpgrader.On("WithConfig", mock.Anything).Return(nil)
+		mockUpgrader.On("CurrentImages", mock.Anything).Return("core:old", "worker:old", nil)
+		migrate := mockUpgrader.On("RunMigrations", mock.Anything, "core:new").Return(nil)
+		core := mockUpgrader.On("UpgradeCore", mock.Anything, "core:new").Return(nil).NotBefore(migrate)
+		mockUpgrader.On("UpgradeWorker", mock.Anything, "worker:new").Return(nil).NotBefore(core)
+
+		outbuf, logger := testLogger()
+		err := upgrade.Run(ctx, mockUpgrader, logger)
+		require.NoError(t, err)
+
+		expectedOutput := `Upgrading Kubernetes FunLess deployment...
+
+Setting things up...
+done
+Reading current images...
+done
+Running database migrations...
+done
+Rolling out Core...
+done
+Rolling out Workers...
+done
+
+Upgrade complete! 🎉
+`
+		require.Equal(t, expectedOutput, outbuf.String())
+	})
+}
@@ -0,0 +1,80 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin_deploy
+
+import (
+	"bytes"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/funlessdev/fl-cli/pkg/deploy"
+	"github.com/funlessdev/fl-cli/pkg/log"
+)
+
+const listTimeFormat = "2006-01-02 15:04:05"
+
+type List struct{}
+
+func (l *List) Help() string {
+	return `
+DESCRIPTION
+
+	List the FunLess deployments created with fl, as recorded in
+	~/.fl/deployments.json: backend, compose project or namespace,
+	images, host and when they were created and last updated.
+	Images left to the upstream manifests are shown as "default".
+
+EXAMPLES
+
+	$ fl admin deploy list`
+}
+
+func (l *List) Run(logger log.FLogger) error {
+	registry, err := deploy.LoadRegistry()
+	if err != nil {
+		return err
+	}
+
+	if len(registry.Deployments) == 0 {
+		logger.Info("No deployments recorded. Use \"fl admin deploy docker up\" or \"fl admin deploy kubernetes up\" to create one.\n")
+		return nil
+	}
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "BACKEND\tTARGET\tCORE\tWORKER\tHOST\tCREATED\tUPDATED")
+	for _, d := range registry.Deployments {
+		target := d.Project
+		if d.Backend == "kubernetes" {
+			target = d.Namespace
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			d.Backend, target, orDefault(d.CoreImage, "default"), orDefault(d.WorkerImage, "default"),
+			orDefault(d.Host, "-"), d.CreatedAt.Local().Format(listTimeFormat), d.UpdatedAt.Local().Format(listTimeFormat))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	logger.Info(buf.String())
+	return nil
+}
+
+func orDefault(value string, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
@@ -0,0 +1,69 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin_deploy
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/funlessdev/fl-cli/pkg/deploy"
+	"github.com/funlessdev/fl-cli/pkg/homedir"
+	"github.com/funlessdev/fl-cli/pkg/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListRun(t *testing.T) {
+	homedirPath, err := os.MkdirTemp("", "funless-test-homedir-")
+	require.NoError(t, err)
+
+	homedir.GetHomeDir = func() (string, error) {
+		return homedirPath, nil
+	}
+	defer func() {
+		homedir.GetHomeDir = os.UserHomeDir
+		os.RemoveAll(homedirPath)
+	}()
+
+	t.Run("should suggest a deploy when nothing is recorded", func(t *testing.T) {
+		var outbuf bytes.Buffer
+		logger, _ := log.NewLoggerBuilder().WithWriter(&outbuf).DisableAnimation().Build()
+
+		cmd := List{}
+		require.NoError(t, cmd.Run(logger))
+		require.Contains(t, outbuf.String(), "No deployments recorded.")
+	})
+
+	t.Run("should list the recorded deployments", func(t *testing.T) {
+		registry := &deploy.Registry{}
+		now := time.Now()
+		registry.Record(deploy.Deployment{Backend: "docker", Project: "fl", CoreImage: "core:1", WorkerImage: "worker:1", Host: "http://localhost:4000"}, now)
+		registry.Record(deploy.Deployment{Backend: "kubernetes", Namespace: "fl"}, now)
+		require.NoError(t, registry.Save())
+
+		var outbuf bytes.Buffer
+		logger, _ := log.NewLoggerBuilder().WithWriter(&outbuf).DisableAnimation().Build()
+
+		cmd := List{}
+		require.NoError(t, cmd.Run(logger))
+
+		lines := bytes.Split(bytes.TrimSpace(outbuf.Bytes()), []byte("\n"))
+		require.Len(t, lines, 3)
+		require.Regexp(t, `^BACKEND\s+TARGET\s+CORE\s+WORKER\s+HOST\s+CREATED\s+UPDATED$`, string(lines[0]))
+		require.Regexp(t, `^docker\s+fl\s+core:1\s+worker:1\s+http://localhost:4000\s`, string(lines[1]))
+		require.Regexp(t, `^kubernetes\s+fl\s+default\s+default\s+-\s`, string(lines[2]))
+	})
+}
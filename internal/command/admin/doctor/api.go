@@ -0,0 +1,133 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin_doctor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/funlessdev/fl-cli/pkg/client"
+)
+
+// apiTimeout bounds every request to the platform
+const apiTimeout = 10 * time.Second
+
+// checkAPI checks that the api_host answers and that the configured tokens are accepted by it
+func checkAPI(ctx context.Context, config client.Config, configOK bool, httpClient *http.Client) []Check {
+	if !configOK {
+		skipped := "skipped, the config could not be read"
+		return []Check{
+			{Name: "api_host", Status: StatusWarn, Detail: skipped},
+			{Name: "api_token", Status: StatusWarn, Detail: skipped},
+			{Name: "admin_token", Status: StatusWarn, Detail: skipped},
+		}
+	}
+
+	hostCheck := checkAPIHost(ctx, config, httpClient)
+	reachable := hostCheck.Status == StatusPass
+
+	apiTokenCheck := checkToken(ctx, config, httpClient, reachable, "api_token", config.APIToken, func(c *client.Client) (*http.Response, error) {
+		_, res, err := c.ApiClient.ModulesApi.ListModules(ctx).Execute()
+		return res, err
+	})
+	adminTokenCheck := checkToken(ctx, config, httpClient, reachable, "admin_token", config.AdminToken, func(c *client.Client) (*http.Response, error) {
+		_, res, err := c.ApiClient.SubjectsApi.ListSubjects(ctx).Execute()
+		return res, err
+	})
+
+	return []Check{hostCheck, apiTokenCheck, adminTokenCheck}
+}
+
+func checkAPIHost(ctx context.Context, config client.Config, httpClient *http.Client) Check {
+	u, err := url.Parse(config.Host)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Check{
+			Name:   "api_host",
+			Status: StatusFail,
+			Detail: fmt.Sprintf("%q is not a valid http(s) URL", config.Host),
+			Hint:   "Set it with \"fl cfg set api_host http://localhost:4000\".",
+		}
+	}
+
+	c, err := newAPIClient(config, httpClient, "")
+	if err != nil {
+		return Check{Name: "api_host", Status: StatusFail, Detail: err.Error()}
+	}
+
+	// any answer, even an authentication error, means the platform is there
+	_, res, err := c.ApiClient.ModulesApi.ListModules(ctx).Execute()
+	if res == nil {
+		return Check{
+			Name:   "api_host",
+			Status: StatusFail,
+			Detail: fmt.Sprintf("%s is unreachable: %v", config.Host, err),
+			Hint:   "Check that the platform is running (\"fl admin deploy list\"), or point api_host to it with \"fl cfg set api_host <url>\".",
+		}
+	}
+	return Check{Name: "api_host", Status: StatusPass, Detail: fmt.Sprintf("%s answered (HTTP %d)", config.Host, res.StatusCode)}
+}
+
+func checkToken(ctx context.Context, config client.Config, httpClient *http.Client, reachable bool, name string, token string, call func(c *client.Client) (*http.Response, error)) Check {
+	if token == "" {
+		return Check{
+			Name:   name,
+			Status: StatusWarn,
+			Detail: name + " is not set",
+			Hint:   fmt.Sprintf("Set it with \"fl cfg set %s <token>\"; a local deployment prints its tokens when it starts.", name),
+		}
+	}
+	if !reachable {
+		return Check{Name: name, Status: StatusWarn, Detail: "skipped, the api_host is unreachable"}
+	}
+
+	c, err := newAPIClient(config, httpClient, token)
+	if err != nil {
+		return Check{Name: name, Status: StatusFail, Detail: err.Error()}
+	}
+
+	res, err := call(c)
+	switch {
+	case res == nil:
+		return Check{Name: name, Status: StatusFail, Detail: fmt.Sprintf("request failed: %v", err)}
+	case res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden:
+		return Check{
+			Name:   name,
+			Status: StatusFail,
+			Detail: fmt.Sprintf("rejected by %s (HTTP %d)", config.Host, res.StatusCode),
+			Hint:   fmt.Sprintf("The token does not belong to this deployment: update it with \"fl cfg set %s <token>\".", name),
+		}
+	case res.StatusCode >= 300:
+		return Check{Name: name, Status: StatusWarn, Detail: fmt.Sprintf("unexpected answer from %s (HTTP %d)", config.Host, res.StatusCode)}
+	}
+	return Check{Name: name, Status: StatusPass, Detail: "accepted by " + config.Host}
+}
+
+// newAPIClient creates a client of its own for every request, so the tokens do not leak between checks
+func newAPIClient(config client.Config, httpClient *http.Client, token string) (*client.Client, error) {
+	c, err := client.NewClient(httpClient, config)
+	if err != nil {
+		return nil, err
+	}
+
+	apiConfig := c.ApiClient.GetConfig()
+	apiConfig.HTTPClient = httpClient
+	if token != "" {
+		apiConfig.DefaultHeader["Authorization"] = "Bearer " + token
+	}
+	return c, nil
+}
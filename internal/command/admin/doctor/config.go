@@ -0,0 +1,92 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin_doctor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/funlessdev/fl-cli/pkg"
+	"github.com/funlessdev/fl-cli/pkg/client"
+	"github.com/funlessdev/fl-cli/pkg/homedir"
+)
+
+// checkConfig parses ~/.fl/config the same way every command does, and warns about the lines it would ignore
+func checkConfig() (client.Config, Check) {
+	config, err := client.NewConfig(pkg.ConfigFileName)
+	if err != nil {
+		return client.Config{}, Check{
+			Name:   "config",
+			Status: StatusFail,
+			Detail: fmt.Sprintf("unable to read ~/.fl/config: %v", err),
+			Hint:   "Fix the permissions of ~/.fl/config, or remove it and set the values again with \"fl cfg set\".",
+		}
+	}
+
+	if config.Path == "" {
+		return config, Check{Name: "config", Status: StatusPass, Detail: "no ~/.fl/config, using the defaults"}
+	}
+
+	content, _, err := homedir.ReadFromConfigDir(pkg.ConfigFileName)
+	if err != nil {
+		return config, Check{Name: "config", Status: StatusFail, Detail: fmt.Sprintf("unable to read %s: %v", config.Path, err)}
+	}
+
+	if ignored := ignoredConfigLines(string(content)); len(ignored) > 0 {
+		return config, Check{
+			Name:   "config",
+			Status: StatusWarn,
+			Detail: fmt.Sprintf("%s has lines that are ignored: %s", config.Path, strings.Join(ignored, "; ")),
+			Hint:   fmt.Sprintf("Use \"fl cfg set <key> <value>\" to write the values; the known keys are %s.", strings.ReplaceAll(pkg.ConfigKeys, ",", ", ")),
+		}
+	}
+
+	if _, err := client.NewHTTPClient(config); err != nil {
+		return config, Check{
+			Name:   "config",
+			Status: StatusWarn,
+			Detail: err.Error(),
+			Hint:   "Point ca_cert to a PEM file with \"fl cfg set ca_cert <path>\", or remove the line.",
+		}
+	}
+
+	return config, Check{Name: "config", Status: StatusPass, Detail: config.Path + " parsed"}
+}
+
+// ignoredConfigLines describes the lines of the config file that are not a known key=value pair
+func ignoredConfigLines(content string) []string {
+	knownKeys := map[string]bool{}
+	for _, key := range strings.Split(pkg.ConfigKeys, ",") {
+		knownKeys[key] = true
+	}
+
+	ignored := []string{}
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		key, _, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		switch {
+		case !found:
+			ignored = append(ignored, fmt.Sprintf("line %d is not key=value", i+1))
+		case !knownKeys[key]:
+			ignored = append(ignored, fmt.Sprintf("line %d has unknown key %q", i+1, key))
+		}
+	}
+	return ignored
+}
@@ -0,0 +1,203 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin_doctor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+
+	"github.com/funlessdev/fl-cli/pkg"
+	"github.com/funlessdev/fl-cli/pkg/client"
+	"github.com/funlessdev/fl-cli/pkg/deploy"
+	"github.com/funlessdev/fl-cli/pkg/docker"
+	"github.com/funlessdev/fl-cli/pkg/log"
+)
+
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+)
+
+// Check is the outcome of a single diagnostic, with a hint on how to fix it when it does not pass
+type Check struct {
+	Name   string `json:"name"`
+	Status Status `json:"status"`
+	Detail string `json:"detail"`
+	Hint   string `json:"hint,omitempty"`
+}
+
+// Report collects the checks together with the information needed to reproduce the environment
+type Report struct {
+	Version string  `json:"version"`
+	OS      string  `json:"os"`
+	Arch    string  `json:"arch"`
+	Runtime string  `json:"runtime"`
+	Checks  []Check `json:"checks"`
+}
+
+func (r *Report) count(status Status) int {
+	n := 0
+	for _, c := range r.Checks {
+		if c.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+type Doctor struct {
+	JSON       bool   `name:"json" help:"Print the report as JSON, e.g. to attach it to a bug report"`
+	KubeConfig string `name:"kubeconfig" short:"k" help:"Absolute path to the kubeconfig file to check (defaults to ~/.kube/config)"`
+}
+
+func (d *Doctor) Help() string {
+	return `
+DESCRIPTION
+
+	Check the environment fl runs in and report what is broken:
+	whether ~/.fl/config parses, whether the api_host answers and
+	accepts the api_token and admin_token, whether the container engine
+	(Docker or Podman, see "--runtime") and its compose command are
+	available and whether the kubeconfig reaches a cluster.
+	Every check passes, warns or fails; the ones that do not pass come
+	with a hint on how to fix them. The command exits with an error
+	when at least one check fails.
+	The "--json" flag prints the report as JSON, together with the fl
+	version and platform, to attach it to bug reports.
+
+EXAMPLES
+
+	$ fl admin doctor
+	$ fl admin doctor --kubeconfig ~/.kube/kind-config
+	$ fl admin doctor --json > fl-doctor.json`
+}
+
+func (d *Doctor) Run(ctx context.Context, prober deploy.EnvironmentProber, rt docker.Runtime, logger log.FLogger, version pkg.CLIVersion) error {
+	if !d.JSON {
+		logger.Info("Running FunLess diagnostics...\n\n")
+	}
+
+	report := Report{
+		Version: string(version),
+		OS:      runtime.GOOS,
+		Arch:    runtime.GOARCH,
+		Runtime: string(rt),
+	}
+
+	config, configCheck := checkConfig()
+	report.Checks = append(report.Checks, configCheck)
+	// an unusable ca_cert is reported by the config check
+	httpClient, err := client.NewHTTPClient(config)
+	if err != nil {
+		httpClient = &http.Client{}
+	}
+	httpClient.Timeout = apiTimeout
+	report.Checks = append(report.Checks, checkAPI(ctx, config, configCheck.Status != StatusFail, httpClient)...)
+	report.Checks = append(report.Checks,
+		checkDocker(ctx, prober, rt),
+		checkCompose(ctx, prober, rt),
+		checkKubernetes(ctx, prober, d.KubeConfig),
+	)
+
+	if d.JSON {
+		out, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		logger.Info(string(out) + "\n")
+	} else {
+		printReport(logger, report)
+	}
+
+	if failed := report.count(StatusFail); failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(report.Checks))
+	}
+	return nil
+}
+
+func printReport(logger log.FLogger, report Report) {
+	for _, c := range report.Checks {
+		logger.Infof("[%s] %s: %s\n", strings.ToUpper(string(c.Status)), c.Name, c.Detail)
+		if c.Hint != "" {
+			logger.Infof("       hint: %s\n", c.Hint)
+		}
+	}
+	logger.Infof("\n%d passed, %d warnings, %d failed\n", report.count(StatusPass), report.count(StatusWarn), report.count(StatusFail))
+}
+
+func checkDocker(ctx context.Context, prober deploy.EnvironmentProber, rt docker.Runtime) Check {
+	engine, hint := "Docker Engine", "Start Docker, or point DOCKER_HOST to a running engine."
+	if rt == docker.RuntimePodman {
+		engine, hint = "Podman", "Start the Podman API socket (\"systemctl --user start podman.socket\"), or point DOCKER_HOST to it."
+	}
+
+	version, err := prober.DockerVersion(ctx)
+	if err != nil {
+		return Check{
+			Name:   "docker",
+			Status: StatusFail,
+			Detail: fmt.Sprintf("unable to reach the %s: %v", engine, err),
+			Hint:   hint + " It is needed by \"fl fn build\" and \"fl admin deploy docker\".",
+		}
+	}
+	return Check{Name: "docker", Status: StatusPass, Detail: engine + " " + version}
+}
+
+func checkCompose(ctx context.Context, prober deploy.EnvironmentProber, rt docker.Runtime) Check {
+	compose, hint := "docker compose", "Install the Docker Compose v2 plugin (\"docker compose\")"
+	if rt == docker.RuntimePodman {
+		compose, hint = "podman compose", "Install podman-compose, or a compose provider for \"podman compose\","
+	}
+
+	version, err := prober.ComposeVersion(ctx)
+	if err != nil {
+		return Check{
+			Name:   "compose",
+			Status: StatusFail,
+			Detail: fmt.Sprintf("unable to run %s: %v", compose, err),
+			Hint:   hint + " needed by \"fl admin deploy docker\".",
+		}
+	}
+	return Check{Name: "compose", Status: StatusPass, Detail: compose + " " + version}
+}
+
+func checkKubernetes(ctx context.Context, prober deploy.EnvironmentProber, kubeconfig string) Check {
+	version, err := prober.KubernetesVersion(ctx, kubeconfig)
+	if errors.Is(err, deploy.ErrNoKubeConfig) {
+		return Check{
+			Name:   "kubernetes",
+			Status: StatusWarn,
+			Detail: "no kubeconfig found in ~/.kube/config, skipped",
+			Hint:   "Only needed for \"fl admin deploy kubernetes\": pass \"--kubeconfig\" to check a different file.",
+		}
+	}
+	if err != nil {
+		return Check{
+			Name:   "kubernetes",
+			Status: StatusFail,
+			Detail: fmt.Sprintf("unable to reach the cluster: %v", err),
+			Hint:   "Check that the kubeconfig is valid and the cluster is running (\"kubectl cluster-info\").",
+		}
+	}
+	return Check{Name: "kubernetes", Status: StatusPass, Detail: "Kubernetes " + version}
+}
@@ -0,0 +1,219 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin_doctor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/funlessdev/fl-cli/pkg"
+	"github.com/funlessdev/fl-cli/pkg/deploy"
+	"github.com/funlessdev/fl-cli/pkg/docker"
+	"github.com/funlessdev/fl-cli/pkg/homedir"
+	"github.com/funlessdev/fl-cli/pkg/log"
+	"github.com/funlessdev/fl-cli/test/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func testLogger() (*bytes.Buffer, log.FLogger) {
+	var outbuf bytes.Buffer
+	testLogger, _ := log.NewLoggerBuilder().WithWriter(&outbuf).DisableAnimation().Build()
+	return &outbuf, testLogger
+}
+
+func useTestConfig(t *testing.T, config string) {
+	homedirPath := t.TempDir()
+	homedir.GetHomeDir = func() (string, error) {
+		return homedirPath, nil
+	}
+	t.Cleanup(func() {
+		homedir.GetHomeDir = os.UserHomeDir
+	})
+
+	if config != "" {
+		_, err := homedir.WriteToConfigDir(pkg.ConfigFileName, []byte(config), true)
+		require.NoError(t, err)
+	}
+}
+
+// testPlatform answers 200 to requests carrying the expected token for the endpoint and 401 otherwise
+func testPlatform(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		expected := "Bearer api-token"
+		if r.URL.Path == "/v1/admin/subjects" {
+			expected = "Bearer admin-token"
+		}
+		if r.Header.Get("Authorization") != expected {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"errors":{"detail":"Unauthorized"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[]}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func healthyProber(t *testing.T) *mocks.EnvironmentProber {
+	prober := mocks.NewEnvironmentProber(t)
+	prober.On("DockerVersion", mock.Anything).Return("24.0.5 (API 1.43)", nil)
+	prober.On("ComposeVersion", mock.Anything).Return("2.20.2", nil)
+	prober.On("KubernetesVersion", mock.Anything, "").Return("v1.27.3", nil)
+	return prober
+}
+
+func TestDoctorRun(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should pass every check on a healthy environment", func(t *testing.T) {
+		server := testPlatform(t)
+		useTestConfig(t, "api_host="+server.URL+"\napi_token=api-token\nadmin_token=admin-token\n")
+
+		outbuf, logger := testLogger()
+		err := (&Doctor{}).Run(ctx, healthyProber(t), docker.RuntimeDocker, logger, pkg.CLIVersion("v0.1.0"))
+		require.NoError(t, err)
+
+		configPath, _ := homedir.GetHomeDir()
+		expected := "Running FunLess diagnostics...\n\n" +
+			"[PASS] config: " + configPath + "/.fl/config parsed\n" +
+			"[PASS] api_host: " + server.URL + " answered (HTTP 401)\n" +
+			"[PASS] api_token: accepted by " + server.URL + "\n" +
+			"[PASS] admin_token: accepted by " + server.URL + "\n" +
+			"[PASS] docker: Docker Engine 24.0.5 (API 1.43)\n" +
+			"[PASS] compose: docker compose 2.20.2\n" +
+			"[PASS] kubernetes: Kubernetes v1.27.3\n" +
+			"\n7 passed, 0 warnings, 0 failed\n"
+		require.Equal(t, expected, outbuf.String())
+	})
+
+	t.Run("should fail with hints when tokens are rejected and docker is down", func(t *testing.T) {
+		server := testPlatform(t)
+		useTestConfig(t, "api_host="+server.URL+"\napi_token=wrong\n")
+
+		prober := mocks.NewEnvironmentProber(t)
+		prober.On("DockerVersion", mock.Anything).Return("", errors.New("connection refused"))
+		prober.On("ComposeVersion", mock.Anything).Return("", errors.New("exit status 1"))
+		prober.On("KubernetesVersion", mock.Anything, "").Return("", deploy.ErrNoKubeConfig)
+
+		outbuf, logger := testLogger()
+		err := (&Doctor{JSON: true}).Run(ctx, prober, docker.RuntimeDocker, logger, pkg.CLIVersion("v0.1.0"))
+		require.EqualError(t, err, "3 of 7 checks failed")
+
+		var report Report
+		require.NoError(t, json.Unmarshal(outbuf.Bytes(), &report))
+		require.Equal(t, "v0.1.0", report.Version)
+
+		statuses := map[string]Status{}
+		for _, c := range report.Checks {
+			statuses[c.Name] = c.Status
+			if c.Status == StatusFail {
+				require.NotEmpty(t, c.Hint, c.Name)
+			}
+		}
+		require.Equal(t, map[string]Status{
+			"config":      StatusPass,
+			"api_host":    StatusPass,
+			"api_token":   StatusFail,
+			"admin_token": StatusWarn,
+			"docker":      StatusFail,
+			"compose":     StatusFail,
+			"kubernetes":  StatusWarn,
+		}, statuses)
+	})
+
+	t.Run("should skip the token checks when the api_host is unreachable", func(t *testing.T) {
+		server := testPlatform(t)
+		server.Close()
+		useTestConfig(t, "api_host="+server.URL+"\napi_token=api-token\nadmin_token=admin-token\n")
+
+		outbuf, logger := testLogger()
+		err := (&Doctor{JSON: true}).Run(ctx, healthyProber(t), docker.RuntimeDocker, logger, pkg.CLIVersion("v0.1.0"))
+		require.EqualError(t, err, "1 of 7 checks failed")
+
+		var report Report
+		require.NoError(t, json.Unmarshal(outbuf.Bytes(), &report))
+		require.Equal(t, StatusFail, report.Checks[1].Status)
+		require.Equal(t, Check{Name: "api_token", Status: StatusWarn, Detail: "skipped, the api_host is unreachable"}, report.Checks[2])
+		require.Equal(t, Check{Name: "admin_token", Status: StatusWarn, Detail: "skipped, the api_host is unreachable"}, report.Checks[3])
+	})
+
+	t.Run("should check the given kubeconfig", func(t *testing.T) {
+		useTestConfig(t, "api_host=not a url\n")
+
+		prober := mocks.NewEnvironmentProber(t)
+		prober.On("DockerVersion", mock.Anything).Return("24.0.5 (API 1.43)", nil)
+		prober.On("ComposeVersion", mock.Anything).Return("2.20.2", nil)
+		prober.On("KubernetesVersion", mock.Anything, "/tmp/kubeconfig").Return("", errors.New("no such host"))
+
+		_, logger := testLogger()
+		err := (&Doctor{KubeConfig: "/tmp/kubeconfig"}).Run(ctx, prober, docker.RuntimeDocker, logger, pkg.CLIVersion("v0.1.0"))
+		require.EqualError(t, err, "2 of 7 checks failed")
+	})
+
+	t.Run("should name the podman engine and its compose command", func(t *testing.T) {
+		useTestConfig(t, "api_host=not a url\n")
+
+		prober := mocks.NewEnvironmentProber(t)
+		prober.On("DockerVersion", mock.Anything).Return("4.6.2 (API 1.41)", nil)
+		prober.On("ComposeVersion", mock.Anything).Return("", errors.New("exit status 1"))
+		prober.On("KubernetesVersion", mock.Anything, "").Return("", deploy.ErrNoKubeConfig)
+
+		outbuf, logger := testLogger()
+		err := (&Doctor{JSON: true}).Run(ctx, prober, docker.RuntimePodman, logger, pkg.CLIVersion("v0.1.0"))
+		require.EqualError(t, err, "2 of 7 checks failed")
+
+		var report Report
+		require.NoError(t, json.Unmarshal(outbuf.Bytes(), &report))
+		require.Equal(t, "podman", report.Runtime)
+		require.Equal(t, Check{Name: "docker", Status: StatusPass, Detail: "Podman 4.6.2 (API 1.41)"}, report.Checks[4])
+		require.Equal(t, "unable to run podman compose: exit status 1", report.Checks[5].Detail)
+		require.Contains(t, report.Checks[5].Hint, "podman-compose")
+	})
+}
+
+func TestCheckConfig(t *testing.T) {
+	t.Run("should pass without a config file", func(t *testing.T) {
+		useTestConfig(t, "")
+
+		config, check := checkConfig()
+		require.Equal(t, StatusPass, check.Status)
+		require.Equal(t, "http://localhost:4000", config.Host)
+	})
+
+	t.Run("should warn about ignored lines", func(t *testing.T) {
+		useTestConfig(t, "api_host=http://localhost:4000\napi_tokn=abc\n\nnot a pair\n")
+
+		_, check := checkConfig()
+		require.Equal(t, StatusWarn, check.Status)
+		require.Contains(t, check.Detail, `line 2 has unknown key "api_tokn"; line 4 is not key=value`)
+		require.NotEmpty(t, check.Hint)
+	})
+
+	t.Run("should warn about an unreadable ca_cert", func(t *testing.T) {
+		useTestConfig(t, "api_host=https://fl.example.com\nca_cert=/does/not/exist.pem\n")
+
+		_, check := checkConfig()
+		require.Equal(t, StatusWarn, check.Status)
+		require.Contains(t, check.Detail, "unable to read the ca_cert file")
+	})
+}
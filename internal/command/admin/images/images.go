@@ -0,0 +1,173 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin_images
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/funlessdev/fl-cli/pkg/deploy"
+	"github.com/funlessdev/fl-cli/pkg/docker"
+	"github.com/funlessdev/fl-cli/pkg/homedir"
+	"github.com/funlessdev/fl-cli/pkg/log"
+)
+
+type Images struct {
+	Save SaveImages `cmd:"" name:"save" aliases:"s" help:"Save the images used by FunLess to a bundle"`
+	Load LoadImages `cmd:"" name:"load" aliases:"l" help:"Import the images of a bundle"`
+}
+
+func (i *Images) Help() string {
+	return `
+DESCRIPTION
+
+	Move the images used by FunLess to a host without internet access.
+	"save" pulls the core and worker images, the images of the services
+	in the docker compose files (optional stacks included) and the builder
+	images of the supported languages, then saves them to a single tar bundle through the Docker
+	Engine API. "load" imports the bundle on the offline host, after which
+	"fl admin deploy docker up" and "fl fn build" find the images locally.
+	The bundle uses the "docker save" format, so it can also be imported
+	with "docker load" or loaded into a kind cluster.
+
+EXAMPLES
+
+	$ fl admin images save fl-images.tar
+	$ fl admin images save fl-images.tar --core <your-core-image> --worker <your-worker-image>
+	$ fl admin images load fl-images.tar`
+}
+
+type SaveImages struct {
+	File        string `arg:"" name:"file" type:"path" help:"Path of the bundle to create"`
+	CoreImage   string `name:"core" short:"c" help:"Core docker image to bundle" default:"${default_core_image}"`
+	WorkerImage string `name:"worker" short:"w" help:"Worker docker image to bundle" default:"${default_worker_image}"`
+}
+
+func (s *SaveImages) Run(ctx context.Context, bundler deploy.ImageBundler, logger log.FLogger) error {
+	logger.Info("Saving FunLess images...\n\n")
+
+	_ = logger.StartSpinner("Resolving images...")
+	images, err := resolveImages(s.CoreImage, s.WorkerImage)
+	if err := logger.StopSpinner(err); err != nil {
+		return err
+	}
+
+	if err := bundler.Connect(); err != nil {
+		return err
+	}
+
+	for _, img := range images {
+		msg := fmt.Sprintf("Pulling %s...", img)
+		_ = logger.StartSpinner(msg)
+		pullCtx := docker.WithPullProgress(ctx, func(p docker.PullProgress) {
+			logger.SpinnerMessage(msg + " " + p.String())
+		})
+		if err := logger.StopSpinner(bundler.Pull(pullCtx, img)); err != nil {
+			return err
+		}
+	}
+
+	_ = logger.StartSpinner("Writing the bundle...")
+	if err := logger.StopSpinner(writeBundle(ctx, bundler, images, s.File)); err != nil {
+		return err
+	}
+
+	logger.Infof("\nSaved %d images to %s 📦\n", len(images), s.File)
+	return nil
+}
+
+type LoadImages struct {
+	File string `arg:"" name:"file" type:"existingfile" help:"Path of the bundle to import"`
+}
+
+func (l *LoadImages) Run(ctx context.Context, bundler deploy.ImageBundler, logger log.FLogger) error {
+	logger.Info("Loading FunLess images...\n\n")
+
+	bundle, err := os.Open(l.File)
+	if err != nil {
+		return err
+	}
+	defer bundle.Close()
+
+	if err := bundler.Connect(); err != nil {
+		return err
+	}
+
+	_ = logger.StartSpinner("Importing the bundle...")
+	loaded, err := bundler.Load(ctx, bundle)
+	if err := logger.StopSpinner(err); err != nil {
+		return err
+	}
+
+	logger.Info("\n")
+	for _, img := range loaded {
+		logger.Infof("Loaded %s\n", img)
+	}
+	logger.Infof("\nImported %d images from %s 📦\n", len(loaded), l.File)
+	return nil
+}
+
+// resolveImages lists the images a deployment with the given core and worker would use.
+// The compose files are the ones docker up would use: the local copies if present, the upstream ones otherwise.
+func resolveImages(core string, worker string) ([]string, error) {
+	composeFiles := [][]byte{}
+	for _, f := range []struct{ name, url string }{
+		{"docker-compose.yml", deploy.DockerComposeURL},
+		{"filebeat/filebeat.compose.yml", deploy.FilebeatComposeURL},
+	} {
+		content, err := readComposeFile(f.name, f.url)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get %s: %w", f.name, err)
+		}
+		composeFiles = append(composeFiles, content)
+	}
+	composeFiles = append(composeFiles, deploy.GrafanaComposeYml)
+	return deploy.BundleImages(core, worker, composeFiles...)
+}
+
+func readComposeFile(name string, url string) ([]byte, error) {
+	if content, _, err := homedir.ReadFromConfigDir(name); err == nil {
+		return content, nil
+	}
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// writeBundle saves the images to path, removing the partial file if saving fails
+func writeBundle(ctx context.Context, bundler deploy.ImageBundler, images []string, path string) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := bundler.Save(ctx, images, out); err != nil {
+		out.Close()
+		os.Remove(path)
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,184 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin_images
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/funlessdev/fl-cli/pkg"
+	"github.com/funlessdev/fl-cli/pkg/homedir"
+	"github.com/funlessdev/fl-cli/pkg/log"
+	"github.com/funlessdev/fl-cli/test/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testComposeYml = `services:
+  core:
+    image: ghcr.io/funlessdev/core:latest
+  worker:
+    image: ghcr.io/funlessdev/worker:latest
+  postgres:
+    image: postgres:latest
+`
+
+const testFilebeatYml = `services:
+  kibana:
+    image: kibana:8.6.0
+`
+
+func testLogger() (*bytes.Buffer, log.FLogger) {
+	var outbuf bytes.Buffer
+	testLogger, _ := log.NewLoggerBuilder().WithWriter(&outbuf).DisableAnimation().Build()
+	return &outbuf, testLogger
+}
+
+// useTestComposeFiles seeds a temporary config dir with the compose files, so no download is needed
+func useTestComposeFiles(t *testing.T) {
+	homedirPath := t.TempDir()
+	homedir.GetHomeDir = func() (string, error) {
+		return homedirPath, nil
+	}
+	t.Cleanup(func() {
+		homedir.GetHomeDir = os.UserHomeDir
+	})
+
+	_, err := homedir.WriteToConfigDir("docker-compose.yml", []byte(testComposeYml), true)
+	require.NoError(t, err)
+	_, err = homedir.CreateDirInConfigDir("filebeat")
+	require.NoError(t, err)
+	_, err = homedir.WriteToConfigDir("filebeat/filebeat.compose.yml", []byte(testFilebeatYml), true)
+	require.NoError(t, err)
+}
+
+func TestImagesSave(t *testing.T) {
+	useTestComposeFiles(t)
+	ctx := context.Background()
+	// the pulls report their progress to the spinner
+	reportingProgress := mock.MatchedBy(func(ctx context.Context) bool {
+		return ctx.Value(pkg.FLContextKey("pull_progress")) != nil
+	})
+
+	expectedImages := []string{
+		pkg.SupportedLanguages["js"].BuilderImage,
+		pkg.SupportedLanguages["rust"].BuilderImage,
+		"grafana/grafana:10.1.5",
+		"kibana:8.6.0",
+		"my-core",
+		"my-worker",
+		"postgres:latest",
+	}
+
+	t.Run("should pull every image and save them to the bundle", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "bundle.tar")
+		bundler := mocks.NewImageBundler(t)
+		bundler.On("Connect").Return(nil).Once()
+		for _, img := range expectedImages {
+			bundler.On("Pull", reportingProgress, img).Return(nil).Once()
+		}
+		bundler.On("Save", ctx, expectedImages, mock.Anything).
+			Run(func(args mock.Arguments) {
+				_, _ = args.Get(2).(io.Writer).Write([]byte("images"))
+			}).Return(nil).Once()
+
+		outbuf, logger := testLogger()
+		cmd := SaveImages{File: path, CoreImage: "my-core", WorkerImage: "my-worker"}
+		err := cmd.Run(ctx, bundler, logger)
+		require.NoError(t, err)
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "images", string(content))
+
+		expected := "Saving FunLess images...\n\n" +
+			"Resolving images...\ndone\n"
+		for _, img := range expectedImages {
+			expected += "Pulling " + img + "...\ndone\n"
+		}
+		expected += "Writing the bundle...\ndone\n" +
+			fmt.Sprintf("\nSaved %d images to ", len(expectedImages)) + path + " 📦\n"
+		require.Equal(t, expected, outbuf.String())
+	})
+
+	t.Run("should stop when a pull fails", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "bundle.tar")
+		bundler := mocks.NewImageBundler(t)
+		bundler.On("Connect").Return(nil).Once()
+		bundler.On("Pull", reportingProgress, expectedImages[0]).Return(errors.New("no such image")).Once()
+
+		_, logger := testLogger()
+		cmd := SaveImages{File: path, CoreImage: "my-core", WorkerImage: "my-worker"}
+		err := cmd.Run(ctx, bundler, logger)
+		require.Error(t, err)
+		bundler.AssertNotCalled(t, "Save", mock.Anything, mock.Anything, mock.Anything)
+		require.NoFileExists(t, path)
+	})
+
+	t.Run("should remove the partial bundle when saving fails", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "bundle.tar")
+		bundler := mocks.NewImageBundler(t)
+		bundler.On("Connect").Return(nil).Once()
+		bundler.On("Pull", reportingProgress, mock.Anything).Return(nil)
+		bundler.On("Save", ctx, expectedImages, mock.Anything).Return(errors.New("engine error")).Once()
+
+		_, logger := testLogger()
+		cmd := SaveImages{File: path, CoreImage: "my-core", WorkerImage: "my-worker"}
+		err := cmd.Run(ctx, bundler, logger)
+		require.Error(t, err)
+		require.NoFileExists(t, path)
+	})
+}
+
+func TestImagesLoad(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "bundle.tar")
+	require.NoError(t, os.WriteFile(path, []byte("images"), 0600))
+
+	t.Run("should import the bundle and list the loaded images", func(t *testing.T) {
+		bundler := mocks.NewImageBundler(t)
+		bundler.On("Connect").Return(nil).Once()
+		bundler.On("Load", ctx, mock.Anything).
+			Run(func(args mock.Arguments) {
+				content, _ := io.ReadAll(args.Get(1).(io.Reader))
+				require.Equal(t, "images", string(content))
+			}).Return([]string{"my-core", "my-worker"}, nil).Once()
+
+		outbuf, logger := testLogger()
+		err := (&LoadImages{File: path}).Run(ctx, bundler, logger)
+		require.NoError(t, err)
+
+		expected := "Loading FunLess images...\n\n" +
+			"Importing the bundle...\ndone\n" +
+			"\nLoaded my-core\nLoaded my-worker\n" +
+			"\nImported 2 images from " + path + " 📦\n"
+		require.Equal(t, expected, outbuf.String())
+	})
+
+	t.Run("should return error when the engine cannot be reached", func(t *testing.T) {
+		bundler := mocks.NewImageBundler(t)
+		bundler.On("Connect").Return(errors.New("no docker host")).Once()
+
+		_, logger := testLogger()
+		err := (&LoadImages{File: path}).Run(ctx, bundler, logger)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,126 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin_secret
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"time"
+
+	"github.com/funlessdev/fl-cli/pkg"
+	"github.com/funlessdev/fl-cli/pkg/client"
+	"github.com/funlessdev/fl-cli/pkg/deploy"
+	"github.com/funlessdev/fl-cli/pkg/docker"
+	"github.com/funlessdev/fl-cli/pkg/homedir"
+	"github.com/funlessdev/fl-cli/pkg/log"
+)
+
+type Secret struct {
+	Rotate RotateSecret `cmd:"" name:"rotate" aliases:"r" help:"Generate a new secret_key_base and apply it to the running deployment"`
+
+	Backend    string `name:"backend" short:"b" enum:"docker,kubernetes" default:"docker" help:"The kind of deployment to update (docker, kubernetes)"`
+	KubeConfig string `name:"kubeconfig" short:"k" help:"Absolute path to the kubeconfig file (kubernetes backend only)"`
+	Name       string `name:"name" short:"n" help:"Name of the local instance (docker backend only)" default:"fl"`
+}
+
+func (s *Secret) Help() string {
+	return `
+DESCRIPTION
+
+	Manage the secret_key_base of a FunLess deployment, used by the core
+	to sign and encrypt its data.
+	"rotate" generates a new random key, saves it in ~/.fl/config as
+	secret_key_base, and applies it to the running deployment: the core
+	container is recreated (docker), or the core Secret is updated and
+	the core restarted (kubernetes), waiting for its rollout up to
+	"--timeout".
+	Deployments generate and save a key automatically when none is set.
+	With the docker backend, "--name" selects the instance created with
+	"fl admin deploy docker up --name"; the key is shared by all instances.
+
+EXAMPLES
+
+	$ fl admin secret rotate
+	$ fl admin secret rotate --backend kubernetes --kubeconfig <your-kubeconfig-path>`
+}
+
+type RotateSecret struct {
+	Timeout time.Duration `name:"timeout" short:"t" help:"Maximum time to wait for the core rollout (kubernetes backend only)" default:"5m"`
+}
+
+func (r *RotateSecret) Run(ctx context.Context, dk deploy.DockerShell, upgrader deploy.KubernetesUpgrader, logger log.FLogger, config client.Config, parent *Secret) error {
+	if parent.Backend != "kubernetes" && parent.KubeConfig != "" {
+		return errors.New("--kubeconfig can only be used with the kubernetes backend")
+	}
+
+	var composeFilePath string
+	if parent.Backend != "kubernetes" {
+		if err := deploy.ValidateDockerInstanceName(parent.Name); err != nil {
+			return err
+		}
+		_, composePath, err := homedir.ReadFromConfigDir(path.Join(deploy.DockerInstanceDir(parent.Name), "docker-compose.yml"))
+		if err != nil {
+			if os.IsNotExist(err) {
+				return errors.New("no local deployment found. Use \"fl admin deploy docker up\" to create one.")
+			}
+			return errors.New("unable to read docker-compose.yml file")
+		}
+		composeFilePath = composePath
+	}
+
+	logger.Info("Rotating secret_key_base...\n\n")
+
+	if parent.Backend == "kubernetes" {
+		_ = logger.StartSpinner("Setting things up...")
+		if err := logger.StopSpinner(upgrader.WithConfig(parent.KubeConfig)); err != nil {
+			return err
+		}
+	}
+
+	_ = logger.StartSpinner("Generating a new secret_key_base...")
+	secretKeyBase, err := client.GenerateSecretKeyBase()
+	if err == nil {
+		_, err = client.SetConfigValue(config, "secret_key_base", secretKeyBase)
+	}
+	if err := logger.StopSpinner(err); err != nil {
+		return err
+	}
+
+	if parent.Backend == "kubernetes" {
+		_ = logger.StartSpinner("Updating the core Secret and restarting Core...")
+		rolloutCtx, cancel := context.WithTimeout(ctx, r.Timeout)
+		err = logger.StopSpinner(upgrader.RotateSecretKeyBase(rolloutCtx, secretKeyBase))
+		cancel()
+	} else {
+		cmdEnv := map[string]string{"SECRET_KEY_BASE": secretKeyBase, docker.HostEnv: deploy.RecordedDockerHost(parent.Name)}
+		ctx = context.WithValue(ctx, pkg.FLContextKey("env"), cmdEnv)
+
+		logger.Info("\nRecreating core...\n\n")
+		err = dk.ComposeRecreate(ctx, parent.Name, composeFilePath, "core")
+	}
+
+	if err != nil {
+		// keep the config in sync with the key the deployment is still using
+		if _, restoreErr := client.SetConfigValue(config, "secret_key_base", config.SecretKeyBase); restoreErr != nil {
+			logger.Infof("Couldn't restore the previous secret_key_base in ~/.fl/config: %v\n", restoreErr)
+		}
+		return err
+	}
+
+	logger.Info("\nsecret_key_base rotated and saved in ~/.fl/config 🔒\n")
+	return nil
+}
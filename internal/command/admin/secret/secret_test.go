@@ -0,0 +1,126 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin_secret
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/funlessdev/fl-cli/pkg"
+	"github.com/funlessdev/fl-cli/pkg/client"
+	"github.com/funlessdev/fl-cli/pkg/homedir"
+	"github.com/funlessdev/fl-cli/pkg/log"
+	"github.com/funlessdev/fl-cli/test/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotateSecret(t *testing.T) {
+	homedirPath, err := os.MkdirTemp("", "funless-test-homedir-")
+	require.NoError(t, err)
+
+	homedir.GetHomeDir = func() (string, error) {
+		return homedirPath, nil
+	}
+	defer func() {
+		homedir.GetHomeDir = os.UserHomeDir
+		os.RemoveAll(homedirPath)
+	}()
+
+	ctx := context.Background()
+	cmd := RotateSecret{Timeout: time.Minute}
+
+	writeConfig := func(t *testing.T) client.Config {
+		_, err := homedir.WriteToConfigDir(pkg.ConfigFileName, []byte("secret_key_base=old-key\n"), true)
+		require.NoError(t, err)
+		config, err := client.NewConfig(pkg.ConfigFileName)
+		require.NoError(t, err)
+		return config
+	}
+
+	readKey := func(t *testing.T) string {
+		config, err := client.NewConfig(pkg.ConfigFileName)
+		require.NoError(t, err)
+		return config.SecretKeyBase
+	}
+
+	t.Run("should return error when no docker deployment is found", func(t *testing.T) {
+		var outbuf bytes.Buffer
+		logger, _ := log.NewLoggerBuilder().WithWriter(&outbuf).DisableAnimation().Build()
+
+		err := cmd.Run(ctx, mocks.NewDockerShell(t), mocks.NewKubernetesUpgrader(t), logger, writeConfig(t), &Secret{Backend: "docker", Name: "fl"})
+		require.Error(t, err)
+		require.Equal(t, "old-key", readKey(t))
+	})
+
+	t.Run("should save the new key and recreate the docker core", func(t *testing.T) {
+		path, err := homedir.WriteToConfigDir("docker-compose.yml", []byte("services: {}\n"), true)
+		require.NoError(t, err)
+
+		var outbuf bytes.Buffer
+		logger, _ := log.NewLoggerBuilder().WithWriter(&outbuf).DisableAnimation().Build()
+
+		var envKey string
+		dk := mocks.NewDockerShell(t)
+		dk.On("ComposeRecreate", mock.Anything, "fl", path, "core").Run(func(args mock.Arguments) {
+			env := args.Get(0).(context.Context).Value(pkg.FLContextKey("env")).(map[string]string)
+			envKey = env["SECRET_KEY_BASE"]
+		}).Return(nil)
+
+		err = cmd.Run(ctx, dk, mocks.NewKubernetesUpgrader(t), logger, writeConfig(t), &Secret{Backend: "docker", Name: "fl"})
+		require.NoError(t, err)
+
+		newKey := readKey(t)
+		require.NotEqual(t, "old-key", newKey)
+		require.Equal(t, newKey, envKey)
+		require.Contains(t, outbuf.String(), "secret_key_base rotated")
+	})
+
+	t.Run("should restore the previous key when the kubernetes rotation fails", func(t *testing.T) {
+		var outbuf bytes.Buffer
+		logger, _ := log.NewLoggerBuilder().WithWriter(&outbuf).DisableAnimation().Build()
+
+		upgrader := mocks.NewKubernetesUpgrader(t)
+		upgrader.On("WithConfig", "/kube/config").Return(nil)
+		upgrader.On("RotateSecretKeyBase", mock.Anything, mock.Anything).Return(errors.New("rotate error"))
+
+		err := cmd.Run(ctx, mocks.NewDockerShell(t), upgrader, logger, writeConfig(t), &Secret{Backend: "kubernetes", KubeConfig: "/kube/config"})
+		require.Error(t, err)
+		require.Equal(t, "old-key", readKey(t))
+	})
+
+	t.Run("should save the key applied to the kubernetes deployment", func(t *testing.T) {
+		var outbuf bytes.Buffer
+		logger, _ := log.NewLoggerBuilder().WithWriter(&outbuf).DisableAnimation().Build()
+
+		var applied string
+		upgrader := mocks.NewKubernetesUpgrader(t)
+		upgrader.On("WithConfig", "").Return(nil)
+		upgrader.On("RotateSecretKeyBase", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			_, hasDeadline := args.Get(0).(context.Context).Deadline()
+			require.True(t, hasDeadline)
+			applied = args.String(1)
+		}).Return(nil)
+
+		err := cmd.Run(ctx, mocks.NewDockerShell(t), upgrader, logger, writeConfig(t), &Secret{Backend: "kubernetes"})
+		require.NoError(t, err)
+		require.Equal(t, applied, readKey(t))
+		require.Len(t, applied, 64)
+	})
+}
@@ -0,0 +1,101 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builder
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/funlessdev/fl-cli/pkg"
+	"github.com/funlessdev/fl-cli/pkg/build"
+	"github.com/funlessdev/fl-cli/pkg/docker"
+	"github.com/funlessdev/fl-cli/pkg/log"
+)
+
+type Lock struct {
+	Dir      string   `arg:"" type:"existingdir" default:"." help:"Path of the source directory holding the fl.lock file"`
+	Language []string `short:"l" enum:"${languages}" help:"Languages to pin (default: all of them)"`
+	Update   bool     `name:"update" short:"u" help:"Pull the builder images again and pin them to their current digest"`
+}
+
+func (l *Lock) Help() string {
+	return `
+DESCRIPTION
+
+	Resolve the builder image of each language to its registry digest and
+	record it in the fl.lock file of the source directory. Docker builds of
+	that directory then use the pinned images, so they are the same on
+	every machine, whatever the local :latest tag points to.
+	Languages already pinned are left as they are: use "--update" to pull
+	the latest builder images and pin them again.
+	The "--language" flag limits the lock to some languages. The languages
+	without a builder image, like go, build natively and are skipped.
+
+EXAMPLES
+
+	$ fl build lock
+
+	$ fl build lock <your-function-source> --language=rust
+
+	$ fl build lock --update`
+}
+
+func (l *Lock) Run(ctx context.Context, rt docker.Runtime, logger log.FLogger) error {
+	dir := filepath.Clean(l.Dir)
+	lock, err := build.ReadLock(dir)
+	if err != nil {
+		return err
+	}
+
+	languages := l.Language
+	if len(languages) == 0 {
+		// the languages without a builder image only build natively, there is nothing to pin
+		for id, lang := range pkg.SupportedLanguages {
+			if lang.BuilderImage != "" {
+				languages = append(languages, id)
+			}
+		}
+	}
+
+	cli, err := docker.NewClient(rt, "")
+	if err != nil {
+		return err
+	}
+	flDocker := docker.NewDockerClient(cli)
+
+	_ = logger.StartSpinner("Resolving the builder images 📦")
+	pullCtx := docker.WithPullProgress(ctx, func(p docker.PullProgress) {
+		logger.SpinnerMessage(fmt.Sprintf("Resolving the builder images 📦 pulling %s %s", p.Image, p))
+	})
+	changed, err := lock.Update(pullCtx, flDocker, languages, l.Update)
+	if err := logger.StopSpinner(err); err != nil {
+		return err
+	}
+
+	if len(changed) == 0 {
+		logger.Infof("%s is up to date.\n", build.LockFileName)
+		return nil
+	}
+	if err := lock.Write(dir); err != nil {
+		return err
+	}
+	for _, language := range changed {
+		logger.Infof("Pinned %s to %s\n", language, lock.Builders[language])
+	}
+	logger.Infof("Updated %s (%s).\n", filepath.Join(dir, build.LockFileName), strings.Join(changed, ", "))
+	return nil
+}
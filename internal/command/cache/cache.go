@@ -0,0 +1,23 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+type Cache struct {
+	Ls    Ls    `cmd:"" aliases:"list" help:"List the cached wasm files"`
+	Prune Prune `cmd:"" help:"Remove the least recently used wasm files"`
+	Clear Clear `cmd:"" help:"Remove every cached wasm file"`
+
+	PurgeDeps PurgeDeps `cmd:"" name:"purge-deps" help:"Remove the dependency caches of the builder images"`
+}
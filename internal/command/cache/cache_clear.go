@@ -0,0 +1,47 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"github.com/funlessdev/fl-cli/pkg/build"
+	"github.com/funlessdev/fl-cli/pkg/log"
+)
+
+type Clear struct{}
+
+func (c *Clear) Help() string {
+	return `
+DESCRIPTION
+
+	Remove every wasm file from the build cache (~/.fl/cache). The next
+	build of each function runs the builder again.
+
+EXAMPLES
+
+	$ fl cache clear`
+}
+
+func (c *Clear) Run(logger log.FLogger) error {
+	cache, err := build.OpenCache()
+	if err != nil {
+		return err
+	}
+	removed, err := cache.Clear()
+	if err != nil {
+		return err
+	}
+	logger.Infof("Removed %d files from the build cache.\n", removed)
+	return nil
+}
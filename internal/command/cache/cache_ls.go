@@ -0,0 +1,79 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"bytes"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/docker/go-units"
+	"github.com/funlessdev/fl-cli/pkg/build"
+	"github.com/funlessdev/fl-cli/pkg/log"
+)
+
+const lsTimeFormat = "2006-01-02 15:04:05"
+
+// length of the keys shown by ls, as for image IDs
+const shortKeyLength = 12
+
+type Ls struct{}
+
+func (l *Ls) Help() string {
+	return `
+DESCRIPTION
+
+	List the wasm files in the build cache (~/.fl/cache), the most
+	recently used first, with their size and the total size of the cache.
+	Each file is stored under a hash of the function source (without the
+	paths listed in .flignore), its language and the builder image, so
+	builds of unchanged functions reuse it instead of running the builder.
+
+EXAMPLES
+
+	$ fl cache ls`
+}
+
+func (l *Ls) Run(logger log.FLogger) error {
+	cache, err := build.OpenCache()
+	if err != nil {
+		return err
+	}
+	entries, err := cache.List()
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		logger.Info("The build cache is empty.\n")
+		return nil
+	}
+
+	var total int64
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tSIZE\tLAST USED")
+	for _, e := range entries {
+		total += e.Size
+		fmt.Fprintf(w, "%s\t%s\t%s\n", e.Key[:shortKeyLength], units.HumanSize(float64(e.Size)), e.LastUsed.Local().Format(lsTimeFormat))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	logger.Info(buf.String())
+	logger.Infof("\n%d files, %s\n", len(entries), units.HumanSize(float64(total)))
+	return nil
+}
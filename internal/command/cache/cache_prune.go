@@ -0,0 +1,71 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/funlessdev/fl-cli/pkg/build"
+	"github.com/funlessdev/fl-cli/pkg/log"
+)
+
+type Prune struct {
+	MaxSize   string        `name:"max-size" default:"1GB" help:"Size the cache is reduced to, removing the least recently used files first"`
+	OlderThan time.Duration `name:"older-than" help:"Also remove the files unused for longer than this (e.g. 720h)"`
+}
+
+func (p *Prune) Help() string {
+	return `
+DESCRIPTION
+
+	Remove wasm files from the build cache (~/.fl/cache): those unused for
+	longer than "--older-than", if given, then the least recently used
+	ones until the cache fits in "--max-size" (1GB by default).
+
+EXAMPLES
+
+	$ fl cache prune
+
+	$ fl cache prune --max-size 200MB --older-than 720h`
+}
+
+func (p *Prune) Run(logger log.FLogger) error {
+	maxSize, err := units.FromHumanSize(p.MaxSize)
+	if err != nil {
+		return fmt.Errorf("invalid --max-size %q: %w", p.MaxSize, err)
+	}
+	if p.OlderThan < 0 {
+		return errors.New("--older-than must not be negative")
+	}
+
+	cache, err := build.OpenCache()
+	if err != nil {
+		return err
+	}
+	removed, err := cache.Prune(maxSize, p.OlderThan)
+	if err != nil {
+		return err
+	}
+
+	var freed int64
+	for _, e := range removed {
+		freed += e.Size
+	}
+	logger.Infof("Removed %d files, freed %s.\n", len(removed), units.HumanSize(float64(freed)))
+	return nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"strings"
+
+	"github.com/funlessdev/fl-cli/pkg/build"
+	"github.com/funlessdev/fl-cli/pkg/docker"
+	"github.com/funlessdev/fl-cli/pkg/log"
+)
+
+type PurgeDeps struct {
+	Language string `name:"language" short:"l" enum:"${languages}," default:"" help:"Only purge the dependency caches of this language"`
+}
+
+func (p *PurgeDeps) Help() string {
+	return `
+DESCRIPTION
+
+	Remove the Docker volumes keeping the dependencies downloaded by the
+	builder images (cargo registry, npm cache, Go module cache) across builds. The next
+	build of each language downloads them again. Volumes used by a running
+	build cannot be removed.
+	The "--language" flag limits the purge to one language.
+
+EXAMPLES
+
+	$ fl cache purge-deps
+
+	$ fl cache purge-deps --language rust`
+}
+
+func (p *PurgeDeps) Run(ctx context.Context, rt docker.Runtime, logger log.FLogger) error {
+	cli, err := docker.NewClient(rt, "")
+	if err != nil {
+		return err
+	}
+	flDocker := docker.NewDockerClient(cli)
+
+	removed, err := build.PurgeDependencyCaches(ctx, flDocker, p.Language)
+	if len(removed) > 0 {
+		logger.Infof("Removed %s.\n", strings.Join(removed, ", "))
+	} else if err == nil {
+		logger.Info("No dependency caches found.\n")
+	}
+	return err
+}
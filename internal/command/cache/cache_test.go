@@ -0,0 +1,82 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/funlessdev/fl-cli/pkg/build"
+	"github.com/funlessdev/fl-cli/pkg/homedir"
+	"github.com/funlessdev/fl-cli/pkg/log"
+	"github.com/stretchr/testify/require"
+)
+
+func testLogger() (*bytes.Buffer, log.FLogger) {
+	var outbuf bytes.Buffer
+	testLogger, _ := log.NewLoggerBuilder().WithWriter(&outbuf).DisableAnimation().Build()
+	return &outbuf, testLogger
+}
+
+func TestCacheCommands(t *testing.T) {
+	homedirPath := t.TempDir()
+	homedir.GetHomeDir = func() (string, error) {
+		return homedirPath, nil
+	}
+	t.Cleanup(func() {
+		homedir.GetHomeDir = os.UserHomeDir
+	})
+
+	t.Run("ls should report an empty cache", func(t *testing.T) {
+		out, logger := testLogger()
+		require.NoError(t, (&Ls{}).Run(logger))
+		require.Equal(t, "The build cache is empty.\n", out.String())
+	})
+
+	cache, err := build.OpenCache()
+	require.NoError(t, err)
+	wasm := filepath.Join(t.TempDir(), "code.wasm")
+	require.NoError(t, os.WriteFile(wasm, bytes.Repeat([]byte("w"), 1000), 0600))
+	for _, key := range []string{"0123456789abcdef", "fedcba9876543210"} {
+		require.NoError(t, cache.Put(key, wasm))
+	}
+
+	t.Run("ls should list the entries and the total size", func(t *testing.T) {
+		out, logger := testLogger()
+		require.NoError(t, (&Ls{}).Run(logger))
+		require.Contains(t, out.String(), "KEY           SIZE")
+		require.Contains(t, out.String(), "0123456789ab  1kB")
+		require.Contains(t, out.String(), "2 files, 2kB")
+	})
+
+	t.Run("prune should reject invalid sizes", func(t *testing.T) {
+		_, logger := testLogger()
+		require.ErrorContains(t, (&Prune{MaxSize: "lots"}).Run(logger), "invalid --max-size")
+	})
+
+	t.Run("prune should shrink the cache to the max size", func(t *testing.T) {
+		out, logger := testLogger()
+		require.NoError(t, (&Prune{MaxSize: "1kB"}).Run(logger))
+		require.Equal(t, "Removed 1 files, freed 1kB.\n", out.String())
+	})
+
+	t.Run("clear should remove every entry", func(t *testing.T) {
+		out, logger := testLogger()
+		require.NoError(t, (&Clear{}).Run(logger))
+		require.Equal(t, "Removed 1 files from the build cache.\n", out.String())
+	})
+}
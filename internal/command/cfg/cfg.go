@@ -16,15 +16,8 @@ package cfg
 
 import (
 	"context"
-	"fmt"
-	"os"
-	"path"
-	"regexp"
-	"strings"
 
-	"github.com/funlessdev/fl-cli/pkg"
 	"github.com/funlessdev/fl-cli/pkg/client"
-	"github.com/funlessdev/fl-cli/pkg/homedir"
 	"github.com/funlessdev/fl-cli/pkg/log"
 )
 
@@ -43,34 +36,7 @@ type CfgGet struct {
 }
 
 func (g *CfgSet) Run(ctx context.Context, logger log.FLogger, config client.Config) error {
-
-	var configBasePath string
-
-	if config.Path == "" {
-		configBasePath = pkg.ConfigFileName
-	} else {
-		configBasePath = path.Base(config.Path)
-	}
-
-	configText, _, err := homedir.ReadFromConfigDir(configBasePath)
-
-	if err != nil && !os.IsNotExist(err) {
-		return err
-	}
-
-	configString := string(configText[:])
-	r, _ := regexp.Compile(fmt.Sprintf("%s=(.+)", g.Key))
-
-	var outConfig string
-
-	if r.MatchString(configString) {
-		outConfig = r.ReplaceAllLiteralString(configString, fmt.Sprintf("%s=%s", g.Key, g.Value))
-	} else {
-		outConfig = fmt.Sprintf("%s\n%s=%s\n", strings.Trim(configString, "\n"), g.Key, g.Value)
-	}
-
-	_, err = homedir.WriteToConfigDir(configBasePath, []byte(outConfig), true)
-	if err != nil {
+	if _, err := client.SetConfigValue(config, g.Key, g.Value); err != nil {
 		return err
 	}
 
@@ -90,6 +56,10 @@ func (g *CfgGet) Run(ctx context.Context, logger log.FLogger, config client.Conf
 		cfgValue = config.AdminToken
 	case "secret_key_base":
 		cfgValue = config.SecretKeyBase
+	case "database_url":
+		cfgValue = config.DatabaseURL
+	case "ca_cert":
+		cfgValue = config.CACert
 	}
 
 	logger.Infof("%s=%s\n", g.Key, cfgValue)
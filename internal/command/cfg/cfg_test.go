@@ -47,7 +47,7 @@ func TestCfg(t *testing.T) {
 	config, err := client.NewConfig("config")
 	require.NoError(t, err)
 
-	setCmds := [4]CfgSet{
+	setCmds := [5]CfgSet{
 		{
 			Key:   "api_host",
 			Value: "test_host",
@@ -64,6 +64,10 @@ func TestCfg(t *testing.T) {
 			Key:   "secret_key_base",
 			Value: "test_secret_key_base",
 		},
+		{
+			Key:   "database_url",
+			Value: "postgres://fl:pass@db:5432/funless?sslmode=disable",
+		},
 	}
 
 	for _, c := range setCmds {
@@ -74,7 +78,7 @@ func TestCfg(t *testing.T) {
 	config, err = client.NewConfig("config")
 	require.NoError(t, err)
 
-	getCmds := [4]CfgGet{
+	getCmds := [5]CfgGet{
 		{
 			Key: "api_host",
 		},
@@ -87,6 +91,9 @@ func TestCfg(t *testing.T) {
 		{
 			Key: "secret_key_base",
 		},
+		{
+			Key: "database_url",
+		},
 	}
 
 	for _, c := range getCmds {
@@ -98,7 +105,8 @@ func TestCfg(t *testing.T) {
 		"api_host=test_host\n" +
 			"api_token=test_api_token\n" +
 			"admin_token=test_admin_token\n" +
-			"secret_key_base=test_secret_key_base\n"
+			"secret_key_base=test_secret_key_base\n" +
+			"database_url=postgres://fl:pass@db:5432/funless?sslmode=disable\n"
 
 	require.Equal(t, expected, outbuf.String())
 }
@@ -20,41 +20,96 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
-	"github.com/docker/docker/client"
 	"github.com/funlessdev/fl-cli/pkg"
 	"github.com/funlessdev/fl-cli/pkg/build"
 	"github.com/funlessdev/fl-cli/pkg/docker"
 	"github.com/funlessdev/fl-cli/pkg/log"
 )
 
+// value of --builder selecting the toolchains installed on the host
+const nativeBuilder = "native"
+
 type Build struct {
 	Name        string `arg:"" help:"The name of the function"`
 	Source      string `arg:"" type:"existingdir" help:"Path of the source directory"`
 	Destination string `short:"d" type:"path" help:"Path where the compiled wasm file will be saved" default:"."`
-	Language    string `short:"l" enum:"rust,js" required:"" help:"Programming language of the function"`
+	Language    string `short:"l" enum:"${languages}," default:"" help:"Programming language of the function, detected from the source files by default"`
+	Builder     string `name:"builder" enum:"docker,native" default:"docker" help:"Build in the builder image (docker) or with the toolchains installed on the host (native)"`
+	NoDepCache  bool   `name:"no-dep-cache" help:"Do not reuse the dependencies downloaded by previous docker builds"`
+	Pull        string `name:"pull" enum:"always,missing,never" default:"missing" help:"When to pull the builder image: always, missing or never"`
 }
 
 func (c *Build) Help() string {
-	return `
+	return fmt.Sprintf(`
 DESCRIPTION
 
 	It compiles a wasm binary from the function specified in the source arg.
-	The "--language" flag is required, with the following possible values: [rust, js].
+	The language is detected from the files of the source directory
+	(Cargo.toml for rust, package.json for js, go.mod for go, and the
+	required files and extensions of the languages added in
+	languages.yaml). The "--language" flag overrides it, with the
+	following possible values: [%s].
 	The "--destination" flag can be used to choose a output directory 
 	other than the default one. 
+	The "--builder" flag chooses how to build: "docker" (the default) runs
+	the builder image of the language, "native" calls the toolchains
+	installed on the host, cargo with the wasm32-wasi target for Rust,
+	jco componentize for JS (the main module of package.json, with the
+	WIT world in the wit directory) and tinygo with the wasi target for Go.
+	Go has no builder image yet, so Go functions need "--builder=native"
+	unless languages.yaml sets a builder_image for go.
+	Docker builds are cached in ~/.fl/cache: an unchanged source, built
+	for the same language with the same builder image, reuses the cached
+	wasm file. Use "fl cache" to manage the cache.
+	The builder only sees a copy of the source without the paths matching
+	the patterns of a .flignore file in the source directory, written
+	like a .gitignore, nor .git, target, node_modules and editor files,
+	which "!" patterns can bring back. The ignored paths are not part of
+	the cache hash either.
+	The dependencies downloaded by the builder (cargo registry, npm cache,
+	Go module cache) are kept in a Docker volume per language, shared across builds. The
+	"--no-dep-cache" flag builds without them, "fl cache purge-deps"
+	removes them.
+	The "--pull" flag sets when the builder image is pulled: "missing"
+	(the default) only pulls it when it is not found locally, "always"
+	pulls the latest one, "never" fails the build if it is missing.
+	When the source directory has a fl.lock file (see "fl build lock"),
+	the builder image is the one pinned to its digest in the file.
 
 EXAMPLES
 	
+	$ fl fn build <your-function-name> <your-function-source>
+
 	$ fl fn build <your-function-name> <your-function-source> --language=<lang-from-enum> --destination=<your-output-directory>
-`
+
+	$ fl fn build <your-function-name> <your-function-source> --language=rust --builder=native
+
+	$ fl fn build <your-function-name> <your-function-source> --language=rust --pull=always
+`, strings.Join(pkg.LanguageIDs(), ", "))
 
 }
-func (b *Build) Run(ctx context.Context, builder build.DockerBuilder, logger log.FLogger) error {
+func (b *Build) Run(ctx context.Context, builder build.DockerBuilder, native build.NativeBuilder, rt docker.Runtime, logger log.FLogger) error {
+	lang, err := resolveLanguage(b.Language, b.Source, logger)
+	if err != nil {
+		return err
+	}
+	b.Language = lang
+
 	logger.Info(fmt.Sprintf("Building %s into a wasm binary...\n\n", b.Name))
+	if b.NoDepCache {
+		ctx = build.WithoutDependencyCache(ctx)
+	}
 
+	var wasmBuilder build.Builder = builder
 	_ = logger.StartSpinner("Setting up...")
-	if err := logger.StopSpinner(setupBuilder(builder, b.Language, b.Destination)); err != nil {
+	if b.Builder == nativeBuilder {
+		wasmBuilder = native
+		if err := logger.StopSpinner(native.Setup(b.Language, filepath.Clean(b.Destination))); err != nil {
+			return err
+		}
+	} else if err := logger.StopSpinner(setupBuilder(builder, rt, b.Language, b.Destination)); err != nil {
 		return err
 	}
 
@@ -63,15 +118,31 @@ func (b *Build) Run(ctx context.Context, builder build.DockerBuilder, logger log
 		return err
 	}
 
-	_ = logger.StartSpinner(fmt.Sprintf("Pulling %s builder image (%s) 📦", pkg.SupportedLanguages[b.Language].Name, pkg.SupportedLanguages[b.Language].BuilderImage))
-	if err := logger.StopSpinner(builder.PullBuilderImage(ctx)); err != nil {
-		return err
+	if b.Builder == nativeBuilder {
+		_ = logger.StartSpinner(fmt.Sprintf("Checking %s toolchains 🔧", pkg.SupportedLanguages[b.Language].Name))
+		if err := logger.StopSpinner(native.CheckToolchains(ctx)); err != nil {
+			return err
+		}
+	} else {
+		lock, err := build.ReadLock(b.Source)
+		if err != nil {
+			return err
+		}
+		ctx = build.WithLock(build.WithPullPolicy(ctx, b.Pull), lock)
+		msg := fmt.Sprintf("Pulling %s builder image (%s) 📦", pkg.SupportedLanguages[b.Language].Name, lock.Image(b.Language))
+		_ = logger.StartSpinner(msg)
+		pullCtx := docker.WithPullProgress(ctx, func(p docker.PullProgress) {
+			logger.SpinnerMessage(msg + " " + p.String())
+		})
+		if err := logger.StopSpinner(builder.PullBuilderImage(pullCtx)); err != nil {
+			return err
+		}
 	}
 	_ = logger.StartSpinner("Building source... 🛠️")
-	if err := builder.BuildSource(ctx, b.Source); err != nil {
+	if err := wasmBuilder.BuildSource(ctx, b.Source); err != nil {
 		return logger.StopSpinner(err)
 	}
-	if err := logger.StopSpinner(builder.RenameCodeWasm(b.Name)); err != nil {
+	if err := logger.StopSpinner(wasmBuilder.RenameCodeWasm(b.Name)); err != nil {
 		return err
 	}
 
@@ -79,8 +150,21 @@ func (b *Build) Run(ctx context.Context, builder build.DockerBuilder, logger log
 	return nil
 }
 
-func setupBuilder(builder build.DockerBuilder, lang, out string) error {
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithVersion("1.41"))
+// resolveLanguage returns the language of the flag or, when it is empty, the one detected from the source directory
+func resolveLanguage(lang, source string, logger log.FLogger) (string, error) {
+	if lang != "" {
+		return lang, nil
+	}
+	lang, err := pkg.DetectLanguage(source)
+	if err != nil {
+		return "", err
+	}
+	logger.Infof("Detected a %s function in %s\n", pkg.SupportedLanguages[lang].Name, source)
+	return lang, nil
+}
+
+func setupBuilder(builder build.DockerBuilder, rt docker.Runtime, lang, out string) error {
+	cli, err := docker.NewClient(rt, "")
 	if err != nil {
 		return err
 	}
@@ -21,9 +21,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/funlessdev/fl-cli/pkg"
+	"github.com/funlessdev/fl-cli/pkg/build"
+	"github.com/funlessdev/fl-cli/pkg/docker"
 	"github.com/funlessdev/fl-cli/pkg/log"
 	"github.com/funlessdev/fl-cli/test/mocks"
 	"github.com/stretchr/testify/mock"
@@ -38,6 +42,8 @@ func TestFnBuild(t *testing.T) {
 	testOutDir, _ := filepath.Abs("../../../test/fixtures")
 
 	ctx := context.TODO()
+	// the docker builds run with the pull policy and the (empty) lock of the source directory
+	buildCtx := build.WithLock(build.WithPullPolicy(ctx, ""), &build.Lock{Builders: map[string]string{}})
 
 	testLogger, _ := log.NewLoggerBuilder().WithWriter(os.Stdout).DisableAnimation().Build()
 
@@ -53,10 +59,10 @@ func TestFnBuild(t *testing.T) {
 		}
 
 		mockBuilder.On("Setup", mock.Anything, testLanguage, testOutDir).Return(nil).Once()
-		mockBuilder.On("PullBuilderImage", ctx).Return(nil).Once()
-		mockBuilder.On("BuildSource", ctx, testDir).Return(nil).Once()
+		mockBuilder.On("PullBuilderImage", pullingWith(buildCtx)).Return(nil).Once()
+		mockBuilder.On("BuildSource", buildCtx, testDir).Return(nil).Once()
 
-		err := cmd.Run(ctx, mockBuilder, testLogger)
+		err := cmd.Run(ctx, mockBuilder, mocks.NewNativeBuilder(t), docker.RuntimeDocker, testLogger)
 		require.NoError(t, err)
 
 		mockBuilder.AssertNumberOfCalls(t, "BuildSource", 1)
@@ -74,13 +80,13 @@ func TestFnBuild(t *testing.T) {
 		output := genTestOutput(testFn, pkg.SupportedLanguages[testLanguage].BuilderImage, testDir)
 
 		mockBuilder.On("Setup", mock.Anything, testLanguage, testOutDir).Return(nil).Once()
-		mockBuilder.On("PullBuilderImage", ctx).Return(nil).Once()
-		mockBuilder.On("BuildSource", ctx, testDir).Return(nil).Once()
+		mockBuilder.On("PullBuilderImage", pullingWith(buildCtx)).Return(nil).Once()
+		mockBuilder.On("BuildSource", buildCtx, testDir).Return(nil).Once()
 
 		var outbuf bytes.Buffer
 		bufLogger, _ := log.NewLoggerBuilder().WithWriter(&outbuf).DisableAnimation().Build()
 
-		err := cmd.Run(ctx, mockBuilder, bufLogger)
+		err := cmd.Run(ctx, mockBuilder, mocks.NewNativeBuilder(t), docker.RuntimeDocker, bufLogger)
 
 		require.NoError(t, err)
 		require.Equal(t, output, (&outbuf).String())
@@ -97,7 +103,7 @@ func TestFnBuild(t *testing.T) {
 
 		mockBuilder.On("Setup", mock.Anything, testLanguage, testOutDir).Return(errors.New("some error")).Once()
 
-		err := cmd.Run(ctx, mockBuilder, testLogger)
+		err := cmd.Run(ctx, mockBuilder, mocks.NewNativeBuilder(t), docker.RuntimeDocker, testLogger)
 		require.Error(t, err)
 		mockBuilder.AssertExpectations(t)
 	})
@@ -111,9 +117,9 @@ func TestFnBuild(t *testing.T) {
 		}
 
 		mockBuilder.On("Setup", mock.Anything, testLanguage, testOutDir).Return(nil).Once()
-		mockBuilder.On("PullBuilderImage", ctx).Return(errors.New("some error")).Once()
+		mockBuilder.On("PullBuilderImage", pullingWith(buildCtx)).Return(errors.New("some error")).Once()
 
-		err := cmd.Run(ctx, mockBuilder, testLogger)
+		err := cmd.Run(ctx, mockBuilder, mocks.NewNativeBuilder(t), docker.RuntimeDocker, testLogger)
 		require.Error(t, err)
 		mockBuilder.AssertExpectations(t)
 	})
@@ -127,13 +133,151 @@ func TestFnBuild(t *testing.T) {
 		}
 
 		mockBuilder.On("Setup", mock.Anything, testLanguage, testOutDir).Return(nil).Once()
-		mockBuilder.On("PullBuilderImage", ctx).Return(nil).Once()
-		mockBuilder.On("BuildSource", ctx, testDir).Return(errors.New("some error")).Once()
+		mockBuilder.On("PullBuilderImage", pullingWith(buildCtx)).Return(nil).Once()
+		mockBuilder.On("BuildSource", buildCtx, testDir).Return(errors.New("some error")).Once()
 
-		err := cmd.Run(ctx, mockBuilder, testLogger)
+		err := cmd.Run(ctx, mockBuilder, mocks.NewNativeBuilder(t), docker.RuntimeDocker, testLogger)
 		require.Error(t, err)
 		mockBuilder.AssertExpectations(t)
 	})
+
+	t.Run("should check the toolchains instead of pulling with the native builder", func(t *testing.T) {
+		cmd := Build{
+			Name:        testFn,
+			Source:      testDir,
+			Destination: testOutDir,
+			Language:    testLanguage,
+			Builder:     "native",
+		}
+
+		mockNative := mocks.NewNativeBuilder(t)
+		mockNative.On("Setup", testLanguage, testOutDir).Return(nil).Once()
+		mockNative.On("CheckToolchains", ctx).Return(nil).Once()
+		mockNative.On("BuildSource", ctx, testDir).Return(nil).Once()
+		mockNative.On("RenameCodeWasm", testFn).Return(nil).Once()
+
+		var outbuf bytes.Buffer
+		bufLogger, _ := log.NewLoggerBuilder().WithWriter(&outbuf).DisableAnimation().Build()
+
+		err := cmd.Run(ctx, mocks.NewDockerBuilder(t), mockNative, docker.RuntimeDocker, bufLogger)
+		require.NoError(t, err)
+		require.Contains(t, outbuf.String(), "Checking Javascript toolchains 🔧\ndone\n")
+		require.NotContains(t, outbuf.String(), "Pulling")
+	})
+
+	t.Run("should return error if the native toolchains are missing", func(t *testing.T) {
+		cmd := Build{
+			Name:        testFn,
+			Source:      testDir,
+			Destination: testOutDir,
+			Language:    testLanguage,
+			Builder:     "native",
+		}
+
+		mockNative := mocks.NewNativeBuilder(t)
+		mockNative.On("Setup", testLanguage, testOutDir).Return(nil).Once()
+		mockNative.On("CheckToolchains", ctx).Return(errors.New("node not found in PATH")).Once()
+
+		err := cmd.Run(ctx, mocks.NewDockerBuilder(t), mockNative, docker.RuntimeDocker, testLogger)
+		require.ErrorContains(t, err, "node not found")
+	})
+
+	t.Run("should build without the dependency caches when asked", func(t *testing.T) {
+		cmd := Build{
+			Name:        testFn,
+			Source:      testDir,
+			Destination: testOutDir,
+			Language:    testLanguage,
+			NoDepCache:  true,
+		}
+
+		noDepCache := mock.MatchedBy(func(ctx context.Context) bool {
+			return ctx.Value(pkg.FLContextKey("no_dep_cache")) == true
+		})
+		mockBuilder.On("Setup", mock.Anything, testLanguage, testOutDir).Return(nil).Once()
+		mockBuilder.On("PullBuilderImage", noDepCache).Return(nil).Once()
+		mockBuilder.On("BuildSource", noDepCache, testDir).Return(nil).Once()
+
+		err := cmd.Run(ctx, mockBuilder, mocks.NewNativeBuilder(t), docker.RuntimeDocker, testLogger)
+		require.NoError(t, err)
+		mockBuilder.AssertExpectations(t)
+	})
+
+	t.Run("should build with the builder image pinned in fl.lock", func(t *testing.T) {
+		srcDir := t.TempDir()
+		pinned := "ghcr.io/funlessdev/fl-js-builder@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+		require.NoError(t, os.WriteFile(filepath.Join(srcDir, "package.json"), []byte("{}"), 0644))
+		require.NoError(t, (&build.Lock{Builders: map[string]string{testLanguage: pinned}}).Write(srcDir))
+
+		cmd := Build{
+			Name:        testFn,
+			Source:      srcDir,
+			Destination: testOutDir,
+			Language:    testLanguage,
+			Pull:        build.PullNever,
+		}
+
+		lockedCtx := build.WithLock(build.WithPullPolicy(ctx, build.PullNever), &build.Lock{Builders: map[string]string{testLanguage: pinned}})
+		mockBuilder.On("Setup", mock.Anything, testLanguage, testOutDir).Return(nil).Once()
+		mockBuilder.On("PullBuilderImage", pullingWith(lockedCtx)).Return(nil).Once()
+		mockBuilder.On("BuildSource", lockedCtx, srcDir).Return(nil).Once()
+
+		var outbuf bytes.Buffer
+		bufLogger, _ := log.NewLoggerBuilder().WithWriter(&outbuf).DisableAnimation().Build()
+
+		err := cmd.Run(ctx, mockBuilder, mocks.NewNativeBuilder(t), docker.RuntimeDocker, bufLogger)
+		require.NoError(t, err)
+		require.Equal(t, genTestOutput(testFn, pinned, srcDir), outbuf.String())
+		mockBuilder.AssertExpectations(t)
+	})
+
+	t.Run("should detect the language of the source", func(t *testing.T) {
+		srcDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(srcDir, "go.mod"), []byte("module hello\n"), 0644))
+
+		cmd := Build{
+			Name:        testFn,
+			Source:      srcDir,
+			Destination: testOutDir,
+		}
+
+		mockBuilder.On("Setup", mock.Anything, "go", testOutDir).Return(nil).Once()
+		mockBuilder.On("PullBuilderImage", mock.Anything).Return(nil).Once()
+		mockBuilder.On("BuildSource", mock.Anything, srcDir).Return(nil).Once()
+
+		var outbuf bytes.Buffer
+		bufLogger, _ := log.NewLoggerBuilder().WithWriter(&outbuf).DisableAnimation().Build()
+
+		err := cmd.Run(ctx, mockBuilder, mocks.NewNativeBuilder(t), docker.RuntimeDocker, bufLogger)
+		require.NoError(t, err)
+		require.Equal(t, "go", cmd.Language)
+		require.True(t, strings.HasPrefix(outbuf.String(), "Detected a Go function in "+srcDir+"\nBuilding test-fn into a wasm binary..."))
+		mockBuilder.AssertExpectations(t)
+	})
+
+	t.Run("should fail listing the candidates when the language is ambiguous", func(t *testing.T) {
+		cmd := Build{
+			Name:        testFn,
+			Source:      testDir,
+			Destination: testOutDir,
+		}
+
+		err := cmd.Run(ctx, mocks.NewDockerBuilder(t), mocks.NewNativeBuilder(t), docker.RuntimeDocker, testLogger)
+		require.ErrorContains(t, err, "it could be js (package.json), rust (Cargo.toml): use --language to choose")
+	})
+
+}
+
+// pullingWith matches the context of the pull: the one of the build, reporting the progress of the pull
+func pullingWith(buildCtx context.Context) interface{} {
+	return mock.MatchedBy(func(ctx context.Context) bool {
+		for _, key := range []string{"pull_policy", "lock"} {
+			if !reflect.DeepEqual(ctx.Value(pkg.FLContextKey(key)), buildCtx.Value(pkg.FLContextKey(key))) {
+				return false
+			}
+		}
+		return ctx.Value(pkg.FLContextKey("pull_progress")) != nil
+	})
 }
 
 func genTestOutput(name, image, source string) string {
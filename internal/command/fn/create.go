@@ -19,40 +19,58 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/funlessdev/fl-cli/pkg"
 	"github.com/funlessdev/fl-cli/pkg/build"
 	"github.com/funlessdev/fl-cli/pkg/client"
+	"github.com/funlessdev/fl-cli/pkg/docker"
 	"github.com/funlessdev/fl-cli/pkg/log"
 )
 
 type Create struct {
-	Name     string `arg:"" help:"Name of the function to create"`
-	Source   string `arg:"" type:"existingdir" help:"Path of the source directory"`
-	Module   string `short:"m" default:"_" help:"Module of the function to create"`
-	Language string `short:"l" required:"" enum:"rust,js" help:"Programming language of the function"`
+	Name       string `arg:"" help:"Name of the function to create"`
+	Source     string `arg:"" type:"existingdir" help:"Path of the source directory"`
+	Module     string `short:"m" default:"_" help:"Module of the function to create"`
+	Language   string `short:"l" enum:"${languages}," default:"" help:"Programming language of the function, detected from the source files by default"`
+	Builder    string `name:"builder" enum:"docker,native" default:"docker" help:"Build in the builder image (docker) or with the toolchains installed on the host (native)"`
+	NoDepCache bool   `name:"no-dep-cache" help:"Do not reuse the dependencies downloaded by previous docker builds"`
+	Pull       string `name:"pull" enum:"always,missing,never" default:"missing" help:"When to pull the builder image: always, missing or never"`
 }
 
 func (c *Create) Help() string {
-	return `
+	return fmt.Sprintf(`
 DESCRIPTION
 
 	It builds and uploads a function with the specified name from the 
 	given source. 
-	The "--language" flag is required, with the following possible values: [rust, js].
+	The language is detected from the files of the source directory, as
+	in "fl fn build". The "--language" flag overrides it, with the
+	following possible values: [%s].
 	The "--module" flag can be used to choose a module other than 
 	the default one. 
+	The "--builder", "--no-dep-cache" and "--pull" flags choose how to build, as in
+	"fl fn build".
 
 EXAMPLES
 	
 	$ fl fn create <your-function-name> <your-function-source> --language=<lang-from-enum> --module=<your-module-name>
-`
+`, strings.Join(pkg.LanguageIDs(), ", "))
 
 }
 
-func (c *Create) Run(ctx context.Context, builder build.DockerBuilder, fnHandler client.FnHandler, logger log.FLogger, parent *Fn) error {
+func (c *Create) Run(ctx context.Context, builder build.DockerBuilder, native build.NativeBuilder, rt docker.Runtime, fnHandler client.FnHandler, logger log.FLogger, parent *Fn) error {
 
 	ctx = context.WithValue(ctx, pkg.FLContextKey("api_host"), parent.Host)
+	if c.NoDepCache {
+		ctx = build.WithoutDependencyCache(ctx)
+	}
+
+	lang, err := resolveLanguage(c.Language, c.Source, logger)
+	if err != nil {
+		return err
+	}
+	c.Language = lang
 
 	logger.Infof("Creating %s function...\n\n", c.Name)
 
@@ -63,16 +81,36 @@ func (c *Create) Run(ctx context.Context, builder build.DockerBuilder, fnHandler
 	}
 	defer os.RemoveAll(dest)
 
-	if err := setupBuilder(builder, c.Language, dest); err != nil {
+	var wasmBuilder build.Builder = builder
+	if c.Builder == nativeBuilder {
+		wasmBuilder = native
+		if err := native.Setup(c.Language, dest); err != nil {
+			return logger.StopSpinner(err)
+		}
+	} else if err := setupBuilder(builder, rt, c.Language, dest); err != nil {
 		return logger.StopSpinner(err)
 	}
 	if err := checkMustContainFiles(c.Language, c.Source); err != nil {
 		return logger.StopSpinner(err)
 	}
-	if err := builder.PullBuilderImage(ctx); err != nil {
-		return logger.StopSpinner(err)
+	if c.Builder == nativeBuilder {
+		if err := native.CheckToolchains(ctx); err != nil {
+			return logger.StopSpinner(err)
+		}
+	} else {
+		lock, err := build.ReadLock(c.Source)
+		if err != nil {
+			return logger.StopSpinner(err)
+		}
+		ctx = build.WithLock(build.WithPullPolicy(ctx, c.Pull), lock)
+		pullCtx := docker.WithPullProgress(ctx, func(p docker.PullProgress) {
+			logger.SpinnerMessage("Building function...🏗 ️ pulling the builder image " + p.String())
+		})
+		if err := builder.PullBuilderImage(pullCtx); err != nil {
+			return logger.StopSpinner(err)
+		}
 	}
-	if err := builder.BuildSource(ctx, c.Source); err != nil {
+	if err := wasmBuilder.BuildSource(ctx, c.Source); err != nil {
 		return logger.StopSpinner(err)
 	}
 	_ = logger.StopSpinner(nil)
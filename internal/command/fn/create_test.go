@@ -23,6 +23,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/funlessdev/fl-cli/pkg/docker"
 	"github.com/funlessdev/fl-cli/pkg/log"
 	"github.com/funlessdev/fl-cli/test/mocks"
 	"github.com/stretchr/testify/mock"
@@ -73,7 +74,7 @@ Successfully created function %s/%s.
 
 		bufLogger, _ := log.NewLoggerBuilder().WithWriter(&outbuf).DisableAnimation().Build()
 
-		err := cmd.Run(ctx, mockBuilder, mockFnHandler, bufLogger, &Fn{})
+		err := cmd.Run(ctx, mockBuilder, mocks.NewNativeBuilder(t), docker.RuntimeDocker, mockFnHandler, bufLogger, &Fn{})
 
 		require.NoError(t, err)
 		assert.Equal(t, testResult, (&outbuf).String())
@@ -91,7 +92,7 @@ Successfully created function %s/%s.
 			Language: testLanguage,
 		}
 
-		err := cmd.Run(ctx, mockBuilder, mockFnHandler, testLogger, &Fn{})
+		err := cmd.Run(ctx, mockBuilder, mocks.NewNativeBuilder(t), docker.RuntimeDocker, mockFnHandler, testLogger, &Fn{})
 		require.NoError(t, err)
 
 		mockBuilder.AssertCalled(t, "BuildSource", mock.Anything, testDir)
@@ -110,7 +111,7 @@ Successfully created function %s/%s.
 		mockBuilder := mocks.NewDockerBuilder(t)
 		mockBuilder.On("Setup", mock.Anything, testLanguage, mock.Anything).Return(errors.New("some error")).Once()
 
-		err := cmd.Run(ctx, mockBuilder, mockFnHandler, testLogger, &Fn{})
+		err := cmd.Run(ctx, mockBuilder, mocks.NewNativeBuilder(t), docker.RuntimeDocker, mockFnHandler, testLogger, &Fn{})
 		require.Error(t, err)
 		mockBuilder.AssertExpectations(t)
 	})
@@ -127,7 +128,7 @@ Successfully created function %s/%s.
 		mockBuilder.On("Setup", mock.Anything, testLanguage, mock.Anything).Return(nil).Once()
 		mockBuilder.On("PullBuilderImage", mock.Anything).Return(errors.New("some error")).Once()
 
-		err := cmd.Run(ctx, mockBuilder, mockFnHandler, testLogger, &Fn{})
+		err := cmd.Run(ctx, mockBuilder, mocks.NewNativeBuilder(t), docker.RuntimeDocker, mockFnHandler, testLogger, &Fn{})
 		require.Error(t, err)
 		mockBuilder.AssertExpectations(t)
 	})
@@ -145,8 +146,27 @@ Successfully created function %s/%s.
 		mockBuilder.On("PullBuilderImage", mock.Anything).Return(nil).Once()
 		mockBuilder.On("BuildSource", mock.Anything, testDir).Return(errors.New("some error")).Once()
 
-		err := cmd.Run(ctx, mockBuilder, mockFnHandler, testLogger, &Fn{})
+		err := cmd.Run(ctx, mockBuilder, mocks.NewNativeBuilder(t), docker.RuntimeDocker, mockFnHandler, testLogger, &Fn{})
 		require.Error(t, err)
 		mockBuilder.AssertExpectations(t)
 	})
+
+	t.Run("should build with the native builder when asked", func(t *testing.T) {
+		cmd := Create{
+			Name:     testFn,
+			Module:   testMod,
+			Source:   testDir,
+			Language: testLanguage,
+			Builder:  "native",
+		}
+
+		mockNative := mocks.NewNativeBuilder(t)
+		mockNative.On("Setup", testLanguage, mock.Anything).Return(nil).Once()
+		mockNative.On("CheckToolchains", mock.Anything).Return(nil).Once()
+		mockNative.On("BuildSource", mock.Anything, testDir).Return(nil).Once()
+
+		err := cmd.Run(ctx, mocks.NewDockerBuilder(t), mockNative, docker.RuntimeDocker, mockFnHandler, testLogger, &Fn{})
+		require.NoError(t, err)
+	})
+
 }
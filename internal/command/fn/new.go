@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/funlessdev/fl-cli/internal/command/template"
 	"github.com/funlessdev/fl-cli/pkg"
@@ -27,25 +28,27 @@ import (
 
 type New struct {
 	Name        string `arg:"" help:"The name of the function"`
-	Language    string `name:"lang" short:"l" required:"" enum:"rust, js" help:"The language of the function"`
+	Language    string `name:"lang" short:"l" required:"" enum:"${languages}" help:"The language of the function"`
 	TemplateDir string `short:"t" type:"path" default:"." help:"The directory where the template are located"`
 	OutDir      string `short:"o" type:"path" default:"." help:"The directory where the function will be created"`
 }
 
 func (n *New) Help() string {
-	return `
+	return fmt.Sprintf(`
 DESCRIPTION
 
 	It creates a new function with specified name from a template.
-	The "--lang" flag is required, with the following possible values: [rust, js].
+	The "--lang" flag is required, with the following possible values: [%s].
 	The "--template-dir" and "--out-dir" flags can be used to choose a template 
 	directory and a output directory other than the default ones. 
+	The go template is shipped with the CLI, and used when the template
+	directory has none.
 
 EXAMPLES
 	
 	$ fl fn new <your-function-name> --lang=<lang-from-enum> --template-dir=<your-template-dir> --out-dir=<your-output-dir>
 
-`
+`, strings.Join(pkg.LanguageIDs(), ", "))
 }
 
 func (n *New) Run(ctx context.Context, logger log.FLogger) error {
@@ -69,13 +72,16 @@ func (n *New) Run(ctx context.Context, logger log.FLogger) error {
 		}
 	}
 
-	// if language template is still not available, return error
+	// if language template is still not available, fall back to the one shipped with the CLI or return error
 	if !folderExists(srcLanguageTemplate) {
-		return fmt.Errorf("no valid template for \"%s\" found", n.Language)
-	}
-
-	// copy template to current directory with the name of the function
-	if err := pkg.Copy(srcLanguageTemplate, destFunc); err != nil {
+		if !template.HasBuiltinTemplate(n.Language) {
+			return fmt.Errorf("no valid template for \"%s\" found", n.Language)
+		}
+		if err := template.WriteBuiltinTemplate(n.Language, destFunc); err != nil {
+			return err
+		}
+	} else if err := pkg.Copy(srcLanguageTemplate, destFunc); err != nil {
+		// copy template to current directory with the name of the function
 		return err
 	}
 
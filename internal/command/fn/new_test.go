@@ -115,4 +115,54 @@ func TestNew(t *testing.T) {
 		_, err = os.Stat(filepath.Join(testOutDir, "test"))
 		require.NoError(t, err)
 	})
+
+	t.Run("creates a go function from the built-in template when the template dir has none", func(t *testing.T) {
+		out.Reset()
+
+		testOutDir := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(testOutDir, "templates", "template", "js"), 0755))
+		newCmd := New{
+			Name:        "hello",
+			Language:    "go",
+			TemplateDir: filepath.Join(testOutDir, "templates"),
+			OutDir:      testOutDir,
+		}
+
+		err := newCmd.Run(ctx, testLogger)
+		require.NoError(t, err)
+		require.Equal(t, "Function \"hello\" created!\n", out.String())
+
+		require.NoError(t, checkMustContainFiles("go", filepath.Join(testOutDir, "hello")))
+		gomod, err := os.ReadFile(filepath.Join(testOutDir, "hello", "go.mod"))
+		require.NoError(t, err)
+		require.Contains(t, string(gomod), "module hello")
+		_, err = os.Stat(filepath.Join(testOutDir, "hello", "main.go"))
+		require.NoError(t, err)
+	})
+
+	t.Run("creates a go function from a local template", func(t *testing.T) {
+		out.Reset()
+
+		testOutDir := t.TempDir()
+		templateDir := filepath.Join(testOutDir, "templates")
+		require.NoError(t, os.MkdirAll(filepath.Join(templateDir, "template", "go"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(templateDir, "template", "go", "go.mod"), []byte("module hello\n"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(templateDir, "template", "go", "main.go"), []byte("package main\n"), 0644))
+		newCmd := New{
+			Name:        "hello",
+			Language:    "go",
+			TemplateDir: templateDir,
+			OutDir:      testOutDir,
+		}
+
+		err := newCmd.Run(ctx, testLogger)
+		require.NoError(t, err)
+		require.Equal(t, "Function \"hello\" created!\n", out.String())
+
+		// the function has the files the go builder needs
+		require.NoError(t, checkMustContainFiles("go", filepath.Join(testOutDir, "hello")))
+		_, err = os.Stat(filepath.Join(testOutDir, "hello", "main.go"))
+		require.NoError(t, err)
+	})
+
 }
@@ -0,0 +1,24 @@
+// A FunLess function in Go, built with TinyGo for WASI. It reads the arguments
+// of the invocation as JSON from stdin and writes its result as JSON to stdout.
+package main
+
+import (
+	"encoding/json"
+	"os"
+)
+
+type Input struct {
+	Name string `json:"name"`
+}
+
+type Output struct {
+	Payload string `json:"payload"`
+}
+
+func main() {
+	var input Input
+	if err := json.NewDecoder(os.Stdin).Decode(&input); err != nil || input.Name == "" {
+		input.Name = "World"
+	}
+	_ = json.NewEncoder(os.Stdout).Encode(Output{Payload: "Hello " + input.Name + "!"})
+}
@@ -0,0 +1,60 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package template
+
+import (
+	"embed"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// the templates shipped with the CLI, for the languages the template repository does not cover yet.
+// A go.mod is stored as go.mod.tmpl, as it would make its directory a module of its own, left out of the binary.
+//
+//go:embed _builtin
+var builtinTemplates embed.FS
+
+const (
+	builtinTemplatesDir = "_builtin"
+	builtinTemplateExt  = ".tmpl"
+)
+
+// HasBuiltinTemplate reports whether the CLI ships a template for the language
+func HasBuiltinTemplate(language string) bool {
+	info, err := fs.Stat(builtinTemplates, path.Join(builtinTemplatesDir, language))
+	return err == nil && info.IsDir()
+}
+
+// WriteBuiltinTemplate writes the template shipped with the CLI for the language in dest
+func WriteBuiltinTemplate(language string, dest string) error {
+	root := path.Join(builtinTemplatesDir, language)
+	return fs.WalkDir(builtinTemplates, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, filepath.FromSlash(strings.TrimPrefix(p, root)))
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		content, err := builtinTemplates.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(strings.TrimSuffix(target, builtinTemplateExt), content, 0644)
+	})
+}
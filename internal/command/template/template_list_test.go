@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"context"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -46,6 +47,20 @@ func TestListTemplates(t *testing.T) {
 		require.Equal(t, NoTemplates, out)
 	})
 
+	t.Run("prints the templates of a local template dir", func(t *testing.T) {
+		outbuf.Reset()
+
+		tmpDir := t.TempDir()
+		require.NoError(t, WriteBuiltinTemplate("go", filepath.Join(tmpDir, "template", "go")))
+		listCmd := List{
+			TemplateDir: tmpDir,
+		}
+
+		err := listCmd.Run(ctx, testLogger)
+		require.NoError(t, err)
+		require.Equal(t, FoundTemplates+"\n- go\n\n", outbuf.String())
+	})
+
 	t.Run("prints available templates", func(t *testing.T) {
 		outbuf.Reset()
 
@@ -0,0 +1,165 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+const (
+	// FormatVersion is bumped whenever the archive layout changes
+	FormatVersion = 1
+
+	metadataEntry = "metadata.json"
+	dumpEntry     = "database.dump"
+)
+
+// Metadata describes the deployment a backup archive was created from.
+type Metadata struct {
+	FormatVersion int       `json:"format_version"`
+	Backend       string    `json:"backend"`
+	Source        string    `json:"source"`
+	APIHost       string    `json:"api_host,omitempty"`
+	DumpFormat    string    `json:"dump_format"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Write creates a gzipped tar archive at path with the metadata and the database dump read from dumpFile.
+func Write(path string, meta Metadata, dumpFile *os.File) error {
+	info, err := dumpFile.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := dumpFile.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	meta.FormatVersion = FormatVersion
+	metaContent, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+
+	if err := writeEntry(tw, metadataEntry, int64(len(metaContent)), meta.CreatedAt, bytes.NewReader(metaContent)); err != nil {
+		return err
+	}
+	if err := writeEntry(tw, dumpEntry, info.Size(), meta.CreatedAt, dumpFile); err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// Reader reads a backup archive: the metadata is available right away, the dump is streamed from Dump.
+type Reader struct {
+	Metadata Metadata
+	Dump     io.Reader
+
+	file *os.File
+}
+
+// Open opens the archive at path, reading its metadata and positioning Dump at the start of the database dump.
+func Open(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := newReader(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("invalid backup archive %s: %w", path, err)
+	}
+	r.file = f
+	return r, nil
+}
+
+func (r *Reader) Close() error {
+	return r.file.Close()
+}
+
+func newReader(in io.Reader) (*Reader, error) {
+	gz, err := gzip.NewReader(in)
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(gz)
+
+	hdr, err := tr.Next()
+	if err != nil {
+		return nil, err
+	}
+	if hdr.Name != metadataEntry {
+		return nil, fmt.Errorf("expected %s as first entry, found %s", metadataEntry, hdr.Name)
+	}
+
+	var meta Metadata
+	if err := json.NewDecoder(tr).Decode(&meta); err != nil {
+		return nil, err
+	}
+	if meta.FormatVersion > FormatVersion {
+		return nil, fmt.Errorf("archive format version %d is not supported, upgrade fl", meta.FormatVersion)
+	}
+
+	hdr, err = tr.Next()
+	if err == io.EOF {
+		return nil, errors.New("missing database dump")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if hdr.Name != dumpEntry {
+		return nil, fmt.Errorf("expected %s entry, found %s", dumpEntry, hdr.Name)
+	}
+
+	return &Reader{Metadata: meta, Dump: tr}, nil
+}
+
+func writeEntry(tw *tar.Writer, name string, size int64, modTime time.Time, content io.Reader) error {
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    size,
+		ModTime: modTime,
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := io.Copy(tw, content)
+	return err
+}
@@ -0,0 +1,65 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+
+	dump, err := os.CreateTemp(dir, "dump-")
+	require.NoError(t, err)
+	defer dump.Close()
+	_, err = dump.WriteString("PGDMP test dump content")
+	require.NoError(t, err)
+
+	meta := Metadata{
+		Backend:    "docker",
+		Source:     "fl-postgres-1",
+		APIHost:    "http://localhost:4000",
+		DumpFormat: "pg_dump-custom",
+		CreatedAt:  time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC),
+	}
+
+	path := filepath.Join(dir, "backup.tar.gz")
+	require.NoError(t, Write(path, meta, dump))
+
+	r, err := Open(path)
+	require.NoError(t, err)
+	defer r.Close()
+
+	meta.FormatVersion = FormatVersion
+	require.Equal(t, meta, r.Metadata)
+
+	content, err := io.ReadAll(r.Dump)
+	require.NoError(t, err)
+	require.Equal(t, "PGDMP test dump content", string(content))
+}
+
+func TestOpenInvalidArchive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "not-a-backup")
+	require.NoError(t, os.WriteFile(path, []byte("plain text"), 0600))
+
+	_, err := Open(path)
+	require.ErrorContains(t, err, "invalid backup archive")
+}
@@ -0,0 +1,265 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/funlessdev/fl-cli/pkg"
+	"github.com/funlessdev/fl-cli/pkg/homedir"
+)
+
+const (
+	// CacheDir is the directory, in the config dir, holding the built wasm files
+	CacheDir = "cache"
+	// IgnoreFileName is the file, in the source directory, listing the paths that do not affect the build
+	IgnoreFileName = ".flignore"
+
+	// bumped whenever the inputs of the key change
+	cacheKeyVersion = "1"
+	cacheEntryExt   = ".wasm"
+)
+
+// paths left out of the build context and never hashed: version control, the build outputs of the
+// toolchains and editor files. The ignore file can re-include them with ! patterns.
+var defaultIgnores = []string{".git/", "target/", "node_modules/", ".DS_Store", ".idea/", ".vscode/", "*.swp", "*~"}
+
+// CacheEntry is a wasm file in the build cache
+type CacheEntry struct {
+	Key      string
+	Size     int64
+	LastUsed time.Time
+}
+
+// Cache stores built wasm files under the hash of everything the build depends on
+type Cache struct {
+	dir string
+}
+
+// OpenCache opens the build cache in the config dir, creating it if needed
+func OpenCache() (*Cache, error) {
+	dir, err := homedir.CreateDirInConfigDir(CacheDir)
+	if err != nil {
+		return nil, err
+	}
+	return &Cache{dir: dir}, nil
+}
+
+// BuildKey hashes the inputs of a build: the source tree (without the ignored paths), the language,
+// the builder image and the build options.
+func BuildKey(srcPath string, language string, builderImageID string, options ...string) (string, error) {
+	sourceHash, err := HashSource(srcPath)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "v%s\x00%s\x00%s\x00%s\x00%s", cacheKeyVersion, language, builderImageID, strings.Join(options, "\x00"), sourceHash)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// HashSource hashes the paths, modes and contents of the files in srcPath, following the symlinks and skipping
+// the ignored files
+func HashSource(srcPath string) (string, error) {
+	patterns, err := ignorePatterns(srcPath)
+	if err != nil {
+		return "", err
+	}
+	matcher := pkg.NewIgnoreMatcher(patterns)
+
+	h := sha256.New()
+	if err := hashPath(h, srcPath, "", matcher); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashPath hashes the file or directory p, at rel in the source. Symlinks are followed, as the copy
+// of the source given to the builder has the files they point to.
+func hashPath(h hash.Hash, p string, rel string, matcher *pkg.IgnoreMatcher) error {
+	info, err := os.Stat(p)
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		entries, err := os.ReadDir(p)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			entryRel := path.Join(rel, entry.Name())
+			if matcher.Ignored(entryRel, entry.IsDir()) {
+				continue
+			}
+			if err := hashPath(h, filepath.Join(p, entry.Name()), entryRel, matcher); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	fmt.Fprintf(h, "%s\x00%o\x00", rel, info.Mode().Perm()&0111)
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(h, f)
+	h.Write([]byte{0})
+	return err
+}
+
+// ignorePatterns returns the default patterns plus the lines of the ignore file of srcPath, if any,
+// in the .gitignore syntax understood by pkg.NewIgnoreMatcher
+func ignorePatterns(srcPath string) ([]string, error) {
+	patterns := append([]string{IgnoreFileName}, defaultIgnores...)
+
+	f, err := os.Open(filepath.Join(srcPath, IgnoreFileName))
+	if os.IsNotExist(err) {
+		return patterns, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		patterns = append(patterns, scanner.Text())
+	}
+	return patterns, scanner.Err()
+}
+
+// Get copies the wasm file stored under key to dest, reporting whether it was found
+func (c *Cache) Get(key string, dest string) (bool, error) {
+	entry := c.entryPath(key)
+	if _, err := os.Stat(entry); os.IsNotExist(err) {
+		return false, nil
+	}
+	if err := copyFile(entry, dest); err != nil {
+		return false, err
+	}
+
+	// the modification time tracks the last use, for prune
+	now := time.Now()
+	return true, os.Chtimes(entry, now, now)
+}
+
+// Put stores the wasm file src under key
+func (c *Cache) Put(key string, src string) error {
+	tmp, err := os.CreateTemp(c.dir, "tmp-*")
+	if err != nil {
+		return err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	if err := copyFile(src, tmp.Name()); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.entryPath(key))
+}
+
+// List returns the cached wasm files, the most recently used first
+func (c *Cache) List() ([]CacheEntry, error) {
+	files, err := os.ReadDir(c.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []CacheEntry{}
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != cacheEntryExt {
+			continue
+		}
+		info, err := f.Info()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, CacheEntry{
+			Key:      strings.TrimSuffix(f.Name(), cacheEntryExt),
+			Size:     info.Size(),
+			LastUsed: info.ModTime(),
+		})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].LastUsed.After(entries[j].LastUsed)
+	})
+	return entries, nil
+}
+
+// Prune removes the entries unused for longer than maxAge, then the least recently used ones until the
+// cache fits in maxSize. A zero maxAge or maxSize disables that limit. It returns the removed entries.
+func (c *Cache) Prune(maxSize int64, maxAge time.Duration) ([]CacheEntry, error) {
+	entries, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	for _, e := range entries {
+		total += e.Size
+	}
+
+	removed := []CacheEntry{}
+	now := time.Now()
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		expired := maxAge > 0 && now.Sub(e.LastUsed) > maxAge
+		oversized := maxSize > 0 && total > maxSize
+		if !expired && !oversized {
+			break
+		}
+		if err := os.Remove(c.entryPath(e.Key)); err != nil {
+			return removed, err
+		}
+		total -= e.Size
+		removed = append(removed, e)
+	}
+	return removed, nil
+}
+
+// Clear removes every entry, returning how many there were
+func (c *Cache) Clear() (int, error) {
+	entries, err := c.List()
+	if err != nil {
+		return 0, err
+	}
+	for i, e := range entries {
+		if err := os.Remove(c.entryPath(e.Key)); err != nil {
+			return i, err
+		}
+	}
+	return len(entries), nil
+}
+
+func (c *Cache) entryPath(key string) string {
+	return filepath.Join(c.dir, key+cacheEntryExt)
+}
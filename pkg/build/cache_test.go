@@ -0,0 +1,161 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/funlessdev/fl-cli/pkg/homedir"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0700))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0600))
+	}
+}
+
+func TestBuildKey(t *testing.T) {
+	src := t.TempDir()
+	writeFiles(t, src, map[string]string{"Cargo.toml": "[package]", "src/lib.rs": "fn main() {}"})
+
+	key, err := BuildKey(src, "rust", "sha256:builder")
+	require.NoError(t, err)
+
+	t.Run("should be stable", func(t *testing.T) {
+		again, err := BuildKey(src, "rust", "sha256:builder")
+		require.NoError(t, err)
+		require.Equal(t, key, again)
+	})
+
+	t.Run("should change with the language, the builder image and the options", func(t *testing.T) {
+		for _, args := range [][]string{{"js", "sha256:builder"}, {"rust", "sha256:other"}, {"rust", "sha256:builder", "--release"}} {
+			other, err := BuildKey(src, args[0], args[1], args[2:]...)
+			require.NoError(t, err)
+			require.NotEqual(t, key, other)
+		}
+	})
+
+	t.Run("should ignore the default and the .flignore paths", func(t *testing.T) {
+		writeFiles(t, src, map[string]string{
+			"target/wasm32-wasi/release/code.wasm": "wasm",
+			".git/HEAD":                            "ref",
+			"README.md":                            "docs",
+			"notes/todo.txt":                       "todo",
+			IgnoreFileName:                         "# docs\n*.md\nnotes/\n",
+		})
+		other, err := BuildKey(src, "rust", "sha256:builder")
+		require.NoError(t, err)
+		require.Equal(t, key, other)
+	})
+
+	t.Run("should change with the target of a symlink", func(t *testing.T) {
+		shared := t.TempDir()
+		writeFiles(t, shared, map[string]string{"util.rs": "pub fn util() {}"})
+		require.NoError(t, os.Symlink(filepath.Join(shared, "util.rs"), filepath.Join(src, "src", "util.rs")))
+		t.Cleanup(func() { os.Remove(filepath.Join(src, "src", "util.rs")) })
+
+		linked, err := BuildKey(src, "rust", "sha256:builder")
+		require.NoError(t, err)
+		require.NotEqual(t, key, linked)
+
+		writeFiles(t, shared, map[string]string{"util.rs": "pub fn util() { println!() }"})
+		other, err := BuildKey(src, "rust", "sha256:builder")
+		require.NoError(t, err)
+		require.NotEqual(t, linked, other)
+	})
+
+	t.Run("should change with the sources", func(t *testing.T) {
+		writeFiles(t, src, map[string]string{"src/lib.rs": "fn main() { println!() }"})
+		other, err := BuildKey(src, "rust", "sha256:builder")
+		require.NoError(t, err)
+		require.NotEqual(t, key, other)
+	})
+}
+
+func TestCache(t *testing.T) {
+	homedirPath := t.TempDir()
+	homedir.GetHomeDir = func() (string, error) {
+		return homedirPath, nil
+	}
+	t.Cleanup(func() {
+		homedir.GetHomeDir = os.UserHomeDir
+	})
+
+	cache, err := OpenCache()
+	require.NoError(t, err)
+
+	wasm := filepath.Join(t.TempDir(), "code.wasm")
+	dest := filepath.Join(t.TempDir(), "code.wasm")
+
+	t.Run("should miss unknown keys", func(t *testing.T) {
+		hit, err := cache.Get("missing", dest)
+		require.NoError(t, err)
+		require.False(t, hit)
+	})
+
+	t.Run("should return the stored files", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(wasm, []byte("wasm"), 0600))
+		require.NoError(t, cache.Put("key1", wasm))
+
+		hit, err := cache.Get("key1", dest)
+		require.NoError(t, err)
+		require.True(t, hit)
+		content, err := os.ReadFile(dest)
+		require.NoError(t, err)
+		require.Equal(t, "wasm", string(content))
+	})
+
+	t.Run("should list and prune the least recently used first", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(wasm, []byte("0123456789"), 0600))
+		require.NoError(t, cache.Put("key2", wasm))
+		require.NoError(t, cache.Put("key3", wasm))
+
+		old := time.Now().Add(-48 * time.Hour)
+		require.NoError(t, os.Chtimes(cache.entryPath("key2"), old, old))
+		older := time.Now().Add(-72 * time.Hour)
+		require.NoError(t, os.Chtimes(cache.entryPath("key1"), older, older))
+
+		entries, err := cache.List()
+		require.NoError(t, err)
+		require.Len(t, entries, 3)
+		require.Equal(t, []string{"key3", "key2", "key1"}, []string{entries[0].Key, entries[1].Key, entries[2].Key})
+
+		removed, err := cache.Prune(0, 60*time.Hour)
+		require.NoError(t, err)
+		require.Len(t, removed, 1)
+		require.Equal(t, "key1", removed[0].Key)
+
+		removed, err = cache.Prune(10, 0)
+		require.NoError(t, err)
+		require.Len(t, removed, 1)
+		require.Equal(t, "key2", removed[0].Key)
+	})
+
+	t.Run("should clear every entry", func(t *testing.T) {
+		count, err := cache.Clear()
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+
+		entries, err := cache.List()
+		require.NoError(t, err)
+		require.Empty(t, entries)
+	})
+}
@@ -0,0 +1,102 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"context"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/funlessdev/fl-cli/pkg"
+	"github.com/funlessdev/fl-cli/pkg/docker"
+)
+
+// DependencyCacheLabel marks the volumes holding the dependency caches of the builders, with the language as value
+const DependencyCacheLabel = "dev.funless.dependency-cache"
+
+// DependencyCache is a named volume keeping the downloads of a builder image across builds
+type DependencyCache struct {
+	Volume string
+	Target string
+}
+
+// DependencyCaches are the download caches of the builder image of each language
+var DependencyCaches = map[string][]DependencyCache{
+	"rust": {
+		{Volume: "fl-rust-cargo-registry", Target: "/usr/local/cargo/registry"},
+		{Volume: "fl-rust-cargo-git", Target: "/usr/local/cargo/git"},
+	},
+	"js": {
+		{Volume: "fl-js-npm-cache", Target: "/root/.npm"},
+	},
+	"go": {
+		{Volume: "fl-go-mod-cache", Target: "/go/pkg/mod"},
+	},
+}
+
+// WithoutDependencyCache makes the builds run with ctx start from empty dependency caches
+func WithoutDependencyCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, pkg.FLContextKey("no_dep_cache"), true)
+}
+
+func dependencyCacheDisabled(ctx context.Context) bool {
+	disabled, _ := ctx.Value(pkg.FLContextKey("no_dep_cache")).(bool)
+	return disabled
+}
+
+// ensureDependencyCaches creates the volumes of the dependency caches of the language, labelled for
+// PurgeDependencyCaches, and returns them
+func ensureDependencyCaches(ctx context.Context, flDocker docker.DockerClient, language string) ([]DependencyCache, error) {
+	caches := DependencyCaches[language]
+	for _, c := range caches {
+		if err := flDocker.CreateVolume(ctx, c.Volume, map[string]string{DependencyCacheLabel: language}); err != nil {
+			return nil, err
+		}
+	}
+	return caches, nil
+}
+
+// PurgeDependencyCaches removes the dependency cache volumes of the language, or of every language
+// when it is empty, returning their names
+func PurgeDependencyCaches(ctx context.Context, flDocker docker.DockerClient, language string) ([]string, error) {
+	label := DependencyCacheLabel
+	if language != "" {
+		label += "=" + language
+	}
+	volumes, err := flDocker.ListVolumes(ctx, label)
+	if err != nil {
+		return nil, err
+	}
+
+	removed := []string{}
+	for _, v := range volumes {
+		if err := flDocker.RemoveVolume(ctx, v); err != nil {
+			return removed, err
+		}
+		removed = append(removed, v)
+	}
+	return removed, nil
+}
+
+func dependencyCacheMounts(caches []DependencyCache) []mount.Mount {
+	mounts := make([]mount.Mount, 0, len(caches))
+	for _, c := range caches {
+		mounts = append(mounts, mount.Mount{
+			Source: c.Volume,
+			Target: c.Target,
+			Type:   mount.TypeVolume,
+		})
+	}
+	return mounts
+}
@@ -0,0 +1,46 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"context"
+	"testing"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/funlessdev/fl-cli/pkg"
+	"github.com/funlessdev/fl-cli/pkg/docker"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_builderHostConfig(t *testing.T) {
+	t.Run("should only mount the source and the output without caches", func(t *testing.T) {
+		hostConfig := builderHostConfig("/src", "/out", pkg.DefaultOutputPath, docker.Engine{}, nil)
+		require.Len(t, hostConfig.Mounts, 2)
+		require.True(t, hostConfig.Mounts[0].ReadOnly)
+		require.True(t, hostConfig.AutoRemove)
+	})
+
+	t.Run("should mount the dependency cache volumes", func(t *testing.T) {
+		hostConfig := builderHostConfig("/src", "/out", pkg.DefaultOutputPath, docker.Engine{}, DependencyCaches["rust"])
+		require.Len(t, hostConfig.Mounts, 4)
+		require.Equal(t, mount.Mount{Source: "fl-rust-cargo-registry", Target: "/usr/local/cargo/registry", Type: mount.TypeVolume}, hostConfig.Mounts[2])
+		require.Equal(t, mount.Mount{Source: "fl-rust-cargo-git", Target: "/usr/local/cargo/git", Type: mount.TypeVolume}, hostConfig.Mounts[3])
+	})
+}
+
+func TestWithoutDependencyCache(t *testing.T) {
+	require.False(t, dependencyCacheDisabled(context.TODO()))
+	require.True(t, dependencyCacheDisabled(WithoutDependencyCache(context.TODO())))
+}
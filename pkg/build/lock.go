@@ -0,0 +1,151 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/funlessdev/fl-cli/pkg"
+	"github.com/funlessdev/fl-cli/pkg/docker"
+	"gopkg.in/yaml.v2"
+)
+
+// LockFileName is the file, in the source directory, pinning the builder images to a digest
+const LockFileName = "fl.lock"
+
+// Pull policies of the builder images
+const (
+	PullAlways  = "always"
+	PullMissing = "missing"
+	PullNever   = "never"
+)
+
+// Lock pins the builder image of each language to a registry digest, so builds are the same on every machine
+type Lock struct {
+	Builders map[string]string `yaml:"builders"`
+}
+
+// ReadLock reads the lock file in dir, returning an empty lock when there is none
+func ReadLock(dir string) (*Lock, error) {
+	lock := &Lock{Builders: map[string]string{}}
+
+	data, err := os.ReadFile(filepath.Join(dir, LockFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return lock, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.UnmarshalStrict(data, lock); err != nil {
+		return nil, fmt.Errorf("%s: %w", LockFileName, err)
+	}
+	if lock.Builders == nil {
+		lock.Builders = map[string]string{}
+	}
+	for language, image := range lock.Builders {
+		if _, ok := pkg.SupportedLanguages[language]; !ok {
+			return nil, fmt.Errorf("%s: unsupported language %s", LockFileName, language)
+		}
+		if !strings.Contains(image, "@sha256:") {
+			return nil, fmt.Errorf("%s: the %s builder image %s is not pinned to a digest", LockFileName, language, image)
+		}
+	}
+	return lock, nil
+}
+
+// Write saves the lock file in dir
+func (l *Lock) Write(dir string) error {
+	data, err := yaml.Marshal(l)
+	if err != nil {
+		return err
+	}
+	header := "# Generated by fl build lock, update it with fl build lock --update\n"
+	return os.WriteFile(filepath.Join(dir, LockFileName), append([]byte(header), data...), 0644)
+}
+
+// Image returns the builder image of the language, pinned to its digest when the lock has one
+func (l *Lock) Image(language string) string {
+	if l != nil {
+		if image, ok := l.Builders[language]; ok {
+			return image
+		}
+	}
+	return pkg.SupportedLanguages[language].BuilderImage
+}
+
+// Update pins the builder images of the languages to the digest they have in the registry. Languages
+// already pinned are only resolved again with update. It returns the languages whose pin changed.
+func (l *Lock) Update(ctx context.Context, flDocker docker.DockerClient, languages []string, update bool) ([]string, error) {
+	sort.Strings(languages)
+
+	changed := []string{}
+	for _, language := range languages {
+		lang, ok := pkg.SupportedLanguages[language]
+		if !ok {
+			return changed, fmt.Errorf("unsupported language %s", language)
+		}
+		if lang.BuilderImage == "" {
+			return changed, fmt.Errorf("the %s language has no builder image to pin", language)
+		}
+		if _, pinned := l.Builders[language]; pinned && !update {
+			continue
+		}
+
+		pull := flDocker.Pull
+		if update {
+			pull = flDocker.ForcePull
+		}
+		if err := pull(ctx, lang.BuilderImage); err != nil {
+			return changed, err
+		}
+		digest, err := flDocker.ImageDigest(ctx, lang.BuilderImage)
+		if err != nil {
+			return changed, err
+		}
+		if l.Builders[language] != digest {
+			l.Builders[language] = digest
+			changed = append(changed, language)
+		}
+	}
+	return changed, nil
+}
+
+// WithPullPolicy sets when the builds run with ctx pull the builder image
+func WithPullPolicy(ctx context.Context, policy string) context.Context {
+	return context.WithValue(ctx, pkg.FLContextKey("pull_policy"), policy)
+}
+
+func pullPolicy(ctx context.Context) string {
+	if policy, ok := ctx.Value(pkg.FLContextKey("pull_policy")).(string); ok && policy != "" {
+		return policy
+	}
+	return PullMissing
+}
+
+// WithLock makes the builds run with ctx use the builder images pinned in the lock
+func WithLock(ctx context.Context, lock *Lock) context.Context {
+	return context.WithValue(ctx, pkg.FLContextKey("lock"), lock)
+}
+
+func lockFromContext(ctx context.Context) *Lock {
+	lock, _ := ctx.Value(pkg.FLContextKey("lock")).(*Lock)
+	return lock
+}
@@ -0,0 +1,91 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/funlessdev/fl-cli/pkg/docker"
+	"github.com/stretchr/testify/require"
+)
+
+const testDigest = "ghcr.io/funlessdev/fl-rust-builder@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func TestReadLock(t *testing.T) {
+	t.Run("should return an empty lock when there is no lock file", func(t *testing.T) {
+		lock, err := ReadLock(t.TempDir())
+		require.NoError(t, err)
+		require.Empty(t, lock.Builders)
+		require.Equal(t, "ghcr.io/funlessdev/fl-rust-builder:latest", lock.Image("rust"))
+	})
+
+	t.Run("should read back a written lock file", func(t *testing.T) {
+		dir := t.TempDir()
+		lock := &Lock{Builders: map[string]string{"rust": testDigest}}
+		require.NoError(t, lock.Write(dir))
+
+		read, err := ReadLock(dir)
+		require.NoError(t, err)
+		require.Equal(t, lock, read)
+		require.Equal(t, testDigest, read.Image("rust"))
+		require.Equal(t, "ghcr.io/funlessdev/fl-js-builder:latest", read.Image("js"))
+	})
+
+	t.Run("should reject images not pinned to a digest", func(t *testing.T) {
+		dir := t.TempDir()
+		content := "builders:\n  rust: ghcr.io/funlessdev/fl-rust-builder:latest\n"
+		require.NoError(t, os.WriteFile(filepath.Join(dir, LockFileName), []byte(content), 0644))
+
+		_, err := ReadLock(dir)
+		require.ErrorContains(t, err, "is not pinned to a digest")
+	})
+
+	t.Run("should reject unsupported languages and unknown fields", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, LockFileName), []byte("builders:\n  cobol: x@sha256:00\n"), 0644))
+		_, err := ReadLock(dir)
+		require.ErrorContains(t, err, "unsupported language cobol")
+
+		require.NoError(t, os.WriteFile(filepath.Join(dir, LockFileName), []byte("images: {}\n"), 0644))
+		_, err = ReadLock(dir)
+		require.Error(t, err)
+	})
+}
+
+func TestPullPolicy(t *testing.T) {
+	require.Equal(t, PullMissing, pullPolicy(context.TODO()))
+	require.Equal(t, PullMissing, pullPolicy(WithPullPolicy(context.TODO(), "")))
+	require.Equal(t, PullNever, pullPolicy(WithPullPolicy(context.TODO(), PullNever)))
+}
+
+func TestWasmBuilderImage(t *testing.T) {
+	b := &WasmBuilder{language: "rust", builderImg: "ghcr.io/funlessdev/fl-rust-builder:latest"}
+	require.Equal(t, b.builderImg, b.image(context.TODO()))
+
+	lock := &Lock{Builders: map[string]string{"rust": testDigest}}
+	require.Equal(t, testDigest, b.image(WithLock(context.TODO(), lock)))
+
+	b.language = "js"
+	require.Equal(t, b.builderImg, b.image(WithLock(context.TODO(), lock)))
+}
+
+func TestLockUpdateWithoutBuilderImage(t *testing.T) {
+	lock := &Lock{Builders: map[string]string{}}
+	_, err := lock.Update(context.TODO(), docker.DockerClient{}, []string{"go"}, false)
+	require.ErrorContains(t, err, "the go language has no builder image to pin")
+}
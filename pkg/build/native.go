@@ -0,0 +1,283 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/funlessdev/fl-cli/pkg"
+)
+
+// WasiTarget is the Rust target the functions are compiled to
+const WasiTarget = "wasm32-wasi"
+
+// TinyGoTarget is the TinyGo target the Go functions are compiled to
+const TinyGoTarget = "wasi"
+
+// Builder compiles the source of a function into code.wasm, then gives it the name of the function
+type Builder interface {
+	BuildSource(ctx context.Context, srcPath string) error
+	RenameCodeWasm(name string) error
+}
+
+// NativeBuilder builds functions with the toolchains installed on the host, without Docker
+type NativeBuilder interface {
+	Setup(language string, dest string) error
+	CheckToolchains(ctx context.Context) error
+	BuildSource(ctx context.Context, srcPath string) error
+	RenameCodeWasm(name string) error
+}
+
+// toolchain is a command the native build of a language needs, with a hint on how to install it
+type toolchain struct {
+	command string
+	hint    string
+}
+
+var nativeToolchains = map[string][]toolchain{
+	"rust": {
+		{command: "cargo", hint: "install Rust from https://rustup.rs"},
+	},
+	"js": {
+		{command: "node", hint: "install Node.js from https://nodejs.org"},
+		{command: "npx", hint: "install npm together with Node.js"},
+	},
+	"go": {
+		{command: "tinygo", hint: "install TinyGo from https://tinygo.org/getting-started/install"},
+	},
+}
+
+// swapped in tests
+var (
+	lookPath   = exec.LookPath
+	runCommand = func(ctx context.Context, dir string, stdout io.Writer, stderr io.Writer, name string, args ...string) error {
+		cmd := exec.CommandContext(ctx, name, args...)
+		cmd.Dir = dir
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+		return cmd.Run()
+	}
+)
+
+type ToolchainBuilder struct {
+	language string
+	outPath  string
+}
+
+func NewNativeBuilder() NativeBuilder {
+	return &ToolchainBuilder{}
+}
+
+func (b *ToolchainBuilder) Setup(language string, dest string) error {
+	dest, err := filepath.Abs(dest)
+	if err != nil {
+		return err
+	}
+	if _, exists := nativeToolchains[language]; !exists {
+		return fmt.Errorf("no native toolchain known for language %s, use the docker builder", language)
+	}
+	b.language = language
+	b.outPath = dest
+
+	return os.MkdirAll(dest, 0700)
+}
+
+// CheckToolchains checks that the commands and targets needed to build the language are installed
+func (b *ToolchainBuilder) CheckToolchains(ctx context.Context) error {
+	for _, t := range nativeToolchains[b.language] {
+		if _, err := lookPath(t.command); err != nil {
+			return fmt.Errorf("%s not found in PATH: %s", t.command, t.hint)
+		}
+	}
+
+	switch b.language {
+	case "rust":
+		// without rustup the target cannot be listed, cargo reports it if missing
+		if _, err := lookPath("rustup"); err != nil {
+			return nil
+		}
+		var out, stderr bytes.Buffer
+		if err := runCommand(ctx, "", &out, &stderr, "rustup", "target", "list", "--installed"); err != nil {
+			return commandError("rustup target list", err, &stderr)
+		}
+		for _, target := range strings.Fields(out.String()) {
+			if target == WasiTarget {
+				return nil
+			}
+		}
+		return fmt.Errorf("the %s target is not installed: run \"rustup target add %s\"", WasiTarget, WasiTarget)
+	case "js":
+		var stderr bytes.Buffer
+		if err := runCommand(ctx, "", io.Discard, &stderr, "npx", "--no-install", "jco", "--version"); err != nil {
+			return errors.New("jco not found: run \"npm install -g @bytecodealliance/jco @bytecodealliance/componentize-js\"")
+		}
+	}
+	return nil
+}
+
+func (b *ToolchainBuilder) BuildSource(ctx context.Context, srcPath string) error {
+	absPath, err := filepath.Abs(srcPath)
+	if err != nil {
+		return err
+	}
+
+	switch b.language {
+	case "rust":
+		return b.buildRust(ctx, absPath)
+	case "js":
+		return b.buildJS(ctx, absPath)
+	case "go":
+		return b.buildGo(ctx, absPath)
+	}
+	return fmt.Errorf("no native toolchain known for language %s", b.language)
+}
+
+func (b *ToolchainBuilder) RenameCodeWasm(name string) error {
+	return renameCodeWasm(b.outPath, name)
+}
+
+// buildRust compiles the crate for WasiTarget and copies the wasm artifact cargo reports to code.wasm
+func (b *ToolchainBuilder) buildRust(ctx context.Context, srcPath string) error {
+	var out, stderr bytes.Buffer
+	args := []string{"build", "--release", "--target", WasiTarget, "--message-format", "json-render-diagnostics"}
+	if err := runCommand(ctx, srcPath, &out, &stderr, "cargo", args...); err != nil {
+		return commandError("cargo build", err, &stderr)
+	}
+
+	artifact, err := wasmArtifact(&out)
+	if err != nil {
+		return err
+	}
+	return copyFile(artifact, filepath.Join(b.outPath, "code.wasm"))
+}
+
+// buildGo compiles the main package of the module with TinyGo, for TinyGoTarget
+func (b *ToolchainBuilder) buildGo(ctx context.Context, srcPath string) error {
+	var stderr bytes.Buffer
+	args := []string{"build", "-target", TinyGoTarget, "-o", filepath.Join(b.outPath, "code.wasm"), "."}
+	if err := runCommand(ctx, srcPath, io.Discard, &stderr, "tinygo", args...); err != nil {
+		return commandError("tinygo build", err, &stderr)
+	}
+	return nil
+}
+
+// buildJS turns the main module of package.json into a component, with the WIT world in the wit directory
+func (b *ToolchainBuilder) buildJS(ctx context.Context, srcPath string) error {
+	main, err := packageMain(srcPath)
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(filepath.Join(srcPath, "wit")); err != nil || !info.IsDir() {
+		return fmt.Errorf("wit directory not found in path %s", srcPath)
+	}
+
+	var stderr bytes.Buffer
+	if _, err := os.Stat(filepath.Join(srcPath, "node_modules")); os.IsNotExist(err) {
+		if err := runCommand(ctx, srcPath, io.Discard, &stderr, "npm", "install"); err != nil {
+			return commandError("npm install", err, &stderr)
+		}
+	}
+
+	args := []string{"--no-install", "jco", "componentize", main, "--wit", "wit", "--out", filepath.Join(b.outPath, "code.wasm")}
+	if err := runCommand(ctx, srcPath, io.Discard, &stderr, "npx", args...); err != nil {
+		return commandError("jco componentize", err, &stderr)
+	}
+	return nil
+}
+
+// wasmArtifact returns the last wasm file among the artifacts of the json messages of cargo
+func wasmArtifact(messages io.Reader) (string, error) {
+	artifact := ""
+	scanner := bufio.NewScanner(messages)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var msg struct {
+			Reason    string   `json:"reason"`
+			Filenames []string `json:"filenames"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil || msg.Reason != "compiler-artifact" {
+			continue
+		}
+		for _, f := range msg.Filenames {
+			if filepath.Ext(f) == ".wasm" {
+				artifact = f
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	if artifact == "" {
+		return "", errors.New("cargo built no wasm file: is the crate a cdylib or a binary?")
+	}
+	return artifact, nil
+}
+
+// packageMain returns the main module of the package.json in srcPath, index.js by default
+func packageMain(srcPath string) (string, error) {
+	content, err := os.ReadFile(filepath.Join(srcPath, pkg.SupportedLanguages["js"].MustContainFiles[0]))
+	if err != nil {
+		return "", err
+	}
+	var manifest struct {
+		Main string `json:"main"`
+	}
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return "", fmt.Errorf("invalid package.json: %w", err)
+	}
+	if manifest.Main == "" {
+		return "index.js", nil
+	}
+	return manifest.Main, nil
+}
+
+func commandError(name string, err error, stderr *bytes.Buffer) error {
+	if output := strings.TrimSpace(stderr.String()); output != "" {
+		return fmt.Errorf("%s failed: %w\n%s", name, err, output)
+	}
+	return fmt.Errorf("%s failed: %w", name, err)
+}
+
+func copyFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func renameCodeWasm(outPath string, name string) error {
+	return os.Rename(filepath.Join(outPath, "code.wasm"), filepath.Join(outPath, name+".wasm"))
+}
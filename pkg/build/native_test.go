@@ -0,0 +1,158 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeToolchains makes the commands in installed available, running them through run
+func fakeToolchains(t *testing.T, installed []string, run func(dir string, stdout io.Writer, name string, args ...string) error) {
+	defaultLookPath, defaultRunCommand := lookPath, runCommand
+	lookPath = func(file string) (string, error) {
+		for _, c := range installed {
+			if c == file {
+				return "/usr/bin/" + file, nil
+			}
+		}
+		return "", exec.ErrNotFound
+	}
+	runCommand = func(ctx context.Context, dir string, stdout io.Writer, stderr io.Writer, name string, args ...string) error {
+		return run(dir, stdout, name, args...)
+	}
+	t.Cleanup(func() {
+		lookPath, runCommand = defaultLookPath, defaultRunCommand
+	})
+}
+
+func TestNativeBuilderCheckToolchains(t *testing.T) {
+	ctx := context.TODO()
+	b := &ToolchainBuilder{language: "rust"}
+
+	t.Run("should fail when cargo is missing", func(t *testing.T) {
+		fakeToolchains(t, nil, nil)
+		require.ErrorContains(t, b.CheckToolchains(ctx), "cargo not found in PATH")
+	})
+
+	t.Run("should fail when the wasi target is not installed", func(t *testing.T) {
+		fakeToolchains(t, []string{"cargo", "rustup"}, func(dir string, stdout io.Writer, name string, args ...string) error {
+			_, err := fmt.Fprintln(stdout, "x86_64-unknown-linux-gnu")
+			return err
+		})
+		require.ErrorContains(t, b.CheckToolchains(ctx), "rustup target add wasm32-wasi")
+	})
+
+	t.Run("should pass when the wasi target is installed", func(t *testing.T) {
+		fakeToolchains(t, []string{"cargo", "rustup"}, func(dir string, stdout io.Writer, name string, args ...string) error {
+			_, err := fmt.Fprintln(stdout, "wasm32-wasi\nx86_64-unknown-linux-gnu")
+			return err
+		})
+		require.NoError(t, b.CheckToolchains(ctx))
+	})
+
+	t.Run("should fail when jco is missing for js", func(t *testing.T) {
+		fakeToolchains(t, []string{"node", "npx"}, func(dir string, stdout io.Writer, name string, args ...string) error {
+			return errors.New("exit status 1")
+		})
+		require.ErrorContains(t, (&ToolchainBuilder{language: "js"}).CheckToolchains(ctx), "jco not found")
+	})
+}
+
+func TestNativeBuilderSetup(t *testing.T) {
+	require.ErrorContains(t, NewNativeBuilder().Setup("cobol", t.TempDir()), "no native toolchain known")
+}
+
+func TestNativeBuilderBuildRust(t *testing.T) {
+	src := t.TempDir()
+	out := filepath.Join(t.TempDir(), "out")
+	artifact := filepath.Join(src, "target", "wasm32-wasi", "release", "hello.wasm")
+
+	fakeToolchains(t, []string{"cargo"}, func(dir string, stdout io.Writer, name string, args ...string) error {
+		require.Equal(t, src, dir)
+		require.Equal(t, "cargo build --release --target wasm32-wasi --message-format json-render-diagnostics", name+" "+strings.Join(args, " "))
+		require.NoError(t, os.MkdirAll(filepath.Dir(artifact), 0700))
+		require.NoError(t, os.WriteFile(artifact, []byte("wasm"), 0600))
+		_, err := fmt.Fprintf(stdout, "{\"reason\":\"compiler-artifact\",\"filenames\":[\"%s/libdep.rlib\"]}\n"+
+			"{\"reason\":\"compiler-artifact\",\"filenames\":[\"%s\"]}\n{\"reason\":\"build-finished\",\"success\":true}\n", src, artifact)
+		return err
+	})
+
+	b := NewNativeBuilder()
+	require.NoError(t, b.Setup("rust", out))
+	require.NoError(t, b.BuildSource(context.TODO(), src))
+	require.NoError(t, b.RenameCodeWasm("hello"))
+
+	content, err := os.ReadFile(filepath.Join(out, "hello.wasm"))
+	require.NoError(t, err)
+	require.Equal(t, "wasm", string(content))
+}
+
+func TestNativeBuilderBuildJS(t *testing.T) {
+	src := t.TempDir()
+	out := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(src, "package.json"), []byte(`{"main": "src/main.js"}`), 0600))
+
+	b := &ToolchainBuilder{language: "js", outPath: out}
+	fakeToolchains(t, nil, nil)
+	require.ErrorContains(t, b.BuildSource(context.TODO(), src), "wit directory not found")
+
+	require.NoError(t, os.Mkdir(filepath.Join(src, "wit"), 0700))
+	commands := []string{}
+	fakeToolchains(t, nil, func(dir string, stdout io.Writer, name string, args ...string) error {
+		commands = append(commands, name+" "+strings.Join(args, " "))
+		return nil
+	})
+	require.NoError(t, b.BuildSource(context.TODO(), src))
+	require.Equal(t, []string{
+		"npm install",
+		"npx --no-install jco componentize src/main.js --wit wit --out " + filepath.Join(out, "code.wasm"),
+	}, commands)
+}
+
+func TestNativeBuilderBuildGo(t *testing.T) {
+	src := t.TempDir()
+	out := t.TempDir()
+
+	b := NewNativeBuilder()
+	require.NoError(t, b.Setup("go", out))
+
+	fakeToolchains(t, nil, nil)
+	require.ErrorContains(t, b.CheckToolchains(context.TODO()), "tinygo not found in PATH")
+
+	commands := []string{}
+	fakeToolchains(t, []string{"tinygo"}, func(dir string, stdout io.Writer, name string, args ...string) error {
+		require.Equal(t, src, dir)
+		commands = append(commands, name+" "+strings.Join(args, " "))
+		return nil
+	})
+	require.NoError(t, b.CheckToolchains(context.TODO()))
+	require.NoError(t, b.BuildSource(context.TODO(), src))
+	require.Equal(t, []string{"tinygo build -target wasi -o " + filepath.Join(out, "code.wasm") + " ."}, commands)
+}
+
+func Test_wasmArtifact(t *testing.T) {
+	_, err := wasmArtifact(strings.NewReader(`{"reason":"compiler-artifact","filenames":["/t/liba.rlib"]}`))
+	require.ErrorContains(t, err, "cargo built no wasm file")
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/docker/docker/api/types/container"
@@ -28,11 +29,6 @@ import (
 	"github.com/funlessdev/fl-cli/pkg/docker"
 )
 
-var builderNames = map[string]string{
-	"js":   "fl-js-builder",
-	"rust": "fl-rust-builder",
-}
-
 type DockerBuilder interface {
 	Setup(client docker.DockerClient, language string, dest string) error
 	PullBuilderImage(ctx context.Context) error
@@ -42,9 +38,12 @@ type DockerBuilder interface {
 
 type WasmBuilder struct {
 	flDocker             docker.DockerClient
+	language             string
 	builderImg           string
 	builderContainerName string
+	builderOutPath       string
 	outPath              string
+	cache                *Cache
 }
 
 func NewWasmBuilder() DockerBuilder {
@@ -61,16 +60,21 @@ func (b *WasmBuilder) Setup(flDocker docker.DockerClient, language string, dest
 	if !exists {
 		return errors.New("no corresponding builder image found for the given language")
 	}
-	b.builderImg = lang.BuilderImage
-
-	containerBaseName, exists := builderNames[language]
-	if !exists {
-		return errors.New("no corresponding builder name found for the given language")
+	if lang.BuilderImage == "" {
+		return fmt.Errorf("no builder image for %s yet: build it with --builder=native, or set its builder_image in languages.yaml", lang.Name)
 	}
-	b.builderContainerName = containerBaseName + fmt.Sprintf("%d", time.Now().UnixMilli())
+	b.builderImg = lang.BuilderImage
+	b.builderOutPath = lang.WasmOutputPath()
+	b.builderContainerName = builderContainerName(lang.BuilderImage) + fmt.Sprintf("%d", time.Now().UnixMilli())
 	b.flDocker = flDocker
+	b.language = language
 	b.outPath = dest
 
+	// builds work without the cache, they are just never skipped
+	if cache, err := OpenCache(); err == nil {
+		b.cache = cache
+	}
+
 	err = os.MkdirAll(dest, 0700)
 	if err != nil {
 		return err
@@ -85,8 +89,34 @@ func (b *WasmBuilder) BuildSource(ctx context.Context, srcPath string) error {
 		return err
 	}
 
-	containerConfig := builderContainerConfig(b.builderImg)
-	hostConfig := builderHostConfig(absPath, b.outPath)
+	codeWasm := filepath.Join(b.outPath, "code.wasm")
+	key := b.cacheKey(ctx, absPath)
+	if key != "" {
+		if hit, err := b.cache.Get(key, codeWasm); err == nil && hit {
+			return nil
+		}
+	}
+
+	engine, err := b.flDocker.Engine(ctx)
+	if err != nil {
+		return err
+	}
+
+	var caches []DependencyCache
+	if !dependencyCacheDisabled(ctx) {
+		if caches, err = ensureDependencyCaches(ctx, b.flDocker, b.language); err != nil {
+			return err
+		}
+	}
+
+	staged, cleanup, err := stageSource(absPath)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	containerConfig := builderContainerConfig(b.image(ctx))
+	hostConfig := builderHostConfig(staged, b.outPath, b.builderOutPath, engine, caches)
 
 	configs := docker.ContainerConfigs{
 		ContName:   b.builderContainerName,
@@ -95,22 +125,99 @@ func (b *WasmBuilder) BuildSource(ctx context.Context, srcPath string) error {
 		Networking: nil,
 	}
 
-	return b.flDocker.RunAndWait(ctx, configs)
+	if err := b.flDocker.RunAndWait(ctx, configs); err != nil {
+		return err
+	}
+
+	if key != "" {
+		// a failure to store only costs a rebuild next time
+		_ = b.cache.Put(key, codeWasm)
+	}
+	return nil
+}
+
+// stageSource copies the source, without the ignored paths, to a temporary directory mounted as the build
+// context in place of the source itself, returning its path and a function removing it.
+func stageSource(srcPath string) (string, func(), error) {
+	patterns, err := ignorePatterns(srcPath)
+	if err != nil {
+		return "", nil, err
+	}
+	tmp, err := os.MkdirTemp("", "fl-build-")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { os.RemoveAll(tmp) }
+
+	// a subdirectory keeps the mode of the source, the temporary directory is private
+	staged := filepath.Join(tmp, "src")
+	if err := pkg.Copy(srcPath, staged, patterns...); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return staged, cleanup, nil
+}
+
+// cacheKey returns the build cache key of the source, or "" when the build cannot be cached
+func (b *WasmBuilder) cacheKey(ctx context.Context, srcPath string) string {
+	if b.cache == nil {
+		return ""
+	}
+	imageID, err := b.flDocker.ImageID(ctx, b.image(ctx))
+	if err != nil {
+		return ""
+	}
+	key, err := BuildKey(srcPath, b.language, imageID)
+	if err != nil {
+		return ""
+	}
+	return key
+}
+
+// image returns the builder image, pinned to its digest when the lock of ctx has one
+func (b *WasmBuilder) image(ctx context.Context) string {
+	if lock := lockFromContext(ctx); lock != nil {
+		if image, ok := lock.Builders[b.language]; ok {
+			return image
+		}
+	}
+	return b.builderImg
 }
 
 func (b *WasmBuilder) PullBuilderImage(ctx context.Context) error {
-	exists, err := b.flDocker.ImageExists(ctx, b.builderImg)
+	image := b.image(ctx)
+	policy := pullPolicy(ctx)
+	if policy == PullAlways {
+		return b.flDocker.ForcePull(ctx, image)
+	}
+
+	exists, err := b.flDocker.ImageExists(ctx, image)
 	if err != nil {
 		return err
 	}
 	if exists {
 		return nil
 	}
-	return b.flDocker.Pull(ctx, b.builderImg)
+	if policy == PullNever {
+		return fmt.Errorf("builder image %s not found locally and pulling is disabled (--pull=never)", image)
+	}
+	return b.flDocker.Pull(ctx, image)
 }
 
 func (b *WasmBuilder) RenameCodeWasm(name string) error {
-	return os.Rename(filepath.Join(b.outPath, "code.wasm"), filepath.Join(b.outPath, name+".wasm"))
+	return renameCodeWasm(b.outPath, name)
+}
+
+// builderContainerName names the builder containers after the image, e.g. fl-rust-builder
+func builderContainerName(image string) string {
+	name := image
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	if i := strings.IndexAny(name, ":@"); i >= 0 {
+		name = name[:i]
+	}
+	return name
 }
 
 func builderContainerConfig(builderImg string) *container.Config {
@@ -120,8 +227,8 @@ func builderContainerConfig(builderImg string) *container.Config {
 	}
 }
 
-func builderHostConfig(absPath, outPath string) *container.HostConfig {
-	return &container.HostConfig{
+func builderHostConfig(absPath, outPath, builderOutPath string, engine docker.Engine, caches []DependencyCache) *container.HostConfig {
+	hostConfig := &container.HostConfig{
 		Mounts: []mount.Mount{
 			{
 				Source:   absPath,
@@ -131,10 +238,23 @@ func builderHostConfig(absPath, outPath string) *container.HostConfig {
 			},
 			{
 				Source: outPath,
-				Target: "/out_wasm",
+				Target: builderOutPath,
 				Type:   mount.TypeBind,
 			},
 		},
 		AutoRemove: true,
 	}
+	// the downloads of the builder outlive the container
+	hostConfig.Mounts = append(hostConfig.Mounts, dependencyCacheMounts(caches)...)
+
+	if engine.Rootless {
+		// the mounts keep the SELinux label of the user files, the builder would be denied access to them
+		hostConfig.SecurityOpt = []string{"label=disable"}
+	}
+	if engine.Podman && engine.Rootless {
+		// map the user to the same uid in the container, so the builder can write the wasm file to the
+		// output directory and the user owns it afterwards
+		hostConfig.UsernsMode = "keep-id"
+	}
+	return hostConfig
 }
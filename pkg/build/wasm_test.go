@@ -0,0 +1,66 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/funlessdev/fl-cli/pkg/docker"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_builderContainerName(t *testing.T) {
+	require.Equal(t, "fl-rust-builder", builderContainerName("ghcr.io/funlessdev/fl-rust-builder:latest"))
+	require.Equal(t, "fl-js-builder", builderContainerName("ghcr.io/funlessdev/fl-js-builder@sha256:0123"))
+	require.Equal(t, "mysdk-builder", builderContainerName("localhost:5000/mysdk-builder"))
+	require.Equal(t, "zig-builder", builderContainerName("zig-builder"))
+}
+
+func Test_builderHostConfigOutputPath(t *testing.T) {
+	hostConfig := builderHostConfig("/src", "/out", "/sdk/out", docker.Engine{}, nil)
+	require.Equal(t, "/out", hostConfig.Mounts[1].Source)
+	require.Equal(t, "/sdk/out", hostConfig.Mounts[1].Target)
+}
+
+func Test_stageSource(t *testing.T) {
+	src := t.TempDir()
+	writeFiles(t, src, map[string]string{
+		"src/lib.rs":            "fn main() {}",
+		"target/debug/lib.wasm": "wasm",
+		".git/HEAD":             "ref",
+		"notes.md":              "docs",
+		IgnoreFileName:          "*.md\n",
+	})
+
+	staged, cleanup, err := stageSource(src)
+	require.NoError(t, err)
+
+	require.FileExists(t, filepath.Join(staged, "src/lib.rs"))
+	for _, p := range []string{"target", ".git", "notes.md", IgnoreFileName} {
+		_, err := os.Stat(filepath.Join(staged, p))
+		require.True(t, os.IsNotExist(err), p)
+	}
+
+	cleanup()
+	_, err = os.Stat(filepath.Dir(staged))
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestWasmBuilderSetupWithoutBuilderImage(t *testing.T) {
+	err := NewWasmBuilder().Setup(docker.DockerClient{}, "go", t.TempDir())
+	require.ErrorContains(t, err, "no builder image for Go yet")
+}
@@ -17,13 +17,18 @@ package client
 import (
 	"bufio"
 	"bytes"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"os"
+	"path"
+	"regexp"
 	"strings"
 
+	"github.com/funlessdev/fl-cli/pkg"
 	"github.com/funlessdev/fl-cli/pkg/homedir"
 	openapi "github.com/funlessdev/fl-client-sdk-go"
 )
@@ -43,8 +48,10 @@ type Config struct {
 	Host          string
 	BaseURL       *url.URL
 	SecretKeyBase string // used when deploying the platform, unused when using API
+	DatabaseURL   string // external database used when deploying the platform, unused when using API
 	AdminToken    string
 	APIToken      string
+	CACert        string // PEM file of the certificates trusted for api_host, besides the system ones
 }
 
 // NewConfig creates a new funless config, reading the information from the given configPath
@@ -71,7 +78,7 @@ func NewConfig(configPath string) (Config, error) {
 	configMap := make(map[string]string)
 	for configScanner.Scan() {
 		line := configScanner.Text()
-		lineParts := strings.Split(line, "=")
+		lineParts := strings.SplitN(line, "=", 2)
 		if len(lineParts) == 2 {
 			key, value := strings.TrimSpace(lineParts[0]), strings.TrimSpace(lineParts[1])
 			configMap[key] = value
@@ -90,16 +97,107 @@ func NewConfig(configPath string) (Config, error) {
 	if secretKeyBase, v := configMap["secret_key_base"]; v {
 		outConfig.SecretKeyBase = secretKeyBase
 	}
+	if databaseURL, v := configMap["database_url"]; v {
+		outConfig.DatabaseURL = databaseURL
+	}
 	if adminToken, v := configMap["admin_token"]; v {
 		outConfig.AdminToken = adminToken
 	}
 	if apiToken, v := configMap["api_token"]; v {
 		outConfig.APIToken = apiToken
 	}
+	if caCert, v := configMap["ca_cert"]; v {
+		outConfig.CACert = caCert
+	}
 
 	return outConfig, nil
 }
 
+// SetConfigValue writes key=value in the config file backing the given config,
+// replacing the previous value if the key is already present.
+// It returns the path of the written config file.
+func SetConfigValue(config Config, key string, value string) (string, error) {
+	configBasePath := configFileName(config)
+	configText, _, err := homedir.ReadFromConfigDir(configBasePath)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+
+	configString := string(configText[:])
+	r := configKeyRegexp(key)
+
+	var outConfig string
+
+	if r.MatchString(configString) {
+		outConfig = r.ReplaceAllLiteralString(configString, fmt.Sprintf("%s=%s", key, value))
+	} else {
+		outConfig = fmt.Sprintf("%s\n%s=%s\n", strings.Trim(configString, "\n"), key, value)
+	}
+
+	return homedir.WriteToConfigDir(configBasePath, []byte(outConfig), true)
+}
+
+// HasConfigValue reports whether the key is set in the config file backing the given config,
+// as opposed to having its default value.
+func HasConfigValue(config Config, key string) (bool, error) {
+	configText, _, err := homedir.ReadFromConfigDir(configFileName(config))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return configKeyRegexp(key).Match(configText), nil
+}
+
+// UnsetConfigValue removes the key from the config file backing the given config, so it goes back to its
+// default value. It returns the path of the written config file.
+func UnsetConfigValue(config Config, key string) (string, error) {
+	configBasePath := configFileName(config)
+	configText, _, err := homedir.ReadFromConfigDir(configBasePath)
+	if err != nil {
+		return "", err
+	}
+
+	r := regexp.MustCompile(fmt.Sprintf("(?m)^%s=.*(\n|$)", regexp.QuoteMeta(key)))
+	return homedir.WriteToConfigDir(configBasePath, r.ReplaceAll(configText, nil), true)
+}
+
+func configFileName(config Config) string {
+	if config.Path == "" {
+		return pkg.ConfigFileName
+	}
+	return path.Base(config.Path)
+}
+
+func configKeyRegexp(key string) *regexp.Regexp {
+	return regexp.MustCompile(fmt.Sprintf("(?m)^%s=(.*)$", regexp.QuoteMeta(key)))
+}
+
+// NewHTTPClient creates the http client used to reach api_host, trusting the certificates in the
+// ca_cert file of the config along with the system ones
+func NewHTTPClient(config Config) (*http.Client, error) {
+	if config.CACert == "" {
+		return &http.Client{}, nil
+	}
+
+	certs, err := os.ReadFile(config.CACert)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the ca_cert file: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(certs) {
+		return nil, fmt.Errorf("no PEM certificates found in the ca_cert file %s", config.CACert)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	return &http.Client{Transport: transport}, nil
+}
+
 // NewClient creates a new funless client with the provided http client and configuration.
 func NewClient(httpClient *http.Client, config Config) (*Client, error) {
 	if len(config.Host) == 0 { // is host missing?
@@ -116,6 +214,7 @@ func NewClient(httpClient *http.Client, config Config) (*Client, error) {
 
 	apiConfig := openapi.NewConfiguration()
 	apiConfig.Servers[0].URL = config.Host
+	apiConfig.HTTPClient = httpClient
 	apiClient := openapi.NewAPIClient(apiConfig)
 
 	return &Client{client: httpClient, Config: config, ApiClient: apiClient}, nil
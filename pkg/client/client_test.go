@@ -15,10 +15,15 @@
 package client
 
 import (
+	"encoding/pem"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/funlessdev/fl-cli/pkg/homedir"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -68,3 +73,64 @@ func TestNewClient(t *testing.T) {
 		require.Equal(t, baseUrl, client.Config.BaseURL)
 	})
 }
+
+func TestNewHTTPClient(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	t.Run("should reject an untrusted certificate without ca_cert", func(t *testing.T) {
+		httpClient, err := NewHTTPClient(Config{})
+		require.NoError(t, err)
+		_, err = httpClient.Get(server.URL)
+		require.Error(t, err)
+	})
+
+	t.Run("should trust the certificates of ca_cert", func(t *testing.T) {
+		caCert := filepath.Join(t.TempDir(), "ca.pem")
+		certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+		require.NoError(t, os.WriteFile(caCert, certPEM, 0600))
+
+		httpClient, err := NewHTTPClient(Config{CACert: caCert})
+		require.NoError(t, err)
+		resp, err := httpClient.Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+	})
+
+	t.Run("should fail when ca_cert has no certificates", func(t *testing.T) {
+		caCert := filepath.Join(t.TempDir(), "ca.pem")
+		require.NoError(t, os.WriteFile(caCert, []byte("not a cert"), 0600))
+
+		_, err := NewHTTPClient(Config{CACert: caCert})
+		require.ErrorContains(t, err, "no PEM certificates found")
+	})
+}
+
+func TestUnsetConfigValue(t *testing.T) {
+	homedirPath := t.TempDir()
+	homedir.GetHomeDir = func() (string, error) {
+		return homedirPath, nil
+	}
+	defer func() {
+		homedir.GetHomeDir = os.UserHomeDir
+	}()
+
+	_, err := homedir.WriteToConfigDir("config", []byte("api_host=http://host:4000\napi_token=token\n"), true)
+	require.NoError(t, err)
+	config, err := NewConfig("config")
+	require.NoError(t, err)
+
+	found, err := HasConfigValue(config, "api_host")
+	require.NoError(t, err)
+	require.True(t, found)
+
+	_, err = UnsetConfigValue(config, "api_host")
+	require.NoError(t, err)
+	content, _, err := homedir.ReadFromConfigDir("config")
+	require.NoError(t, err)
+	require.Equal(t, "api_token=token\n", string(content))
+
+	found, err = HasConfigValue(config, "api_host")
+	require.NoError(t, err)
+	require.False(t, found)
+}
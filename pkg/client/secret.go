@@ -0,0 +1,49 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+)
+
+// number of random bytes of a generated secret_key_base, 64 characters once encoded
+const secretKeyBaseBytes = 48
+
+// GenerateSecretKeyBase returns a cryptographically random key suitable as the core secret_key_base.
+func GenerateSecretKeyBase() (string, error) {
+	b := make([]byte, secretKeyBaseBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// EnsureSecretKeyBase returns the secret_key_base of the config, generating a new one and saving it
+// in the config file when none is set. The boolean reports whether a new key was generated.
+func EnsureSecretKeyBase(config Config) (string, bool, error) {
+	if config.SecretKeyBase != "" {
+		return config.SecretKeyBase, false, nil
+	}
+
+	key, err := GenerateSecretKeyBase()
+	if err != nil {
+		return "", false, err
+	}
+	if _, err := SetConfigValue(config, "secret_key_base", key); err != nil {
+		return "", false, err
+	}
+	return key, true, nil
+}
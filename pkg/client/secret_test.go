@@ -0,0 +1,72 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"os"
+	"testing"
+
+	"github.com/funlessdev/fl-cli/pkg/homedir"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateSecretKeyBase(t *testing.T) {
+	a, err := GenerateSecretKeyBase()
+	require.NoError(t, err)
+	b, err := GenerateSecretKeyBase()
+	require.NoError(t, err)
+
+	require.Len(t, a, 64)
+	require.NotEqual(t, a, b)
+	require.NotContains(t, a, "=")
+}
+
+func TestEnsureSecretKeyBase(t *testing.T) {
+	homedirPath, err := os.MkdirTemp("", "funless-test-homedir-")
+	require.NoError(t, err)
+
+	homedir.GetHomeDir = func() (string, error) {
+		return homedirPath, nil
+	}
+	defer func() {
+		homedir.GetHomeDir = os.UserHomeDir
+		os.RemoveAll(homedirPath)
+	}()
+
+	t.Run("should keep the configured key", func(t *testing.T) {
+		key, generated, err := EnsureSecretKeyBase(Config{SecretKeyBase: "configured"})
+		require.NoError(t, err)
+		require.False(t, generated)
+		require.Equal(t, "configured", key)
+	})
+
+	t.Run("should generate and save a key when missing", func(t *testing.T) {
+		_, err := homedir.WriteToConfigDir("config", []byte("api_host=http://localhost:4000\n"), true)
+		require.NoError(t, err)
+
+		config, err := NewConfig("config")
+		require.NoError(t, err)
+
+		key, generated, err := EnsureSecretKeyBase(config)
+		require.NoError(t, err)
+		require.True(t, generated)
+		require.NotEmpty(t, key)
+
+		config, err = NewConfig("config")
+		require.NoError(t, err)
+		require.Equal(t, key, config.SecretKeyBase)
+		require.Equal(t, "http://localhost:4000", config.Host)
+	})
+}
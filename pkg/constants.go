@@ -15,13 +15,23 @@
 package pkg
 
 type Language struct {
-	Name             string
-	BuilderImage     string
-	Extensions       []string
-	MustContainFiles []string
+	Name             string   `yaml:"name"`
+	BuilderImage     string   `yaml:"builder_image"`
+	Extensions       []string `yaml:"extensions"`
+	MustContainFiles []string `yaml:"required_files"`
+	// OutputPath is the directory where the builder image writes code.wasm, DefaultOutputPath when empty
+	OutputPath string `yaml:"output_path"`
 }
 
+// SupportedLanguages is the language registry, extended by LoadLanguages
 var SupportedLanguages = map[string]Language{
+	// no builder image is published for Go yet: Go functions build with --builder=native, or in the
+	// builder_image a language registry sets for go
+	"go": {
+		Name:             "Go",
+		Extensions:       []string{".go"},
+		MustContainFiles: []string{"go.mod"},
+	},
 	"js": {
 		Name:             "Javascript",
 		BuilderImage:     "ghcr.io/funlessdev/fl-js-builder:latest",
@@ -44,5 +54,10 @@ const (
 	DefaultTemplateRepository = "https://github.com/funlessdev/fl-templates.git"
 	ConfigDir                 = ".fl"
 	ConfigFileName            = "config"
-	ConfigKeys                = "api_host,api_token,admin_token,secret_key_base"
+	ConfigKeys                = "api_host,api_token,admin_token,secret_key_base,database_url,ca_cert"
+
+	// LanguagesFileName is the language registry in the config directory, ProjectLanguagesFileName the one of a project
+	LanguagesFileName        = "languages.yaml"
+	ProjectLanguagesFileName = "fl.languages.yaml"
+	DefaultOutputPath        = "/out_wasm"
 )
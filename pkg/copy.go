@@ -22,7 +22,13 @@ import (
 	"path/filepath"
 )
 
-func Copy(src string, dest string) error {
+// Copy copies src to dest, recursively if it is a directory, leaving out the paths matching the exclude
+// patterns, which follow the .gitignore syntax and are relative to src
+func Copy(src string, dest string, exclude ...string) error {
+	return copyExcluding(src, dest, "", NewIgnoreMatcher(exclude))
+}
+
+func copyExcluding(src string, dest string, rel string, matcher *IgnoreMatcher) error {
 	// Get properties of source
 	info, err := os.Stat(src)
 	if err != nil {
@@ -44,11 +50,15 @@ func Copy(src string, dest string) error {
 			return err
 		}
 
-		// 3. For each entry in the directory, recursively copy it
+		// 3. For each entry in the directory not excluded, recursively copy it
 		for _, dirEntry := range entries {
+			entryRel := path.Join(rel, dirEntry.Name())
+			if matcher.Ignored(entryRel, dirEntry.IsDir()) {
+				continue
+			}
 			newSrc := filepath.Join(src, dirEntry.Name())
 			newDest := filepath.Join(dest, dirEntry.Name())
-			if err := Copy(newSrc, newDest); err != nil {
+			if err := copyExcluding(newSrc, newDest, entryRel, matcher); err != nil {
 				return err
 			}
 		}
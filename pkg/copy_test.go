@@ -17,6 +17,7 @@ package pkg
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -67,3 +68,25 @@ func Test_copy(t *testing.T) {
 		}
 	}
 }
+
+func Test_copyExcluding(t *testing.T) {
+	srcDir := t.TempDir()
+	files := []string{"main.go", "README.md", "docs/guide.md", "docs/KEEP.md", "node_modules/a/index.js", "src/node_modules/b.js"}
+	for _, f := range files {
+		p := filepath.Join(srcDir, f)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, []byte(f), 0644))
+	}
+
+	destDir := filepath.Join(t.TempDir(), "dest")
+	err := Copy(srcDir, destDir, "*.md", "!KEEP.md", "/node_modules/")
+	require.NoError(t, err)
+
+	for _, f := range []string{"main.go", "docs/KEEP.md", "src/node_modules/b.js"} {
+		require.FileExists(t, filepath.Join(destDir, f))
+	}
+	for _, f := range []string{"README.md", "docs/guide.md", "node_modules"} {
+		require.NoFileExists(t, filepath.Join(destDir, f))
+		require.NoDirExists(t, filepath.Join(destDir, f))
+	}
+}
@@ -0,0 +1,747 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v2"
+)
+
+var envNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Compose is the typed model of a docker compose file.
+// The fields fl does not manage are kept in Extra, so they survive a round trip.
+type Compose struct {
+	Services   map[string]*ComposeService `yaml:"services"`
+	PortOffset int                        `yaml:"x-fl-port-offset,omitempty"`
+	Extra      map[string]interface{}     `yaml:",inline"`
+}
+
+type ComposeService struct {
+	Image       string                 `yaml:"image,omitempty"`
+	Profiles    []string               `yaml:"profiles,omitempty"`
+	DependsOn   *ComposeDependsOn      `yaml:"depends_on,omitempty"`
+	Environment *ComposeEnvironment    `yaml:"environment,omitempty"`
+	Ports       []ComposePort          `yaml:"ports,omitempty"`
+	Volumes     []ComposeVolume        `yaml:"volumes,omitempty"`
+	Deploy      *ComposeDeploy         `yaml:"deploy,omitempty"`
+	Extra       map[string]interface{} `yaml:",inline"`
+}
+
+type ComposeDeploy struct {
+	Replicas *int                   `yaml:"replicas,omitempty"`
+	Extra    map[string]interface{} `yaml:",inline"`
+}
+
+// ParseCompose reads a compose file, failing if it has no services
+func ParseCompose(content []byte) (*Compose, error) {
+	var compose Compose
+	if err := yaml.Unmarshal(content, &compose); err != nil {
+		return nil, fmt.Errorf("invalid compose file: %w", err)
+	}
+	if len(compose.Services) == 0 {
+		return nil, errors.New("no services found in the compose file")
+	}
+	for name, svc := range compose.Services {
+		if svc == nil {
+			compose.Services[name] = &ComposeService{}
+		}
+	}
+	return &compose, nil
+}
+
+func (c *Compose) Marshal() ([]byte, error) {
+	return yaml.Marshal(c)
+}
+
+// Service returns the service with the given name, or an error listing the available ones
+func (c *Compose) Service(name string) (*ComposeService, error) {
+	svc, found := c.Services[name]
+	if !found {
+		return nil, fmt.Errorf("service %q not found in the compose file (services: %s)", name, strings.Join(c.ServiceNames(), ", "))
+	}
+	return svc, nil
+}
+
+// ServiceNames returns the sorted names of the services
+func (c *Compose) ServiceNames() []string {
+	names := make([]string, 0, len(c.Services))
+	for name := range c.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetImage sets the image of a service, failing if the service does not exist
+func (c *Compose) SetImage(service string, image string) error {
+	if image == "" {
+		return fmt.Errorf("empty image for service %q", service)
+	}
+	svc, err := c.Service(service)
+	if err != nil {
+		return err
+	}
+	svc.Image = image
+	return nil
+}
+
+// RemoveService removes a service and every dependency on it, returning the names of the services that depended on it
+func (c *Compose) RemoveService(name string) []string {
+	delete(c.Services, name)
+	return c.RemoveDependencies(name)
+}
+
+// RemoveDependencies removes every dependency on a service, returning the names of the services that depended on it
+func (c *Compose) RemoveDependencies(name string) []string {
+	dependants := []string{}
+	for _, svcName := range c.ServiceNames() {
+		svc := c.Services[svcName]
+		if svc.DependsOn != nil && svc.DependsOn.Remove(name) {
+			dependants = append(dependants, svcName)
+			if svc.DependsOn.Len() == 0 {
+				svc.DependsOn = nil
+			}
+		}
+	}
+	return dependants
+}
+
+// SetProfile makes a service start only when the given compose profile is enabled
+func (c *Compose) SetProfile(service string, profile string) error {
+	svc, err := c.Service(service)
+	if err != nil {
+		return err
+	}
+	svc.Profiles = []string{profile}
+	return nil
+}
+
+// AddStack adds the services of another compose file under a compose profile, replacing the ones with the same name,
+// and merges its top-level volumes and networks. The relative bind mounts of the stack are rebased on dir, the directory
+// of the stack file relative to this one, and its host ports are moved by PortOffset.
+func (c *Compose) AddStack(stack *Compose, profile string, dir string) {
+	for name, svc := range stack.Services {
+		svc.Profiles = []string{profile}
+		for i := range svc.Volumes {
+			svc.Volumes[i].rebase(dir)
+		}
+		for i := range svc.Ports {
+			if host, ok := svc.Ports[i].HostPort(); ok && c.PortOffset != 0 {
+				svc.Ports[i].SetHostPort(host + c.PortOffset)
+			}
+		}
+		c.Services[name] = svc
+	}
+
+	for _, key := range []string{"volumes", "networks"} {
+		entries, ok := stack.Extra[key].(map[interface{}]interface{})
+		if !ok {
+			continue
+		}
+		if c.Extra == nil {
+			c.Extra = map[string]interface{}{}
+		}
+		merged, ok := c.Extra[key].(map[interface{}]interface{})
+		if !ok {
+			merged = map[interface{}]interface{}{}
+		}
+		for k, v := range entries {
+			merged[k] = v
+		}
+		c.Extra[key] = merged
+	}
+}
+
+// LocalSources returns the files and directories, inside the directory of the compose file,
+// that the services bind mount, relative to that directory
+func (c *Compose) LocalSources() []string {
+	sources := []string{}
+	for _, svc := range c.Services {
+		for i := range svc.Volumes {
+			source, ok := svc.Volumes[i].localSource()
+			if !ok {
+				continue
+			}
+			source = path.Clean(source)
+			if source != ".." && !strings.HasPrefix(source, "../") && !slices.Contains(sources, source) {
+				sources = append(sources, source)
+			}
+		}
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+// MoveLocalSources makes the bind mounts returned by LocalSources point into dir, an absolute path
+func (c *Compose) MoveLocalSources(dir string) {
+	for _, svc := range c.Services {
+		for i := range svc.Volumes {
+			source, ok := svc.Volumes[i].localSource()
+			if !ok {
+				continue
+			}
+			source = path.Clean(source)
+			if source != ".." && !strings.HasPrefix(source, "../") {
+				svc.Volumes[i].setSource(path.Join(dir, source))
+			}
+		}
+	}
+}
+
+// HostPorts returns the host ports published by the services
+func (c *Compose) HostPorts() []int {
+	var ports []int
+	for _, name := range c.ServiceNames() {
+		for _, p := range c.Services[name].Ports {
+			if host, ok := p.HostPort(); ok {
+				ports = append(ports, host)
+			}
+		}
+	}
+	return ports
+}
+
+// ShiftPorts moves every published host port by offset
+func (c *Compose) ShiftPorts(offset int) {
+	for _, svc := range c.Services {
+		for i := range svc.Ports {
+			if host, ok := svc.Ports[i].HostPort(); ok {
+				svc.Ports[i].SetHostPort(host + offset)
+			}
+		}
+	}
+}
+
+// SetLimits sets the resource limits of a service (see ResourcesSpec.ComposeLimits), keeping its other deploy settings
+func (c *Compose) SetLimits(service string, limits map[string]string) error {
+	svc, err := c.Service(service)
+	if err != nil {
+		return err
+	}
+	if len(limits) == 0 {
+		return nil
+	}
+	if svc.Deploy == nil {
+		svc.Deploy = &ComposeDeploy{}
+	}
+	if svc.Deploy.Extra == nil {
+		svc.Deploy.Extra = map[string]interface{}{}
+	}
+	resources, _ := svc.Deploy.Extra["resources"].(map[interface{}]interface{})
+	if resources == nil {
+		resources = map[interface{}]interface{}{}
+	}
+	resources["limits"] = limits
+	svc.Deploy.Extra["resources"] = resources
+	return nil
+}
+
+// PublishPort publishes the first port of a service on the given host port
+func (c *Compose) PublishPort(service string, port int) error {
+	svc, err := c.Service(service)
+	if err != nil {
+		return err
+	}
+	if len(svc.Ports) == 0 {
+		return fmt.Errorf("service %q exposes no ports", service)
+	}
+	svc.Ports[0].SetHostPort(port)
+	return nil
+}
+
+// Validate checks that the services can be started as configured
+func (c *Compose) Validate() error {
+	for _, name := range c.ServiceNames() {
+		svc := c.Services[name]
+		if svc.Image == "" && svc.Extra["build"] == nil && svc.Extra["extends"] == nil {
+			return fmt.Errorf("service %q has no image", name)
+		}
+		if svc.Deploy == nil || svc.Deploy.Replicas == nil || *svc.Deploy.Replicas <= 1 {
+			continue
+		}
+		if _, named := svc.Extra["container_name"]; named {
+			return fmt.Errorf("service %q cannot have %d replicas: it sets a container_name", name, *svc.Deploy.Replicas)
+		}
+		for _, p := range svc.Ports {
+			if host, ok := p.HostPort(); ok {
+				return fmt.Errorf("service %q cannot have %d replicas: it publishes host port %d", name, *svc.Deploy.Replicas, host)
+			}
+		}
+	}
+	return nil
+}
+
+// ApplyOverrides applies every override (see ApplyOverride) and validates the result
+func (c *Compose) ApplyOverrides(overrides []string) error {
+	for _, o := range overrides {
+		if err := c.ApplyOverride(o); err != nil {
+			return err
+		}
+	}
+	return c.Validate()
+}
+
+// ApplyOverride sets a value of a service, given as service.key=value. The supported keys are
+// image, environment.<NAME>, ports and volumes (comma separated lists replacing the existing ones)
+// and replicas.
+func (c *Compose) ApplyOverride(override string) error {
+	target, value, found := strings.Cut(override, "=")
+	if !found {
+		return fmt.Errorf("invalid override %q: expected service.key=value", override)
+	}
+	service, key, found := strings.Cut(target, ".")
+	if !found || service == "" || key == "" {
+		return fmt.Errorf("invalid override %q: expected service.key=value", override)
+	}
+
+	svc, err := c.Service(service)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case key == "image":
+		return c.SetImage(service, value)
+	case strings.HasPrefix(key, "environment."):
+		name := strings.TrimPrefix(key, "environment.")
+		if !envNameRegex.MatchString(name) {
+			return fmt.Errorf("invalid environment variable name %q", name)
+		}
+		if svc.Environment == nil {
+			svc.Environment = NewComposeEnvironment()
+		}
+		svc.Environment.Set(name, value)
+	case key == "ports":
+		ports := []ComposePort{}
+		for _, entry := range splitList(value) {
+			p, err := ParseComposePort(entry)
+			if err != nil {
+				return err
+			}
+			ports = append(ports, p)
+		}
+		svc.Ports = ports
+	case key == "volumes":
+		volumes := []ComposeVolume{}
+		for _, entry := range splitList(value) {
+			v, err := ParseComposeVolume(entry)
+			if err != nil {
+				return err
+			}
+			volumes = append(volumes, v)
+		}
+		svc.Volumes = volumes
+	case key == "replicas":
+		replicas, err := strconv.Atoi(value)
+		if err != nil || replicas < 0 {
+			return fmt.Errorf("invalid replicas %q for service %q: expected a non-negative number", value, service)
+		}
+		if svc.Deploy == nil {
+			svc.Deploy = &ComposeDeploy{}
+		}
+		svc.Deploy.Replicas = &replicas
+	default:
+		return fmt.Errorf("unsupported override key %q: use image, environment.<NAME>, ports, volumes or replicas", key)
+	}
+	return nil
+}
+
+func splitList(value string) []string {
+	entries := []string{}
+	for _, e := range strings.Split(value, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// ComposeEnvironment is the environment of a service, in either the list or the map syntax.
+// The syntax is kept when marshalling.
+type ComposeEnvironment struct {
+	vars    []composeEnvVar
+	mapForm bool
+}
+
+type composeEnvVar struct {
+	name  string
+	value *string // nil when the value is taken from the shell
+}
+
+// NewComposeEnvironment creates an empty environment, using the map syntax
+func NewComposeEnvironment() *ComposeEnvironment {
+	return &ComposeEnvironment{mapForm: true}
+}
+
+// Get returns the value of a variable, if set
+func (e *ComposeEnvironment) Get(name string) (string, bool) {
+	for _, v := range e.vars {
+		if v.name == name && v.value != nil {
+			return *v.value, true
+		}
+	}
+	return "", false
+}
+
+// Set sets a variable, placing it after the other ones
+func (e *ComposeEnvironment) Set(name string, value string) {
+	e.Unset(name)
+	e.vars = append(e.vars, composeEnvVar{name: name, value: &value})
+}
+
+func (e *ComposeEnvironment) Unset(name string) {
+	kept := e.vars[:0]
+	for _, v := range e.vars {
+		if v.name != name {
+			kept = append(kept, v)
+		}
+	}
+	e.vars = kept
+}
+
+func (e *ComposeEnvironment) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var list []string
+	if err := unmarshal(&list); err == nil {
+		for _, entry := range list {
+			name, value, found := strings.Cut(entry, "=")
+			v := composeEnvVar{name: name}
+			if found {
+				v.value = &value
+			}
+			e.vars = append(e.vars, v)
+		}
+		return nil
+	}
+
+	var entries yaml.MapSlice
+	if err := unmarshal(&entries); err != nil {
+		return errors.New("environment must be a list or a map")
+	}
+	e.mapForm = true
+	for _, item := range entries {
+		v := composeEnvVar{name: fmt.Sprint(item.Key)}
+		if item.Value != nil {
+			value := fmt.Sprint(item.Value)
+			v.value = &value
+		}
+		e.vars = append(e.vars, v)
+	}
+	return nil
+}
+
+func (e ComposeEnvironment) MarshalYAML() (interface{}, error) {
+	if e.mapForm {
+		entries := make(yaml.MapSlice, 0, len(e.vars))
+		for _, v := range e.vars {
+			var value interface{}
+			if v.value != nil {
+				value = *v.value
+			}
+			entries = append(entries, yaml.MapItem{Key: v.name, Value: value})
+		}
+		return entries, nil
+	}
+
+	list := make([]string, 0, len(e.vars))
+	for _, v := range e.vars {
+		if v.value == nil {
+			list = append(list, v.name)
+		} else {
+			list = append(list, v.name+"="+*v.value)
+		}
+	}
+	return list, nil
+}
+
+// ComposeDependsOn lists the dependencies of a service, in either the list or the map syntax
+// (the latter carrying conditions). The syntax is kept when marshalling.
+type ComposeDependsOn struct {
+	entries yaml.MapSlice
+	mapForm bool
+}
+
+func (d *ComposeDependsOn) Names() []string {
+	names := make([]string, 0, len(d.entries))
+	for _, item := range d.entries {
+		names = append(names, fmt.Sprint(item.Key))
+	}
+	return names
+}
+
+func (d *ComposeDependsOn) Len() int {
+	return len(d.entries)
+}
+
+// Remove removes a dependency, reporting whether it was there
+func (d *ComposeDependsOn) Remove(name string) bool {
+	kept := make(yaml.MapSlice, 0, len(d.entries))
+	for _, item := range d.entries {
+		if fmt.Sprint(item.Key) != name {
+			kept = append(kept, item)
+		}
+	}
+	removed := len(kept) != len(d.entries)
+	d.entries = kept
+	return removed
+}
+
+func (d *ComposeDependsOn) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var list []string
+	if err := unmarshal(&list); err == nil {
+		for _, name := range list {
+			d.entries = append(d.entries, yaml.MapItem{Key: name})
+		}
+		return nil
+	}
+
+	if err := unmarshal(&d.entries); err != nil {
+		return errors.New("depends_on must be a list or a map")
+	}
+	d.mapForm = true
+	return nil
+}
+
+func (d ComposeDependsOn) MarshalYAML() (interface{}, error) {
+	if d.mapForm {
+		return d.entries, nil
+	}
+	return d.Names(), nil
+}
+
+// ComposePort is a port of a service, in either the short ("[ip:][host:]container[/protocol]")
+// or the long syntax. The syntax is kept when marshalling.
+type ComposePort struct {
+	HostIP    string
+	Published string // host port or range, empty if not published
+	Target    string // container port or range, with the protocol
+
+	raw  string        // a short syntax entry fl cannot parse (e.g. with variables), kept as is
+	long yaml.MapSlice // the long syntax entry, if the port uses it
+}
+
+// ParseComposePort parses a port in the short syntax
+func ParseComposePort(entry string) (ComposePort, error) {
+	parts := strings.Split(entry, ":")
+	p := ComposePort{Target: parts[len(parts)-1]}
+	if len(parts) >= 2 {
+		p.Published = parts[len(parts)-2]
+	}
+	if len(parts) >= 3 {
+		p.HostIP = strings.Join(parts[:len(parts)-2], ":")
+	}
+
+	target := strings.SplitN(p.Target, "/", 2)[0]
+	if !isPortOrRange(target) || (p.Published != "" && !isPortOrRange(p.Published)) {
+		return ComposePort{}, fmt.Errorf("invalid port %q: expected [ip:][host:]container[/protocol]", entry)
+	}
+	return p, nil
+}
+
+func isPortOrRange(s string) bool {
+	for _, part := range strings.SplitN(s, "-", 2) {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || n > 65535 {
+			return false
+		}
+	}
+	return true
+}
+
+// HostPort returns the host port, if the port is published on a single one
+func (p *ComposePort) HostPort() (int, bool) {
+	if p.raw != "" {
+		return 0, false
+	}
+	published := p.Published
+	if p.long != nil {
+		published = fmt.Sprint(longValue(p.long, "published"))
+	}
+	host, err := strconv.Atoi(published)
+	if err != nil {
+		return 0, false
+	}
+	return host, true
+}
+
+func (p *ComposePort) SetHostPort(host int) {
+	if p.long != nil {
+		setLongValue(&p.long, "published", host)
+		return
+	}
+	p.Published = strconv.Itoa(host)
+}
+
+func (p *ComposePort) String() string {
+	if p.raw != "" {
+		return p.raw
+	}
+	parts := []string{}
+	if p.HostIP != "" {
+		parts = append(parts, p.HostIP)
+	}
+	if p.Published != "" || p.HostIP != "" {
+		parts = append(parts, p.Published)
+	}
+	return strings.Join(append(parts, p.Target), ":")
+}
+
+func (p *ComposePort) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var short string
+	if err := unmarshal(&short); err == nil {
+		parsed, err := ParseComposePort(short)
+		if err != nil {
+			parsed = ComposePort{raw: short}
+		}
+		*p = parsed
+		return nil
+	}
+
+	if err := unmarshal(&p.long); err != nil {
+		return errors.New("port must be a string or a map")
+	}
+	return nil
+}
+
+func (p ComposePort) MarshalYAML() (interface{}, error) {
+	if p.long != nil {
+		return p.long, nil
+	}
+	return p.String(), nil
+}
+
+// ComposeVolume is a volume of a service, in either the short ("source:target[:mode]")
+// or the long syntax. The syntax is kept when marshalling.
+type ComposeVolume struct {
+	Source string
+	Target string
+	Mode   string
+
+	raw  string        // a short syntax entry fl cannot parse (e.g. with variables), kept as is
+	long yaml.MapSlice // the long syntax entry, if the volume uses it
+}
+
+// ParseComposeVolume parses a volume in the short syntax
+func ParseComposeVolume(entry string) (ComposeVolume, error) {
+	parts := strings.Split(entry, ":")
+	var v ComposeVolume
+	switch len(parts) {
+	case 1:
+		v.Target = parts[0]
+	case 2:
+		v.Source, v.Target = parts[0], parts[1]
+	case 3:
+		v.Source, v.Target, v.Mode = parts[0], parts[1], parts[2]
+	default:
+		return ComposeVolume{}, fmt.Errorf("invalid volume %q: expected [source:]target[:mode]", entry)
+	}
+	if v.Target == "" || (len(parts) > 1 && v.Source == "") {
+		return ComposeVolume{}, fmt.Errorf("invalid volume %q: expected [source:]target[:mode]", entry)
+	}
+	return v, nil
+}
+
+func (v *ComposeVolume) String() string {
+	if v.raw != "" {
+		return v.raw
+	}
+	parts := []string{}
+	if v.Source != "" {
+		parts = append(parts, v.Source)
+	}
+	parts = append(parts, v.Target)
+	if v.Mode != "" {
+		parts = append(parts, v.Mode)
+	}
+	return strings.Join(parts, ":")
+}
+
+// localSource returns the source of a bind mount relative to the directory of the compose file
+func (v *ComposeVolume) localSource() (string, bool) {
+	source := v.Source
+	if v.long != nil {
+		source, _ = longValue(v.long, "source").(string)
+	}
+	if strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../") || source == "." {
+		return source, true
+	}
+	return "", false
+}
+
+func (v *ComposeVolume) setSource(source string) {
+	if v.long != nil {
+		setLongValue(&v.long, "source", source)
+		return
+	}
+	v.Source = source
+}
+
+// rebase moves a relative bind mount source into dir
+func (v *ComposeVolume) rebase(dir string) {
+	if source, ok := v.localSource(); ok {
+		v.setSource("./" + path.Join(dir, source))
+	}
+}
+
+func (v *ComposeVolume) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var short string
+	if err := unmarshal(&short); err == nil {
+		parsed, err := ParseComposeVolume(short)
+		if err != nil {
+			parsed = ComposeVolume{raw: short}
+		}
+		*v = parsed
+		return nil
+	}
+
+	if err := unmarshal(&v.long); err != nil {
+		return errors.New("volume must be a string or a map")
+	}
+	return nil
+}
+
+func (v ComposeVolume) MarshalYAML() (interface{}, error) {
+	if v.long != nil {
+		return v.long, nil
+	}
+	return v.String(), nil
+}
+
+func longValue(entries yaml.MapSlice, key string) interface{} {
+	for _, item := range entries {
+		if item.Key == key {
+			return item.Value
+		}
+	}
+	return nil
+}
+
+func setLongValue(entries *yaml.MapSlice, key string, value interface{}) {
+	for i, item := range *entries {
+		if item.Key == key {
+			(*entries)[i].Value = value
+			return
+		}
+	}
+	*entries = append(*entries, yaml.MapItem{Key: key, Value: value})
+}
@@ -0,0 +1,240 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+const testOverridesComposeYml = `version: "3.9"
+services:
+  core:
+    image: ghcr.io/funlessdev/core:latest
+    depends_on:
+      - postgres
+    environment:
+      - PGHOST=postgres
+    ports:
+      - "4000:4000"
+    healthcheck:
+      test: ["CMD", "curl", "localhost:4000"]
+  worker:
+    image: ghcr.io/funlessdev/worker:latest
+    environment:
+      LOG_LEVEL: info
+  postgres:
+    image: postgres:latest
+    volumes:
+      - pgdata:/var/lib/postgresql/data
+volumes:
+  pgdata:
+`
+
+func parseTestCompose(t *testing.T) *Compose {
+	compose, err := ParseCompose([]byte(testOverridesComposeYml))
+	require.NoError(t, err)
+	return compose
+}
+
+func TestComposePort(t *testing.T) {
+	p, err := ParseComposePort("4000:4000")
+	require.NoError(t, err)
+	host, ok := p.HostPort()
+	require.True(t, ok)
+	require.Equal(t, 4000, host)
+	p.SetHostPort(4001)
+	require.Equal(t, "4001:4000", p.String())
+
+	p, err = ParseComposePort("127.0.0.1:9090:9090/tcp")
+	require.NoError(t, err)
+	host, ok = p.HostPort()
+	require.True(t, ok)
+	require.Equal(t, 9090, host)
+	p.SetHostPort(9091)
+	require.Equal(t, "127.0.0.1:9091:9090/tcp", p.String())
+
+	var long ComposePort
+	require.NoError(t, yaml.Unmarshal([]byte(`{target: 5601, published: "5601"}`), &long))
+	host, ok = long.HostPort()
+	require.True(t, ok)
+	require.Equal(t, 5601, host)
+	long.SetHostPort(5602)
+	out, err := yaml.Marshal(long)
+	require.NoError(t, err)
+	require.Equal(t, "target: 5601\npublished: 5602\n", string(out))
+
+	p, err = ParseComposePort("4369")
+	require.NoError(t, err)
+	_, ok = p.HostPort()
+	require.False(t, ok)
+
+	// entries with variables are kept as they are
+	var withVars ComposePort
+	require.NoError(t, yaml.Unmarshal([]byte(`"${CORE_PORT:-4000}:4000"`), &withVars))
+	_, ok = withVars.HostPort()
+	require.False(t, ok)
+	require.Equal(t, "${CORE_PORT:-4000}:4000", withVars.String())
+
+	_, err = ParseComposePort("abc:4000")
+	require.Error(t, err)
+	_, err = ParseComposePort("4000:70000")
+	require.Error(t, err)
+}
+
+func TestParseCompose(t *testing.T) {
+	t.Run("should keep the fields it does not manage", func(t *testing.T) {
+		out, err := parseTestCompose(t).Marshal()
+		require.NoError(t, err)
+
+		var roundTrip map[string]interface{}
+		require.NoError(t, yaml.Unmarshal(out, &roundTrip))
+		var original map[string]interface{}
+		require.NoError(t, yaml.Unmarshal([]byte(testOverridesComposeYml), &original))
+		require.Equal(t, original, roundTrip)
+	})
+
+	t.Run("should return error instead of panicking on malformed files", func(t *testing.T) {
+		_, err := ParseCompose([]byte("services: []"))
+		require.Error(t, err)
+		_, err = ParseCompose([]byte("version: '3'"))
+		require.EqualError(t, err, "no services found in the compose file")
+		_, err = ParseCompose([]byte("services:\n  core:\n    environment: 3\n"))
+		require.Error(t, err)
+	})
+
+	t.Run("should return error when setting the image of a missing service", func(t *testing.T) {
+		compose, err := ParseCompose([]byte("services:\n  worker:\n    image: worker\n"))
+		require.NoError(t, err)
+		require.EqualError(t, compose.SetImage("core", "core"), `service "core" not found in the compose file (services: worker)`)
+	})
+}
+
+func TestComposeRemoveService(t *testing.T) {
+	compose := parseTestCompose(t)
+
+	dependants := compose.RemoveService("postgres")
+	require.Equal(t, []string{"core"}, dependants)
+	require.NotContains(t, compose.Services, "postgres")
+	require.Nil(t, compose.Services["core"].DependsOn)
+}
+
+func TestComposeApplyOverrides(t *testing.T) {
+	t.Run("should override images, environment, ports, volumes and replicas", func(t *testing.T) {
+		compose := parseTestCompose(t)
+
+		err := compose.ApplyOverrides([]string{
+			"core.image=my-core",
+			"core.environment.LOG_LEVEL=debug",
+			"core.ports=4100:4000, 127.0.0.1:4369:4369",
+			"core.volumes=./data:/data:ro",
+			"worker.environment.LOG_LEVEL=warning",
+			"worker.replicas=3",
+			"postgres.environment.POSTGRES_DB=fl",
+		})
+		require.NoError(t, err)
+
+		out, err := compose.Marshal()
+		require.NoError(t, err)
+		var result struct {
+			Services map[string]map[string]interface{} `yaml:"services"`
+		}
+		require.NoError(t, yaml.Unmarshal(out, &result))
+
+		core := result.Services["core"]
+		require.Equal(t, "my-core", core["image"])
+		require.Equal(t, []interface{}{"PGHOST=postgres", "LOG_LEVEL=debug"}, core["environment"])
+		require.Equal(t, []interface{}{"4100:4000", "127.0.0.1:4369:4369"}, core["ports"])
+		require.Equal(t, []interface{}{"./data:/data:ro"}, core["volumes"])
+
+		worker := result.Services["worker"]
+		require.Equal(t, map[interface{}]interface{}{"LOG_LEVEL": "warning"}, worker["environment"])
+		require.Equal(t, map[interface{}]interface{}{"replicas": 3}, worker["deploy"])
+
+		require.Equal(t, map[interface{}]interface{}{"POSTGRES_DB": "fl"}, result.Services["postgres"]["environment"])
+	})
+
+	t.Run("should reject invalid overrides", func(t *testing.T) {
+		invalid := map[string]string{
+			"core":                     `invalid override "core": expected service.key=value`,
+			"core=x":                   `invalid override "core=x": expected service.key=value`,
+			"db.image=postgres":        `service "db" not found in the compose file (services: core, postgres, worker)`,
+			"core.image=":              `empty image for service "core"`,
+			"core.command=start":       `unsupported override key "command": use image, environment.<NAME>, ports, volumes or replicas`,
+			"core.environment.1X=a":    `invalid environment variable name "1X"`,
+			"core.ports=4000:abc":      `invalid port "4000:abc": expected [ip:][host:]container[/protocol]`,
+			"core.volumes=:/data":      `invalid volume ":/data": expected [source:]target[:mode]`,
+			"worker.replicas=-1":       `invalid replicas "-1" for service "worker": expected a non-negative number`,
+			"core.replicas=2":          `service "core" cannot have 2 replicas: it publishes host port 4000`,
+			"worker.replicas=two":      `invalid replicas "two" for service "worker": expected a non-negative number`,
+			"worker.environment.=info": `invalid environment variable name ""`,
+		}
+		for override, expected := range invalid {
+			err := parseTestCompose(t).ApplyOverrides([]string{override})
+			require.EqualError(t, err, expected, override)
+		}
+	})
+}
+
+func TestComposeLocalSources(t *testing.T) {
+	compose, err := ParseCompose([]byte(`services:
+  prometheus:
+    image: prom/prometheus
+    volumes:
+      - ./prometheus/config.yml:/etc/prometheus/prometheus.yml
+      - promdata:/prometheus
+  grafana:
+    image: grafana/grafana
+    volumes:
+      - type: bind
+        source: ./grafana/provisioning
+        target: /etc/grafana/provisioning
+      - ../shared:/shared
+      - /etc/localtime:/etc/localtime:ro
+`))
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"grafana/provisioning", "prometheus/config.yml"}, compose.LocalSources())
+
+	compose.MoveLocalSources("/home/fl/.fl")
+	require.Empty(t, compose.LocalSources())
+
+	out, err := compose.Marshal()
+	require.NoError(t, err)
+	require.Contains(t, string(out), "/home/fl/.fl/prometheus/config.yml:/etc/prometheus/prometheus.yml")
+	require.Contains(t, string(out), "source: /home/fl/.fl/grafana/provisioning")
+	require.Contains(t, string(out), "../shared:/shared")
+	require.Contains(t, string(out), "promdata:/prometheus")
+}
+
+func TestComposeSetLimitsAndPublishPort(t *testing.T) {
+	compose := parseTestCompose(t)
+
+	require.NoError(t, compose.SetLimits("worker", map[string]string{"cpus": "0.5", "memory": "536870912"}))
+	require.NoError(t, compose.PublishPort("core", 4100))
+	require.ErrorContains(t, compose.PublishPort("worker", 4100), "exposes no ports")
+	require.Error(t, compose.SetLimits("missing", map[string]string{"cpus": "1"}))
+
+	out, err := compose.Marshal()
+	require.NoError(t, err)
+	require.Contains(t, string(out), `- 4100:4000`)
+
+	reparsed, err := ParseCompose(out)
+	require.NoError(t, err)
+	resources := reparsed.Services["worker"].Deploy.Extra["resources"].(map[interface{}]interface{})
+	require.Equal(t, map[interface{}]interface{}{"cpus": "0.5", "memory": "536870912"}, resources["limits"])
+}
@@ -0,0 +1,71 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Shell commands run inside the postgres container to dump and restore the platform database.
+// The official postgres image exposes the credentials in POSTGRES_USER and POSTGRES_DB.
+const (
+	PostgresDumpCmd    = `pg_dump -U "${POSTGRES_USER:-postgres}" --format=custom "${POSTGRES_DB:-${POSTGRES_USER:-postgres}}"`
+	PostgresRestoreCmd = `pg_restore -U "${POSTGRES_USER:-postgres}" --clean --if-exists --no-owner -d "${POSTGRES_DB:-${POSTGRES_USER:-postgres}}"`
+)
+
+// CoreMigrateCmd is the release script of the core image running the database migrations.
+const CoreMigrateCmd = "bin/migrate"
+
+// DatabaseEnv validates a postgres connection URL and returns the environment variables
+// used by the core (and the init job) to connect to it: DATABASE_URL and the libpq PG* ones.
+func DatabaseEnv(databaseURL string) (map[string]string, error) {
+	u, err := url.Parse(databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid database url: %w", err)
+	}
+	if u.Scheme != "postgres" && u.Scheme != "postgresql" {
+		return nil, fmt.Errorf("invalid database url: unsupported scheme %q, expected postgres://", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid database url: missing host")
+	}
+	database := strings.TrimPrefix(u.Path, "/")
+	if database == "" {
+		return nil, fmt.Errorf("invalid database url: missing database name")
+	}
+
+	env := map[string]string{
+		"DATABASE_URL": databaseURL,
+		"PGHOST":       u.Hostname(),
+		"PGPORT":       "5432",
+		"PGDATABASE":   database,
+	}
+	if port := u.Port(); port != "" {
+		env["PGPORT"] = port
+	}
+	if u.User != nil {
+		env["PGUSER"] = u.User.Username()
+		if password, ok := u.User.Password(); ok {
+			env["PGPASSWORD"] = password
+		}
+	}
+	if sslMode := u.Query().Get("sslmode"); sslMode != "" {
+		env["PGSSLMODE"] = sslMode
+	}
+
+	return env, nil
+}
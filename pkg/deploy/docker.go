@@ -21,43 +21,154 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"path"
 	"regexp"
 	"strings"
 
 	"github.com/funlessdev/fl-cli/pkg"
+	"github.com/funlessdev/fl-cli/pkg/docker"
 )
 
 type DockerShell interface {
-	ComposeUp(ctx context.Context, composeFilePath string) error
-	ComposeDown(ctx context.Context, composeFilePath string) error
+	ComposeUp(ctx context.Context, project string, composeFilePath string) error
+	ComposeRecreate(ctx context.Context, project string, composeFilePath string, services ...string) error
+	ComposeRun(ctx context.Context, project string, composeFilePath string, service string, args ...string) error
+	ComposeDown(ctx context.Context, project string, composeFilePath string) error
+	ComposeRemove(ctx context.Context, project string, composeFilePath string, profiles []string, services []string) error
 	ComposeList(ctx context.Context) ([]string, error)
-	LogTokens(ctx context.Context) error
+	LogTokens(ctx context.Context, project string) error
+	Exec(ctx context.Context, container string, stdin io.Reader, stdout io.Writer, args ...string) error
+	UploadAssets(ctx context.Context, host string, localDir string, paths []string, remoteDir string) (string, error)
 }
 
-type FLDockerShell struct{}
+// FLDockerShell runs the command line of the container runtime, Docker unless Runtime says otherwise
+type FLDockerShell struct {
+	Runtime docker.Runtime
 
-func (sh *FLDockerShell) ComposeUp(ctx context.Context, composeFilePath string) error {
-	return runShellCmd(ctx, os.Stdout, os.Stderr, "docker", "compose", "-f", composeFilePath, "up", "-d")
+	// the compose command of Podman, found on first use
+	podmanCompose string
 }
 
-func (sh *FLDockerShell) ComposeDown(ctx context.Context, composeFilePath string) error {
-	return runShellCmd(ctx, os.Stdout, os.Stderr, "docker", "compose", "-f", composeFilePath, "down")
+func NewDockerShell(rt docker.Runtime) DockerShell {
+	return &FLDockerShell{Runtime: rt}
+}
+
+func (sh *FLDockerShell) ComposeUp(ctx context.Context, project string, composeFilePath string) error {
+	return runShellCmd(ctx, os.Stdout, os.Stderr, sh.compose(ctx), "-p", project, "-f", composeFilePath, "up", "-d")
+}
+
+// ComposeRecreate recreates only the given services, leaving their dependencies untouched
+func (sh *FLDockerShell) ComposeRecreate(ctx context.Context, project string, composeFilePath string, services ...string) error {
+	params := append([]string{"-p", project, "-f", composeFilePath, "up", "-d", "--no-deps", "--force-recreate"}, services...)
+	return runShellCmd(ctx, os.Stdout, os.Stderr, sh.compose(ctx), params...)
+}
+
+// ComposeRun runs a one-off command in a new container of the given service
+func (sh *FLDockerShell) ComposeRun(ctx context.Context, project string, composeFilePath string, service string, args ...string) error {
+	params := append([]string{"-p", project, "-f", composeFilePath, "run", "--rm", "--no-deps", service}, args...)
+	return runShellCmd(ctx, os.Stdout, os.Stderr, sh.compose(ctx), params...)
+}
+
+func (sh *FLDockerShell) ComposeDown(ctx context.Context, project string, composeFilePath string) error {
+	return runShellCmd(ctx, os.Stdout, os.Stderr, sh.compose(ctx), "-p", project, "-f", composeFilePath, "down")
+}
+
+// ComposeRemove stops and removes the containers of the given services, enabling the profiles they are under
+func (sh *FLDockerShell) ComposeRemove(ctx context.Context, project string, composeFilePath string, profiles []string, services []string) error {
+	params := []string{"-p", project, "-f", composeFilePath}
+	for _, profile := range profiles {
+		params = append(params, "--profile", profile)
+	}
+	params = append(params, "rm", "--stop", "--force")
+	params = append(params, services...)
+	return runShellCmd(ctx, os.Stdout, os.Stderr, sh.compose(ctx), params...)
 }
 
 func (sh *FLDockerShell) ComposeList(ctx context.Context) ([]string, error) {
 	var buf bytes.Buffer
-	err := runShellCmd(ctx, &buf, os.Stderr, "docker", "compose", "ls", "-q")
+	err := runShellCmd(ctx, &buf, os.Stderr, sh.compose(ctx), "ls", "-q")
 	lines := strings.Split(buf.String(), "\n")
 	return lines, err
 }
 
-func (sh *FLDockerShell) LogTokens(ctx context.Context) error {
-	return runShellCmd(ctx, os.Stdout, os.Stderr, "docker", "exec", "fl-core-1", "cat", "/tmp/funless/tokens")
+func (sh *FLDockerShell) LogTokens(ctx context.Context, project string) error {
+	return runShellCmd(ctx, os.Stdout, os.Stderr, sh.cli(), "exec", DockerContainerName(project, "core"), "cat", "/tmp/funless/tokens")
+}
+
+// Exec runs a command in a running container, streaming stdin (if any) and stdout
+func (sh *FLDockerShell) Exec(ctx context.Context, container string, stdin io.Reader, stdout io.Writer, args ...string) error {
+	params := []string{"exec"}
+	if stdin != nil {
+		params = append(params, "-i")
+	}
+	params = append(params, container)
+	params = append(params, args...)
+	return runShellCmdWithInput(ctx, stdin, stdout, os.Stderr, sh.cli(), params...)
+}
+
+// cli returns the command line tool of the runtime
+func (sh *FLDockerShell) cli() string {
+	if sh.Runtime == docker.RuntimePodman {
+		return "podman"
+	}
+	return "docker"
+}
+
+// compose returns the compose command of the runtime: "docker compose", or "podman compose" when Podman
+// has a compose provider, "podman-compose" otherwise
+func (sh *FLDockerShell) compose(ctx context.Context) string {
+	if sh.Runtime != docker.RuntimePodman {
+		return "docker compose"
+	}
+	if sh.podmanCompose == "" {
+		sh.podmanCompose = "podman-compose"
+		if runShellCmd(ctx, io.Discard, io.Discard, "podman", "compose", "version") == nil {
+			sh.podmanCompose = "podman compose"
+		}
+	}
+	return sh.podmanCompose
+}
+
+// UploadAssets copies paths, relative to localDir, into remoteDir (relative to the home of the ssh user)
+// on the ssh:// Docker host, replacing older copies. It returns the absolute path of remoteDir.
+func (sh *FLDockerShell) UploadAssets(ctx context.Context, host string, localDir string, paths []string, remoteDir string) (string, error) {
+	quoted := make([]string, 0, len(paths))
+	for _, p := range paths {
+		quoted = append(quoted, shellQuote(p))
+	}
+	script := fmt.Sprintf("mkdir -p %[1]s && cd %[1]s && rm -rf %[2]s && tar -xf - && pwd", shellQuote(remoteDir), strings.Join(quoted, " "))
+
+	args, err := docker.SSHArgs(host, script)
+	if err != nil {
+		return "", err
+	}
+
+	archive, writer := io.Pipe()
+	defer archive.Close()
+	go func() {
+		writer.CloseWithError(writeTar(writer, localDir, paths))
+	}()
+
+	var out bytes.Buffer
+	if err := runShellCmdWithInput(ctx, archive, &out, os.Stderr, "ssh", args...); err != nil {
+		return "", fmt.Errorf("unable to copy the deployment assets to %s: %w", host, err)
+	}
+
+	dir := strings.TrimSpace(out.String())
+	if !path.IsAbs(dir) {
+		return "", fmt.Errorf("unable to copy the deployment assets to %s: unexpected output %q", host, dir)
+	}
+	return dir, nil
 }
 
 func runShellCmd(ctx context.Context, resultBuf io.Writer, errorBuf io.Writer, cmd string, args ...string) error {
+	return runShellCmdWithInput(ctx, nil, resultBuf, errorBuf, cmd, args...)
+}
+
+func runShellCmdWithInput(ctx context.Context, input io.Reader, resultBuf io.Writer, errorBuf io.Writer, cmd string, args ...string) error {
 	exe, params := parseCmd(ctx, cmd, args...)
 	command := exec.Command(exe, params...)
+	command.Stdin = input
 	command.Stdout = resultBuf
 	command.Stderr = errorBuf
 
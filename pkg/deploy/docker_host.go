@@ -0,0 +1,110 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DockerHostAddress returns the address the services published by the Docker host are reachable at:
+// localhost for the local engine, the host name for tcp:// and ssh:// hosts.
+func DockerHostAddress(host string) (string, error) {
+	if host == "" {
+		return "localhost", nil
+	}
+	u, err := url.Parse(host)
+	if err != nil {
+		return "", fmt.Errorf("invalid Docker host %q: %w", host, err)
+	}
+	switch u.Scheme {
+	case "unix", "npipe":
+		return "localhost", nil
+	case "tcp", "ssh":
+		if u.Hostname() == "" {
+			return "", fmt.Errorf("invalid Docker host %q: missing host name", host)
+		}
+		return u.Hostname(), nil
+	default:
+		return "", fmt.Errorf("invalid Docker host %q: use unix://, tcp:// or ssh://", host)
+	}
+}
+
+// RecordedDockerHost returns the Docker host the local instance was deployed to, empty when it is the
+// one of the environment or the instance is not recorded.
+func RecordedDockerHost(project string) string {
+	registry, err := LoadRegistry()
+	if err != nil {
+		return ""
+	}
+	record, found := registry.Find(Deployment{Backend: "docker", Project: project}.ID())
+	if !found {
+		return ""
+	}
+	return record.DockerHost
+}
+
+// writeTar writes an archive of paths, relative to dir, with the directories walked recursively
+func writeTar(w io.Writer, dir string, paths []string) error {
+	tw := tar.NewWriter(w)
+	for _, p := range paths {
+		err := filepath.Walk(filepath.Join(dir, p), func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() && !info.Mode().IsRegular() {
+				return nil
+			}
+
+			name, err := filepath.Rel(dir, file)
+			if err != nil {
+				return err
+			}
+			hdr, err := tar.FileInfoHeader(info, "")
+			if err != nil {
+				return err
+			}
+			hdr.Name = filepath.ToSlash(name)
+			if err := tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+
+			f, err := os.Open(file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = io.Copy(tw, f)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// shellQuote quotes s as a single word for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
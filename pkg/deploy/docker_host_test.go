@@ -0,0 +1,110 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/funlessdev/fl-cli/pkg/homedir"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDockerHostAddress(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+		err  bool
+	}{
+		{host: "", want: "localhost"},
+		{host: "unix:///var/run/docker.sock", want: "localhost"},
+		{host: "tcp://10.0.0.5:2376", want: "10.0.0.5"},
+		{host: "ssh://fl@test-box:2222", want: "test-box"},
+		{host: "ssh://", err: true},
+		{host: "http://test-box", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			got, err := DockerHostAddress(tt.host)
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRecordedDockerHost(t *testing.T) {
+	homedirPath, err := os.MkdirTemp("", "funless-test-homedir-")
+	require.NoError(t, err)
+
+	homedir.GetHomeDir = func() (string, error) {
+		return homedirPath, nil
+	}
+	defer func() {
+		homedir.GetHomeDir = os.UserHomeDir
+		os.RemoveAll(homedirPath)
+	}()
+
+	require.Empty(t, RecordedDockerHost("fl"))
+
+	err = RecordDeployment(Deployment{Backend: "docker", Project: "fl", DockerHost: "ssh://fl@test-box"})
+	require.NoError(t, err)
+	require.Equal(t, "ssh://fl@test-box", RecordedDockerHost("fl"))
+	require.Empty(t, RecordedDockerHost("fl-next"))
+}
+
+func Test_writeTar(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "grafana", "dashboards"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "grafana", "dashboards", "funless.json"), []byte("{}"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "prometheus"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "prometheus", "config.yml"), []byte("scrape_configs: []"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "prometheus", "other.yml"), []byte(""), 0644))
+
+	var buf bytes.Buffer
+	require.NoError(t, writeTar(&buf, dir, []string{"grafana", "prometheus/config.yml"}))
+
+	entries := map[string]string{}
+	tr := tar.NewReader(&buf)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		content, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		entries[hdr.Name] = string(content)
+	}
+
+	require.Equal(t, map[string]string{
+		"grafana":                         "",
+		"grafana/dashboards":              "",
+		"grafana/dashboards/funless.json": "{}",
+		"prometheus/config.yml":           "scrape_configs: []",
+	}, entries)
+}
+
+func Test_shellQuote(t *testing.T) {
+	require.Equal(t, `'.fl/instances/fl'`, shellQuote(".fl/instances/fl"))
+	require.Equal(t, `'it'\''s'`, shellQuote("it's"))
+}
@@ -0,0 +1,45 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+)
+
+var dockerInstanceNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ValidateDockerInstanceName checks that name can be used as a compose project name.
+func ValidateDockerInstanceName(name string) error {
+	if !dockerInstanceNameRegex.MatchString(name) {
+		return fmt.Errorf("invalid instance name %q: use lowercase letters, digits, dashes and underscores, starting with a letter or digit", name)
+	}
+	return nil
+}
+
+// DockerInstanceDir returns the directory, relative to the config dir, holding the assets of a docker instance.
+// The default instance keeps them in the config dir itself.
+func DockerInstanceDir(name string) string {
+	if name == DockerProject {
+		return ""
+	}
+	return path.Join("instances", name)
+}
+
+// DockerContainerName returns the name compose gives to the first container of a service in a project.
+func DockerContainerName(project string, service string) string {
+	return fmt.Sprintf("%s-%s-1", project, service)
+}
@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"context"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -84,3 +85,14 @@ func Test_runShellCmd(t *testing.T) {
 	})
 
 }
+
+func Test_runShellCmdWithInput(t *testing.T) {
+	t.Run("should pass the input to the command stdin", func(t *testing.T) {
+		var outBuf bytes.Buffer
+		testCtx := context.Background()
+
+		err := runShellCmdWithInput(testCtx, strings.NewReader("hello in"), &outBuf, os.Stderr, "cat")
+		assert.Nil(t, err)
+		assert.Equal(t, "hello in", outBuf.String())
+	})
+}
@@ -0,0 +1,118 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/funlessdev/fl-cli/pkg/docker"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// ErrNoKubeConfig is returned when no kubeconfig was given and ~/.kube/config does not exist
+var ErrNoKubeConfig = errors.New("no kubeconfig found")
+
+// probeTimeout bounds every probe, so an unreachable endpoint does not hang the diagnostics
+const probeTimeout = 10 * time.Second
+
+type EnvironmentProber interface {
+	DockerVersion(ctx context.Context) (string, error)
+	ComposeVersion(ctx context.Context) (string, error)
+	KubernetesVersion(ctx context.Context, kubeconfig string) (string, error)
+}
+
+type FLEnvironmentProber struct {
+	runtime docker.Runtime
+}
+
+func NewEnvironmentProber(rt docker.Runtime) EnvironmentProber {
+	return &FLEnvironmentProber{runtime: rt}
+}
+
+// DockerVersion returns the version of the engine reachable from the environment (DOCKER_HOST etc.)
+// or at the socket of the runtime
+func (p *FLEnvironmentProber) DockerVersion(ctx context.Context) (string, error) {
+	c, err := docker.NewClient(p.runtime, "")
+	if err != nil {
+		return "", err
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	flDocker := docker.NewDockerClient(c)
+	version, apiVersion, err := flDocker.ServerVersion(ctx)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s (API %s)", version, apiVersion), nil
+}
+
+// ComposeVersion returns the version of the compose command of the runtime
+func (p *FLEnvironmentProber) ComposeVersion(ctx context.Context) (string, error) {
+	sh := &FLDockerShell{Runtime: p.runtime}
+	compose := sh.compose(ctx)
+
+	var out bytes.Buffer
+	if compose == "podman-compose" {
+		// no --short, the first line is "podman-compose version X"
+		if err := runShellCmd(ctx, &out, io.Discard, compose, "version"); err != nil {
+			return "", err
+		}
+		first, _, _ := strings.Cut(strings.TrimSpace(out.String()), "\n")
+		return first, nil
+	}
+
+	if err := runShellCmd(ctx, &out, io.Discard, compose, "version", "--short"); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+// KubernetesVersion returns the version of the cluster the kubeconfig points to
+func (p *FLEnvironmentProber) KubernetesVersion(ctx context.Context, kubeconfig string) (string, error) {
+	path, err := kubeConfigPath(kubeconfig)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); kubeconfig == "" && os.IsNotExist(err) {
+		return "", ErrNoKubeConfig
+	}
+
+	kConfig, err := clientcmd.BuildConfigFromFlags("", path)
+	if err != nil {
+		return "", err
+	}
+	kConfig.Timeout = probeTimeout
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(kConfig)
+	if err != nil {
+		return "", err
+	}
+	version, err := discoveryClient.ServerVersion()
+	if err != nil {
+		return "", err
+	}
+	return version.GitVersion, nil
+}
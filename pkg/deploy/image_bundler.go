@@ -0,0 +1,129 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+
+	"github.com/funlessdev/fl-cli/pkg"
+	"github.com/funlessdev/fl-cli/pkg/docker"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	// DockerComposeURL is the compose file of the local deployment
+	DockerComposeURL = "https://raw.githubusercontent.com/funlessdev/fl-deploy/" + AssetsRef + "/docker-compose/docker-compose.yml"
+	// FilebeatComposeURL is the compose file of the logging services of the local deployment
+	FilebeatComposeURL = "https://raw.githubusercontent.com/funlessdev/fl-deploy/" + AssetsRef + "/docker-compose/filebeat/filebeat.compose.yml"
+)
+
+type ImageBundler interface {
+	Connect() error
+
+	Pull(ctx context.Context, image string) error
+	Save(ctx context.Context, images []string, dest io.Writer) error
+	Load(ctx context.Context, src io.Reader) ([]string, error)
+}
+
+type FLImageBundler struct {
+	runtime  docker.Runtime
+	flDocker *docker.DockerClient
+}
+
+func NewImageBundler(rt docker.Runtime) ImageBundler {
+	return &FLImageBundler{runtime: rt}
+}
+
+// Connect creates the client of the engine of the environment (DOCKER_HOST etc.) or of the runtime
+func (b *FLImageBundler) Connect() error {
+	c, err := docker.NewClient(b.runtime, "")
+	if err != nil {
+		return err
+	}
+	flDocker := docker.NewDockerClient(c)
+	b.flDocker = &flDocker
+	return nil
+}
+
+func (b *FLImageBundler) Pull(ctx context.Context, image string) error {
+	if b.flDocker == nil {
+		return errors.New("image bundler is not connected")
+	}
+	return b.flDocker.Pull(ctx, image)
+}
+
+func (b *FLImageBundler) Save(ctx context.Context, images []string, dest io.Writer) error {
+	if b.flDocker == nil {
+		return errors.New("image bundler is not connected")
+	}
+	return b.flDocker.SaveImages(ctx, images, dest)
+}
+
+func (b *FLImageBundler) Load(ctx context.Context, src io.Reader) ([]string, error) {
+	if b.flDocker == nil {
+		return nil, errors.New("image bundler is not connected")
+	}
+	return b.flDocker.LoadImages(ctx, src)
+}
+
+// BundleImages returns, sorted and without duplicates, the images needed to deploy FunLess with the given
+// core and worker images and to build functions: the images of the compose files and the builder images.
+// The core and worker services of the compose files are skipped, as the deployment replaces their images.
+func BundleImages(core string, worker string, composeFiles ...[]byte) ([]string, error) {
+	images := map[string]bool{core: true, worker: true}
+
+	for _, content := range composeFiles {
+		composeImages, err := ComposeImages(content, "core", "worker")
+		if err != nil {
+			return nil, err
+		}
+		for _, img := range composeImages {
+			images[img] = true
+		}
+	}
+
+	for _, lang := range pkg.SupportedLanguages {
+		if lang.BuilderImage != "" {
+			images[lang.BuilderImage] = true
+		}
+	}
+
+	result := make([]string, 0, len(images))
+	for img := range images {
+		result = append(result, img)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// ComposeImages returns the images used by the services of a compose file, except for the skipped services
+func ComposeImages(content []byte, skip ...string) ([]string, error) {
+	compose, err := ParseCompose(content)
+	if err != nil {
+		return nil, err
+	}
+
+	images := []string{}
+	for _, name := range compose.ServiceNames() {
+		if img := compose.Services[name].Image; img != "" && !slices.Contains(skip, name) {
+			images = append(images, img)
+		}
+	}
+	sort.Strings(images)
+	return images, nil
+}
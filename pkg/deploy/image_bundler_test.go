@@ -0,0 +1,80 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"testing"
+
+	"github.com/funlessdev/fl-cli/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+const testBundleComposeYml = `services:
+  core:
+    image: ghcr.io/funlessdev/core:latest
+  worker:
+    image: ghcr.io/funlessdev/worker:latest
+  postgres:
+    image: postgres:latest
+  prometheus:
+    image: prom/prometheus
+  init:
+    build: .
+`
+
+const testBundleFilebeatYml = `services:
+  kibana:
+    image: kibana:8.6.0
+  elasticsearch:
+    image: elasticsearch:8.6.0
+  prometheus:
+    image: prom/prometheus
+`
+
+func TestComposeImages(t *testing.T) {
+	t.Run("should list the images of the services", func(t *testing.T) {
+		images, err := ComposeImages([]byte(testBundleComposeYml))
+		require.NoError(t, err)
+		require.Equal(t, []string{"ghcr.io/funlessdev/core:latest", "ghcr.io/funlessdev/worker:latest", "postgres:latest", "prom/prometheus"}, images)
+	})
+
+	t.Run("should skip the given services", func(t *testing.T) {
+		images, err := ComposeImages([]byte(testBundleComposeYml), "core", "worker")
+		require.NoError(t, err)
+		require.Equal(t, []string{"postgres:latest", "prom/prometheus"}, images)
+	})
+
+	t.Run("should return error on invalid yaml", func(t *testing.T) {
+		_, err := ComposeImages([]byte("services: ["))
+		require.Error(t, err)
+	})
+}
+
+func TestBundleImages(t *testing.T) {
+	images, err := BundleImages("my-core", "my-worker", []byte(testBundleComposeYml), []byte(testBundleFilebeatYml))
+	require.NoError(t, err)
+
+	expected := []string{
+		"elasticsearch:8.6.0",
+		pkg.SupportedLanguages["js"].BuilderImage,
+		pkg.SupportedLanguages["rust"].BuilderImage,
+		"kibana:8.6.0",
+		"my-core",
+		"my-worker",
+		"postgres:latest",
+		"prom/prometheus",
+	}
+	require.Equal(t, expected, images)
+}
@@ -0,0 +1,141 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	apiAppsV1 "k8s.io/api/apps/v1"
+	apiCoreV1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+type KubernetesBackupper interface {
+	WithConfig(config string) error
+
+	// DumpDatabase writes the dump of the database to out, returning the pod it was taken from as namespace/name
+	DumpDatabase(ctx context.Context, out io.Writer) (string, error)
+	RestoreDatabase(ctx context.Context, in io.Reader) error
+}
+
+type FLKubernetesBackupper struct {
+	kubernetesClientSet kubernetes.Interface
+	restConfig          *rest.Config
+	namespace           string
+}
+
+func NewKubernetesBackupper() KubernetesBackupper {
+	return &FLKubernetesBackupper{namespace: "fl"}
+}
+
+func (k *FLKubernetesBackupper) WithConfig(config string) error {
+	kConfig, clientSet, err := loadKubeConfig(config)
+	if err != nil {
+		return err
+	}
+
+	k.restConfig = kConfig
+	k.kubernetesClientSet = clientSet
+	return nil
+}
+
+func (k *FLKubernetesBackupper) DumpDatabase(ctx context.Context, out io.Writer) (string, error) {
+	return k.execInPostgres(ctx, nil, out, PostgresDumpCmd)
+}
+
+func (k *FLKubernetesBackupper) RestoreDatabase(ctx context.Context, in io.Reader) error {
+	_, err := k.execInPostgres(ctx, in, io.Discard, PostgresRestoreCmd)
+	return err
+}
+
+// execInPostgres runs cmd in the postgres pod, returning the pod as namespace/name
+func (k *FLKubernetesBackupper) execInPostgres(ctx context.Context, stdin io.Reader, stdout io.Writer, cmd string) (string, error) {
+	pod, err := k.postgresPod(ctx)
+	if err != nil {
+		return "", err
+	}
+	podName := pod.Namespace + "/" + pod.Name
+
+	req := k.kubernetesClientSet.CoreV1().RESTClient().Post().Resource("pods").Name(pod.Name).Namespace(k.namespace).SubResource("exec")
+	options := &apiCoreV1.PodExecOptions{
+		Container: pod.Spec.Containers[0].Name,
+		Command:   []string{"sh", "-c", cmd},
+		Stdin:     stdin != nil,
+		Stdout:    true,
+		Stderr:    true,
+		TTY:       false,
+	}
+
+	req.VersionedParams(options, scheme.ParameterCodec)
+
+	exec, err := remotecommand.NewSPDYExecutor(k.restConfig, "POST", req.URL())
+	if err != nil {
+		return "", err
+	}
+
+	var stderr bytes.Buffer
+	err = exec.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdin:  stdin,
+		Stdout: stdout,
+		Stderr: &stderr,
+	})
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", err, stderr.String())
+	}
+	return podName, nil
+}
+
+// postgresPod finds a running pod of the postgres Deployment, using the selector of the upstream manifest
+func (k *FLKubernetesBackupper) postgresPod(ctx context.Context) (*apiCoreV1.Pod, error) {
+	yml, err := getYAMLContent("https://raw.githubusercontent.com/funlessdev/fl-deploy/main/kind/postgres.yml")
+	if err != nil {
+		return nil, err
+	}
+
+	typeMeta := v1.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"}
+	obj, err := ParseKubernetesYAML(yml, &apiAppsV1.Deployment{TypeMeta: typeMeta})
+	if err != nil {
+		return nil, err
+	}
+
+	deployment := obj.(*apiAppsV1.Deployment)
+	if deployment.Spec.Selector == nil {
+		return nil, fmt.Errorf("postgres deployment %s has no selector", deployment.Name)
+	}
+
+	return findRunningPod(ctx, k.kubernetesClientSet, k.namespace, labels.Set(deployment.Spec.Selector.MatchLabels).String())
+}
+
+func findRunningPod(ctx context.Context, clientSet kubernetes.Interface, namespace string, selector string) (*apiCoreV1.Pod, error) {
+	pods, err := clientSet.CoreV1().Pods(namespace).List(ctx, v1.ListOptions{
+		LabelSelector: selector,
+		FieldSelector: "status.phase=Running",
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(pods.Items) == 0 {
+		return nil, fmt.Errorf("no running pods matching %s", selector)
+	}
+	return &pods.Items[0], nil
+}
@@ -0,0 +1,57 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"os"
+	"path/filepath"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// loadKubeConfig builds the rest config and the clientset from the given kubeconfig path,
+// falling back to ~/.kube/config when the path is empty.
+func loadKubeConfig(config string) (*rest.Config, kubernetes.Interface, error) {
+	config, err := kubeConfigPath(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	kConfig, err := clientcmd.BuildConfigFromFlags("", config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	clientSet, err := kubernetes.NewForConfig(kConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return kConfig, clientSet, nil
+}
+
+// kubeConfigPath returns the given kubeconfig path, or ~/.kube/config when it is empty
+func kubeConfigPath(config string) (string, error) {
+	if config != "" {
+		return config, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".kube", "config"), nil
+}
@@ -20,21 +20,20 @@ import (
 	"errors"
 	"io"
 	"net/http"
-	"os"
-	"path/filepath"
+	"sort"
 	"time"
 
 	"github.com/funlessdev/fl-cli/pkg"
 	apiAppsV1 "k8s.io/api/apps/v1"
 	apiBatchV1 "k8s.io/api/batch/v1"
 	apiCoreV1 "k8s.io/api/core/v1"
+	apiNetworkingV1 "k8s.io/api/networking/v1"
 	apiRbacV1 "k8s.io/api/rbac/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/remotecommand"
 )
 
@@ -52,9 +51,11 @@ type KubernetesDeployer interface {
 	DeployPostgresService(ctx context.Context) error
 	StartInitPostgres(ctx context.Context) error
 	CreateCoreSecrets(ctx context.Context) error
-	DeployCore(ctx context.Context) error
+	DeployCore(ctx context.Context, core CoreSpec) error
 	DeployCoreService(ctx context.Context) error
-	DeployWorker(ctx context.Context) error
+	DeployWorker(ctx context.Context, worker WorkerSpec) error
+	CreateSelfSignedTLSSecret(ctx context.Context, name string, host string) ([]byte, error)
+	DeployCoreIngress(ctx context.Context, host string, tlsSecret string, ingressClass string) error
 
 	ExtractTokens(ctx context.Context, stdout *bytes.Buffer, stderr *bytes.Buffer) error
 }
@@ -63,6 +64,7 @@ type FLKubernetesDeployer struct {
 	kubernetesClientSet kubernetes.Interface
 	restConfig          *rest.Config
 	namespace           string
+	coreSecretName      string
 }
 
 func getYAMLContent(url string) ([]byte, error) {
@@ -83,20 +85,7 @@ func NewKubernetesDeployer() KubernetesDeployer {
 }
 
 func (k *FLKubernetesDeployer) WithConfig(config string) error {
-	if config == "" {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return err
-		}
-		config = filepath.Join(home, ".kube", "config")
-	}
-
-	kConfig, err := clientcmd.BuildConfigFromFlags("", config)
-	if err != nil {
-		return err
-	}
-
-	clientSet, err := kubernetes.NewForConfig(kConfig)
+	kConfig, clientSet, err := loadKubeConfig(config)
 	if err != nil {
 		return err
 	}
@@ -291,6 +280,10 @@ func (k *FLKubernetesDeployer) StartInitPostgres(ctx context.Context) error {
 
 	job := obj.(*apiBatchV1.Job)
 
+	if err := k.injectDatabaseEnv(ctx, job.Spec.Template.Spec.Containers); err != nil {
+		return err
+	}
+
 	_, err = k.kubernetesClientSet.BatchV1().Jobs(k.namespace).Create(ctx, job, v1.CreateOptions{})
 
 	return err
@@ -310,17 +303,36 @@ func (k *FLKubernetesDeployer) CreateCoreSecrets(ctx context.Context) error {
 
 	secret := obj.(*apiCoreV1.Secret)
 
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+
 	overrideSecretKeyBase, ok := ctx.Value(pkg.FLContextKey("secret_key_base")).(string)
 	if ok && overrideSecretKeyBase != "" {
 		secret.Data["secret_key_base"] = []byte(overrideSecretKeyBase)
 	}
 
+	databaseURL, ok := ctx.Value(pkg.FLContextKey("database_url")).(string)
+	if ok && databaseURL != "" {
+		databaseEnv, err := DatabaseEnv(databaseURL)
+		if err != nil {
+			return err
+		}
+		for k, v := range databaseEnv {
+			secret.Data[k] = []byte(v)
+		}
+	}
+
 	_, err = k.kubernetesClientSet.CoreV1().Secrets(k.namespace).Create(ctx, secret, v1.CreateOptions{})
+	if err == nil {
+		k.coreSecretName = secret.Name
+	}
 
 	return err
 }
 
-func (k *FLKubernetesDeployer) DeployCore(ctx context.Context) error {
+// DeployCore creates the core Deployment, with the image and resource limits of core when set
+func (k *FLKubernetesDeployer) DeployCore(ctx context.Context, core CoreSpec) error {
 	yml, err := getYAMLContent("https://raw.githubusercontent.com/funlessdev/fl-deploy/main/kind/core.yml")
 	if err != nil {
 		return err
@@ -334,6 +346,14 @@ func (k *FLKubernetesDeployer) DeployCore(ctx context.Context) error {
 
 	deployment := obj.(*apiAppsV1.Deployment)
 
+	if err := k.injectDatabaseEnv(ctx, deployment.Spec.Template.Spec.Containers); err != nil {
+		return err
+	}
+
+	if err := applyComponentSpec(&deployment.Spec.Template.Spec, core.Image, core.Resources); err != nil {
+		return err
+	}
+
 	_, err = k.kubernetesClientSet.AppsV1().Deployments(k.namespace).Create(ctx, deployment, v1.CreateOptions{})
 
 	return err
@@ -358,7 +378,8 @@ func (k *FLKubernetesDeployer) DeployCoreService(ctx context.Context) error {
 	return err
 }
 
-func (k *FLKubernetesDeployer) DeployWorker(ctx context.Context) error {
+// DeployWorker creates the worker DaemonSet, with the image and resource limits of worker when set
+func (k *FLKubernetesDeployer) DeployWorker(ctx context.Context, worker WorkerSpec) error {
 	yml, err := getYAMLContent("https://raw.githubusercontent.com/funlessdev/fl-deploy/main/kind/worker.yml")
 	if err != nil {
 		return err
@@ -372,11 +393,187 @@ func (k *FLKubernetesDeployer) DeployWorker(ctx context.Context) error {
 
 	daemonSet := obj.(*apiAppsV1.DaemonSet)
 
+	if err := applyComponentSpec(&daemonSet.Spec.Template.Spec, worker.Image, worker.Resources); err != nil {
+		return err
+	}
+
 	_, err = k.kubernetesClientSet.AppsV1().DaemonSets(k.namespace).Create(ctx, daemonSet, v1.CreateOptions{})
 
 	return err
 }
 
+// CreateSelfSignedTLSSecret stores a generated certificate for host in the TLS Secret name,
+// returning the PEM certificate for the clients to trust
+func (k *FLKubernetesDeployer) CreateSelfSignedTLSSecret(ctx context.Context, name string, host string) ([]byte, error) {
+	cert, key, err := generateSelfSignedCert(host)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := &apiCoreV1.Secret{
+		TypeMeta:   v1.TypeMeta{Kind: "Secret", APIVersion: "v1"},
+		ObjectMeta: v1.ObjectMeta{Name: name, Namespace: k.namespace},
+		Type:       apiCoreV1.SecretTypeTLS,
+		Data: map[string][]byte{
+			apiCoreV1.TLSCertKey:       cert,
+			apiCoreV1.TLSPrivateKeyKey: key,
+		},
+	}
+
+	if _, err = k.kubernetesClientSet.CoreV1().Secrets(k.namespace).Create(ctx, secret, v1.CreateOptions{}); err != nil {
+		return nil, err
+	}
+
+	return cert, nil
+}
+
+func (k *FLKubernetesDeployer) DeployCoreIngress(ctx context.Context, host string, tlsSecret string, ingressClass string) error {
+	yml, err := getYAMLContent("https://raw.githubusercontent.com/funlessdev/fl-deploy/main/kind/core.yml")
+	if err != nil {
+		return err
+	}
+
+	typeMeta := v1.TypeMeta{Kind: "Service", APIVersion: "v1"}
+	obj, err := ParseKubernetesYAML(yml, &apiCoreV1.Service{TypeMeta: typeMeta})
+	if err != nil {
+		return err
+	}
+
+	service := obj.(*apiCoreV1.Service)
+	if len(service.Spec.Ports) == 0 {
+		return errors.New("the core service exposes no ports")
+	}
+
+	ingress := coreIngress(service.Name, service.Spec.Ports[0].Port, host, tlsSecret, ingressClass)
+	ingress.Namespace = k.namespace
+
+	_, err = k.kubernetesClientSet.NetworkingV1().Ingresses(k.namespace).Create(ctx, ingress, v1.CreateOptions{})
+
+	return err
+}
+
+// applyComponentSpec sets the image and the resource limits of the first container of the pod, when given
+func applyComponentSpec(podSpec *apiCoreV1.PodSpec, image string, resources ResourcesSpec) error {
+	if image != "" {
+		if err := setPodImage(podSpec, image); err != nil {
+			return err
+		}
+	}
+
+	limits, err := resources.KubernetesLimits()
+	if err != nil || len(limits) == 0 {
+		return err
+	}
+	if len(podSpec.Containers) == 0 {
+		return errors.New("pod template has no containers")
+	}
+	container := &podSpec.Containers[0]
+	if container.Resources.Limits == nil {
+		container.Resources.Limits = apiCoreV1.ResourceList{}
+	}
+	for name, q := range limits {
+		container.Resources.Limits[name] = q
+		// requests above the limit are rejected by the API server
+		if request, found := container.Resources.Requests[name]; found && request.Cmp(q) > 0 {
+			container.Resources.Requests[name] = q
+		}
+	}
+	return nil
+}
+
+// injectDatabaseEnv makes the containers read the external database parameters from the core Secret,
+// when an external database url is set in the context.
+func (k *FLKubernetesDeployer) injectDatabaseEnv(ctx context.Context, containers []apiCoreV1.Container) error {
+	databaseURL, ok := ctx.Value(pkg.FLContextKey("database_url")).(string)
+	if !ok || databaseURL == "" {
+		return nil
+	}
+	if k.coreSecretName == "" {
+		return errors.New("the core Secret must be created before using an external database")
+	}
+
+	databaseEnv, err := DatabaseEnv(databaseURL)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(databaseEnv))
+	for name := range databaseEnv {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for i := range containers {
+		for _, name := range names {
+			setContainerEnv(&containers[i], apiCoreV1.EnvVar{
+				Name: name,
+				ValueFrom: &apiCoreV1.EnvVarSource{
+					SecretKeyRef: &apiCoreV1.SecretKeySelector{
+						LocalObjectReference: apiCoreV1.LocalObjectReference{Name: k.coreSecretName},
+						Key:                  name,
+					},
+				},
+			})
+		}
+	}
+	return nil
+}
+
+// setContainerEnv adds the env var to the container, replacing the one with the same name.
+func setContainerEnv(c *apiCoreV1.Container, env apiCoreV1.EnvVar) {
+	for i := range c.Env {
+		if c.Env[i].Name == env.Name {
+			c.Env[i] = env
+			return
+		}
+	}
+	c.Env = append(c.Env, env)
+}
+
+// coreIngress builds an Ingress routing every path of host to the given core service.
+func coreIngress(serviceName string, servicePort int32, host string, tlsSecret string, ingressClass string) *apiNetworkingV1.Ingress {
+	pathType := apiNetworkingV1.PathTypePrefix
+	ingress := &apiNetworkingV1.Ingress{
+		TypeMeta: v1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: v1.ObjectMeta{
+			Name:   serviceName,
+			Labels: map[string]string{"app": "fl-core"},
+		},
+		Spec: apiNetworkingV1.IngressSpec{
+			Rules: []apiNetworkingV1.IngressRule{
+				{
+					Host: host,
+					IngressRuleValue: apiNetworkingV1.IngressRuleValue{
+						HTTP: &apiNetworkingV1.HTTPIngressRuleValue{
+							Paths: []apiNetworkingV1.HTTPIngressPath{
+								{
+									Path:     "/",
+									PathType: &pathType,
+									Backend: apiNetworkingV1.IngressBackend{
+										Service: &apiNetworkingV1.IngressServiceBackend{
+											Name: serviceName,
+											Port: apiNetworkingV1.ServiceBackendPort{Number: servicePort},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if ingressClass != "" {
+		ingress.Spec.IngressClassName = &ingressClass
+	}
+	if tlsSecret != "" {
+		ingress.Spec.TLS = []apiNetworkingV1.IngressTLS{{Hosts: []string{host}, SecretName: tlsSecret}}
+	}
+
+	return ingress
+}
+
 func (k *FLKubernetesDeployer) ExtractTokens(ctx context.Context, stdout *bytes.Buffer, stderr *bytes.Buffer) error {
 
 	corePods, err := k.kubernetesClientSet.CoreV1().Pods(k.namespace).List(ctx, v1.ListOptions{LabelSelector: "app=fl-core"})
@@ -0,0 +1,133 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/funlessdev/fl-cli/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiCoreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_coreIngress(t *testing.T) {
+	t.Run("should route the host to the core service without TLS", func(t *testing.T) {
+		ingress := coreIngress("fl-core", 4000, "fl.example.com", "", "")
+
+		require.Len(t, ingress.Spec.Rules, 1)
+		rule := ingress.Spec.Rules[0]
+		assert.Equal(t, "fl.example.com", rule.Host)
+		backend := rule.HTTP.Paths[0].Backend.Service
+		assert.Equal(t, "fl-core", backend.Name)
+		assert.Equal(t, int32(4000), backend.Port.Number)
+		assert.Empty(t, ingress.Spec.TLS)
+		assert.Nil(t, ingress.Spec.IngressClassName)
+	})
+
+	t.Run("should set TLS and ingress class when given", func(t *testing.T) {
+		ingress := coreIngress("fl-core", 4000, "fl.example.com", "fl-core-tls", "nginx")
+
+		require.Len(t, ingress.Spec.TLS, 1)
+		assert.Equal(t, "fl-core-tls", ingress.Spec.TLS[0].SecretName)
+		assert.Equal(t, []string{"fl.example.com"}, ingress.Spec.TLS[0].Hosts)
+		assert.Equal(t, "nginx", *ingress.Spec.IngressClassName)
+	})
+}
+
+func TestCreateSelfSignedTLSSecret(t *testing.T) {
+	ctx := context.TODO()
+	k := &FLKubernetesDeployer{kubernetesClientSet: fake.NewSimpleClientset(), namespace: "fl"}
+
+	cert, err := k.CreateSelfSignedTLSSecret(ctx, "fl-core-tls", "fl.example.com")
+	require.NoError(t, err)
+
+	secret, err := k.kubernetesClientSet.CoreV1().Secrets("fl").Get(ctx, "fl-core-tls", v1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, apiCoreV1.SecretTypeTLS, secret.Type)
+	assert.NotEmpty(t, secret.Data[apiCoreV1.TLSCertKey])
+	assert.NotEmpty(t, secret.Data[apiCoreV1.TLSPrivateKeyKey])
+	assert.Equal(t, secret.Data[apiCoreV1.TLSCertKey], cert)
+}
+
+func Test_injectDatabaseEnv(t *testing.T) {
+	containers := func() []apiCoreV1.Container {
+		return []apiCoreV1.Container{{Name: "core", Env: []apiCoreV1.EnvVar{{Name: "PGHOST", Value: "postgres"}, {Name: "OTHER", Value: "x"}}}}
+	}
+
+	t.Run("should leave containers untouched without an external database", func(t *testing.T) {
+		k := &FLKubernetesDeployer{namespace: "fl"}
+		c := containers()
+		require.NoError(t, k.injectDatabaseEnv(context.TODO(), c))
+		assert.Equal(t, containers(), c)
+	})
+
+	ctx := context.WithValue(context.TODO(), pkg.FLContextKey("database_url"), "postgres://fl:pw@db:5432/funless")
+
+	t.Run("should return error when the core secret was not created", func(t *testing.T) {
+		k := &FLKubernetesDeployer{namespace: "fl"}
+		require.Error(t, k.injectDatabaseEnv(ctx, containers()))
+	})
+
+	t.Run("should reference the core secret for every database variable", func(t *testing.T) {
+		k := &FLKubernetesDeployer{namespace: "fl", coreSecretName: "fl-core-secret"}
+		c := containers()
+		require.NoError(t, k.injectDatabaseEnv(ctx, c))
+
+		names := []string{}
+		for _, e := range c[0].Env {
+			names = append(names, e.Name)
+			if e.Name == "OTHER" {
+				continue
+			}
+			require.NotNil(t, e.ValueFrom)
+			assert.Equal(t, "fl-core-secret", e.ValueFrom.SecretKeyRef.Name)
+			assert.Equal(t, e.Name, e.ValueFrom.SecretKeyRef.Key)
+		}
+		assert.Equal(t, []string{"PGHOST", "OTHER", "DATABASE_URL", "PGDATABASE", "PGPASSWORD", "PGPORT", "PGUSER"}, names)
+	})
+}
+
+func Test_applyComponentSpec(t *testing.T) {
+	podSpec := func() apiCoreV1.PodSpec {
+		return apiCoreV1.PodSpec{Containers: []apiCoreV1.Container{{
+			Name:  "core",
+			Image: "ghcr.io/funlessdev/core:latest",
+			Resources: apiCoreV1.ResourceRequirements{
+				Requests: apiCoreV1.ResourceList{apiCoreV1.ResourceMemory: resource.MustParse("1Gi")},
+			},
+		}}}
+	}
+
+	t.Run("should keep the upstream settings with an empty spec", func(t *testing.T) {
+		p := podSpec()
+		require.NoError(t, applyComponentSpec(&p, "", ResourcesSpec{}))
+		assert.Equal(t, podSpec(), p)
+	})
+
+	t.Run("should set the image and the limits, lowering the requests above them", func(t *testing.T) {
+		p := podSpec()
+		require.NoError(t, applyComponentSpec(&p, "core:spec", ResourcesSpec{CPU: "1", Memory: "512Mi"}))
+		c := p.Containers[0]
+		assert.Equal(t, "core:spec", c.Image)
+		assert.Equal(t, resource.MustParse("1"), c.Resources.Limits[apiCoreV1.ResourceCPU])
+		assert.Equal(t, resource.MustParse("512Mi"), c.Resources.Limits[apiCoreV1.ResourceMemory])
+		assert.Equal(t, resource.MustParse("512Mi"), c.Resources.Requests[apiCoreV1.ResourceMemory])
+	})
+}
@@ -0,0 +1,105 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	apiCoreV1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// the port exposed by the core container when the pod spec doesn't declare one
+const defaultCorePort = 4000
+
+type KubernetesForwarder interface {
+	WithConfig(config string) error
+
+	ForwardCore(ctx context.Context, localPort int, ready chan struct{}, stdout io.Writer, stderr io.Writer) error
+}
+
+type FLKubernetesForwarder struct {
+	kubernetesClientSet kubernetes.Interface
+	restConfig          *rest.Config
+	namespace           string
+}
+
+func NewKubernetesForwarder() KubernetesForwarder {
+	return &FLKubernetesForwarder{namespace: "fl"}
+}
+
+func (k *FLKubernetesForwarder) WithConfig(config string) error {
+	kConfig, clientSet, err := loadKubeConfig(config)
+	if err != nil {
+		return err
+	}
+
+	k.restConfig = kConfig
+	k.kubernetesClientSet = clientSet
+	return nil
+}
+
+// ForwardCore opens an SPDY port-forward from localPort to a running fl-core pod.
+// The ready channel is closed once the local listener is up; the call blocks until ctx is done.
+func (k *FLKubernetesForwarder) ForwardCore(ctx context.Context, localPort int, ready chan struct{}, stdout io.Writer, stderr io.Writer) error {
+	corePod, err := findRunningPod(ctx, k.kubernetesClientSet, k.namespace, "app=fl-core")
+	if err != nil {
+		return err
+	}
+
+	req := k.kubernetesClientSet.CoreV1().RESTClient().Post().Resource("pods").Name(corePod.Name).Namespace(k.namespace).SubResource("portforward")
+
+	transport, upgrader, err := spdy.RoundTripperFor(k.restConfig)
+	if err != nil {
+		return err
+	}
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, req.URL())
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			close(stop)
+		case <-done:
+		}
+	}()
+
+	ports := []string{fmt.Sprintf("%d:%d", localPort, corePodPort(*corePod))}
+	forwarder, err := portforward.New(dialer, ports, stop, ready, stdout, stderr)
+	if err != nil {
+		return err
+	}
+
+	return forwarder.ForwardPorts()
+}
+
+func corePodPort(pod apiCoreV1.Pod) int32 {
+	for _, c := range pod.Spec.Containers {
+		for _, p := range c.Ports {
+			if p.ContainerPort != 0 {
+				return p.ContainerPort
+			}
+		}
+	}
+	return defaultCorePort
+}
@@ -17,13 +17,10 @@ package deploy
 import (
 	"context"
 	"fmt"
-	"os"
-	"path/filepath"
 
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/clientcmd"
 )
 
 type KubernetesRemover interface {
@@ -43,20 +40,7 @@ func NewKubernetesRemover() KubernetesRemover {
 }
 
 func (k *FLKubernetesRemover) WithConfig(config string) error {
-	if config == "" {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return err
-		}
-		config = filepath.Join(home, ".kube", "config")
-	}
-
-	kConfig, err := clientcmd.BuildConfigFromFlags("", config)
-	if err != nil {
-		return err
-	}
-
-	clientSet, err := kubernetes.NewForConfig(kConfig)
+	_, clientSet, err := loadKubeConfig(config)
 	if err != nil {
 		return err
 	}
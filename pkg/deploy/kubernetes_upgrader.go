@@ -0,0 +1,335 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	apiAppsV1 "k8s.io/api/apps/v1"
+	apiBatchV1 "k8s.io/api/batch/v1"
+	apiCoreV1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+const migrationJobName = "fl-core-migrate"
+
+type KubernetesUpgrader interface {
+	WithConfig(config string) error
+
+	CurrentImages(ctx context.Context) (core string, worker string, err error)
+	RunMigrations(ctx context.Context, coreImage string) error
+	UpgradeCore(ctx context.Context, image string) error
+	UpgradeWorker(ctx context.Context, image string) error
+	RotateSecretKeyBase(ctx context.Context, secretKeyBase string) error
+}
+
+type FLKubernetesUpgrader struct {
+	kubernetesClientSet kubernetes.Interface
+	namespace           string
+	pollInterval        time.Duration
+}
+
+func NewKubernetesUpgrader() KubernetesUpgrader {
+	return &FLKubernetesUpgrader{namespace: "fl", pollInterval: 2 * time.Second}
+}
+
+func (k *FLKubernetesUpgrader) WithConfig(config string) error {
+	_, clientSet, err := loadKubeConfig(config)
+	if err != nil {
+		return err
+	}
+
+	k.kubernetesClientSet = clientSet
+	return nil
+}
+
+// CurrentImages returns the images of the first container of the core Deployment and of the worker DaemonSet
+func (k *FLKubernetesUpgrader) CurrentImages(ctx context.Context) (string, string, error) {
+	coreName, workerName, err := upstreamCoreWorkerNames()
+	if err != nil {
+		return "", "", err
+	}
+
+	core, err := k.kubernetesClientSet.AppsV1().Deployments(k.namespace).Get(ctx, coreName, v1.GetOptions{})
+	if err != nil {
+		return "", "", err
+	}
+	worker, err := k.kubernetesClientSet.AppsV1().DaemonSets(k.namespace).Get(ctx, workerName, v1.GetOptions{})
+	if err != nil {
+		return "", "", err
+	}
+
+	if len(core.Spec.Template.Spec.Containers) == 0 || len(worker.Spec.Template.Spec.Containers) == 0 {
+		return "", "", errors.New("core or worker have no containers")
+	}
+	return core.Spec.Template.Spec.Containers[0].Image, worker.Spec.Template.Spec.Containers[0].Image, nil
+}
+
+// RunMigrations runs the database migrations with the given core image, in a Job sharing
+// the pod spec (env, secrets, service account) of the running core, and waits for it to complete.
+func (k *FLKubernetesUpgrader) RunMigrations(ctx context.Context, coreImage string) error {
+	coreName, _, err := upstreamCoreWorkerNames()
+	if err != nil {
+		return err
+	}
+
+	core, err := k.kubernetesClientSet.AppsV1().Deployments(k.namespace).Get(ctx, coreName, v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	jobs := k.kubernetesClientSet.BatchV1().Jobs(k.namespace)
+
+	// a previous migration job with the same name would make the create fail
+	propagation := v1.DeletePropagationBackground
+	err = jobs.Delete(ctx, migrationJobName, v1.DeleteOptions{PropagationPolicy: &propagation})
+	if err != nil && !k8sErrors.IsNotFound(err) {
+		return err
+	}
+	err = wait.PollImmediateUntilWithContext(ctx, k.pollInterval, func(ctx context.Context) (bool, error) {
+		_, err := jobs.Get(ctx, migrationJobName, v1.GetOptions{})
+		if k8sErrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := jobs.Create(ctx, migrationJob(core, coreImage), v1.CreateOptions{}); err != nil {
+		return err
+	}
+
+	return wait.PollImmediateUntilWithContext(ctx, k.pollInterval, func(ctx context.Context) (bool, error) {
+		job, err := jobs.Get(ctx, migrationJobName, v1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return jobFinished(job)
+	})
+}
+
+// UpgradeCore sets the image of the core Deployment and waits for the rollout to complete
+func (k *FLKubernetesUpgrader) UpgradeCore(ctx context.Context, image string) error {
+	coreName, _, err := upstreamCoreWorkerNames()
+	if err != nil {
+		return err
+	}
+
+	deployments := k.kubernetesClientSet.AppsV1().Deployments(k.namespace)
+	core, err := deployments.Get(ctx, coreName, v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if err := setPodImage(&core.Spec.Template.Spec, image); err != nil {
+		return err
+	}
+	if _, err := deployments.Update(ctx, core, v1.UpdateOptions{}); err != nil {
+		return err
+	}
+
+	return wait.PollImmediateUntilWithContext(ctx, k.pollInterval, func(ctx context.Context) (bool, error) {
+		d, err := deployments.Get(ctx, coreName, v1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return deploymentRolledOut(d), nil
+	})
+}
+
+// UpgradeWorker sets the image of the worker DaemonSet and waits for the rollout to complete
+func (k *FLKubernetesUpgrader) UpgradeWorker(ctx context.Context, image string) error {
+	_, workerName, err := upstreamCoreWorkerNames()
+	if err != nil {
+		return err
+	}
+
+	daemonSets := k.kubernetesClientSet.AppsV1().DaemonSets(k.namespace)
+	worker, err := daemonSets.Get(ctx, workerName, v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if err := setPodImage(&worker.Spec.Template.Spec, image); err != nil {
+		return err
+	}
+	if _, err := daemonSets.Update(ctx, worker, v1.UpdateOptions{}); err != nil {
+		return err
+	}
+
+	return wait.PollImmediateUntilWithContext(ctx, k.pollInterval, func(ctx context.Context) (bool, error) {
+		ds, err := daemonSets.Get(ctx, workerName, v1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return daemonSetRolledOut(ds), nil
+	})
+}
+
+// RotateSecretKeyBase replaces the secret_key_base in the core Secret, then restarts the core
+// and waits for the rollout so that the new key is picked up.
+func (k *FLKubernetesUpgrader) RotateSecretKeyBase(ctx context.Context, secretKeyBase string) error {
+	yml, err := getYAMLContent("https://raw.githubusercontent.com/funlessdev/fl-deploy/main/kind/core-secret-key-base.yml")
+	if err != nil {
+		return err
+	}
+	obj, err := ParseKubernetesYAML(yml, &apiCoreV1.Secret{TypeMeta: v1.TypeMeta{Kind: "Secret", APIVersion: "v1"}})
+	if err != nil {
+		return err
+	}
+	secretName := obj.(*apiCoreV1.Secret).Name
+
+	secrets := k.kubernetesClientSet.CoreV1().Secrets(k.namespace)
+	secret, err := secrets.Get(ctx, secretName, v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	secret.Data["secret_key_base"] = []byte(secretKeyBase)
+	if _, err := secrets.Update(ctx, secret, v1.UpdateOptions{}); err != nil {
+		return err
+	}
+
+	coreName, _, err := upstreamCoreWorkerNames()
+	if err != nil {
+		return err
+	}
+
+	deployments := k.kubernetesClientSet.AppsV1().Deployments(k.namespace)
+	core, err := deployments.Get(ctx, coreName, v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	restartPodTemplate(&core.Spec.Template, time.Now())
+	if _, err := deployments.Update(ctx, core, v1.UpdateOptions{}); err != nil {
+		return err
+	}
+
+	return wait.PollImmediateUntilWithContext(ctx, k.pollInterval, func(ctx context.Context) (bool, error) {
+		d, err := deployments.Get(ctx, coreName, v1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return deploymentRolledOut(d), nil
+	})
+}
+
+// upstreamCoreWorkerNames reads the names of the core Deployment and worker DaemonSet from the upstream manifests
+func upstreamCoreWorkerNames() (string, string, error) {
+	coreYml, err := getYAMLContent("https://raw.githubusercontent.com/funlessdev/fl-deploy/main/kind/core.yml")
+	if err != nil {
+		return "", "", err
+	}
+	core, err := ParseKubernetesYAML(coreYml, &apiAppsV1.Deployment{TypeMeta: v1.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"}})
+	if err != nil {
+		return "", "", err
+	}
+
+	workerYml, err := getYAMLContent("https://raw.githubusercontent.com/funlessdev/fl-deploy/main/kind/worker.yml")
+	if err != nil {
+		return "", "", err
+	}
+	worker, err := ParseKubernetesYAML(workerYml, &apiAppsV1.DaemonSet{TypeMeta: v1.TypeMeta{Kind: "DaemonSet", APIVersion: "apps/v1"}})
+	if err != nil {
+		return "", "", err
+	}
+
+	return core.(*apiAppsV1.Deployment).Name, worker.(*apiAppsV1.DaemonSet).Name, nil
+}
+
+func migrationJob(core *apiAppsV1.Deployment, image string) *apiBatchV1.Job {
+	var backoffLimit int32 = 2
+	var ttl int32 = 600
+
+	podSpec := *core.Spec.Template.Spec.DeepCopy()
+	podSpec.RestartPolicy = apiCoreV1.RestartPolicyNever
+	podSpec.Containers = podSpec.Containers[:1]
+	podSpec.Containers[0].Image = image
+	podSpec.Containers[0].Command = []string{CoreMigrateCmd}
+	podSpec.Containers[0].Args = nil
+	podSpec.Containers[0].Ports = nil
+	podSpec.Containers[0].LivenessProbe = nil
+	podSpec.Containers[0].ReadinessProbe = nil
+	podSpec.Containers[0].StartupProbe = nil
+
+	return &apiBatchV1.Job{
+		TypeMeta:   v1.TypeMeta{Kind: "Job", APIVersion: "batch/v1"},
+		ObjectMeta: v1.ObjectMeta{Name: migrationJobName, Namespace: core.Namespace},
+		Spec: apiBatchV1.JobSpec{
+			BackoffLimit:            &backoffLimit,
+			TTLSecondsAfterFinished: &ttl,
+			Template: apiCoreV1.PodTemplateSpec{
+				ObjectMeta: v1.ObjectMeta{Labels: map[string]string{"app": migrationJobName}},
+				Spec:       podSpec,
+			},
+		},
+	}
+}
+
+// restartPodTemplate triggers a rollout of the template pods, the same way "kubectl rollout restart" does
+func restartPodTemplate(template *apiCoreV1.PodTemplateSpec, now time.Time) {
+	if template.Annotations == nil {
+		template.Annotations = map[string]string{}
+	}
+	template.Annotations["kubectl.kubernetes.io/restartedAt"] = now.Format(time.RFC3339)
+}
+
+func jobFinished(job *apiBatchV1.Job) (bool, error) {
+	for _, c := range job.Status.Conditions {
+		if c.Status != apiCoreV1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case apiBatchV1.JobComplete:
+			return true, nil
+		case apiBatchV1.JobFailed:
+			return false, fmt.Errorf("migration job failed: %s", c.Message)
+		}
+	}
+	return false, nil
+}
+
+func setPodImage(spec *apiCoreV1.PodSpec, image string) error {
+	if len(spec.Containers) == 0 {
+		return errors.New("pod template has no containers")
+	}
+	spec.Containers[0].Image = image
+	return nil
+}
+
+func deploymentRolledOut(d *apiAppsV1.Deployment) bool {
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+	return d.Status.ObservedGeneration >= d.Generation &&
+		d.Status.UpdatedReplicas == replicas &&
+		d.Status.Replicas == replicas &&
+		d.Status.AvailableReplicas == replicas
+}
+
+func daemonSetRolledOut(ds *apiAppsV1.DaemonSet) bool {
+	return ds.Status.ObservedGeneration >= ds.Generation &&
+		ds.Status.UpdatedNumberScheduled == ds.Status.DesiredNumberScheduled &&
+		ds.Status.NumberAvailable == ds.Status.DesiredNumberScheduled
+}
@@ -0,0 +1,114 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	apiAppsV1 "k8s.io/api/apps/v1"
+	apiBatchV1 "k8s.io/api/batch/v1"
+	apiCoreV1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testCoreDeployment() *apiAppsV1.Deployment {
+	return &apiAppsV1.Deployment{
+		ObjectMeta: v1.ObjectMeta{Name: "fl-core", Namespace: "fl"},
+		Spec: apiAppsV1.DeploymentSpec{
+			Template: apiCoreV1.PodTemplateSpec{
+				Spec: apiCoreV1.PodSpec{
+					ServiceAccountName: "fl-svc-account",
+					Containers: []apiCoreV1.Container{
+						{
+							Name:           "fl-core",
+							Image:          "core:old",
+							Ports:          []apiCoreV1.ContainerPort{{ContainerPort: 4000}},
+							Env:            []apiCoreV1.EnvVar{{Name: "PGHOST", Value: "postgres"}},
+							ReadinessProbe: &apiCoreV1.Probe{},
+						},
+						{Name: "sidecar", Image: "sidecar"},
+					},
+				},
+			},
+		},
+	}
+}
+
+func Test_migrationJob(t *testing.T) {
+	core := testCoreDeployment()
+	job := migrationJob(core, "core:new")
+
+	require.Equal(t, migrationJobName, job.Name)
+	require.Equal(t, "fl", job.Namespace)
+
+	spec := job.Spec.Template.Spec
+	require.Equal(t, apiCoreV1.RestartPolicyNever, spec.RestartPolicy)
+	require.Equal(t, "fl-svc-account", spec.ServiceAccountName)
+	require.Len(t, spec.Containers, 1)
+	require.Equal(t, "core:new", spec.Containers[0].Image)
+	require.Equal(t, []string{CoreMigrateCmd}, spec.Containers[0].Command)
+	require.Equal(t, core.Spec.Template.Spec.Containers[0].Env, spec.Containers[0].Env)
+	require.Nil(t, spec.Containers[0].Ports)
+	require.Nil(t, spec.Containers[0].ReadinessProbe)
+
+	// the running deployment is left untouched
+	require.Equal(t, "core:old", core.Spec.Template.Spec.Containers[0].Image)
+	require.Len(t, core.Spec.Template.Spec.Containers, 2)
+}
+
+func Test_jobFinished(t *testing.T) {
+	job := &apiBatchV1.Job{}
+	done, err := jobFinished(job)
+	require.NoError(t, err)
+	require.False(t, done)
+
+	job.Status.Conditions = []apiBatchV1.JobCondition{{Type: apiBatchV1.JobComplete, Status: apiCoreV1.ConditionTrue}}
+	done, err = jobFinished(job)
+	require.NoError(t, err)
+	require.True(t, done)
+
+	job.Status.Conditions = []apiBatchV1.JobCondition{{Type: apiBatchV1.JobFailed, Status: apiCoreV1.ConditionTrue, Message: "BackoffLimitExceeded"}}
+	_, err = jobFinished(job)
+	require.ErrorContains(t, err, "BackoffLimitExceeded")
+}
+
+func Test_rolledOut(t *testing.T) {
+	var replicas int32 = 2
+	d := testCoreDeployment()
+	d.Generation = 2
+	d.Spec.Replicas = &replicas
+	d.Status = apiAppsV1.DeploymentStatus{ObservedGeneration: 2, Replicas: 3, UpdatedReplicas: 1, AvailableReplicas: 2}
+	require.False(t, deploymentRolledOut(d))
+
+	d.Status = apiAppsV1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2}
+	require.True(t, deploymentRolledOut(d))
+
+	ds := &apiAppsV1.DaemonSet{ObjectMeta: v1.ObjectMeta{Generation: 3}}
+	ds.Status = apiAppsV1.DaemonSetStatus{ObservedGeneration: 2, DesiredNumberScheduled: 1, UpdatedNumberScheduled: 1, NumberAvailable: 1}
+	require.False(t, daemonSetRolledOut(ds))
+
+	ds.Status.ObservedGeneration = 3
+	require.True(t, daemonSetRolledOut(ds))
+}
+
+func Test_restartPodTemplate(t *testing.T) {
+	template := &apiCoreV1.PodTemplateSpec{}
+	now := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	restartPodTemplate(template, now)
+	require.Equal(t, "2023-05-01T10:00:00Z", template.Annotations["kubectl.kubernetes.io/restartedAt"])
+}
@@ -0,0 +1,154 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/funlessdev/fl-cli/pkg/homedir"
+)
+
+const (
+	// RegistryFileName is the file in the config dir recording the known deployments
+	RegistryFileName = "deployments.json"
+
+	// AssetsRef is the fl-deploy branch the deployment assets are downloaded from
+	AssetsRef = "main"
+
+	// DockerProject is the compose project of the local deployment
+	DockerProject = "fl"
+	// KubernetesNamespace is the namespace of the kubernetes deployment
+	KubernetesNamespace = "fl"
+)
+
+// Deployment is a FunLess deployment created with fl, as recorded in the registry.
+type Deployment struct {
+	Backend     string    `json:"backend"`
+	Project     string    `json:"project,omitempty"`
+	DockerHost  string    `json:"docker_host,omitempty"`
+	Namespace   string    `json:"namespace,omitempty"`
+	KubeConfig  string    `json:"kubeconfig,omitempty"`
+	CoreImage   string    `json:"core_image"`
+	WorkerImage string    `json:"worker_image"`
+	AssetsRef   string    `json:"assets_ref"`
+	Host        string    `json:"host,omitempty"`
+	PortOffset  int       `json:"port_offset,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ID identifies a deployment in the registry: the backend with its compose project or namespace.
+func (d Deployment) ID() string {
+	if d.Backend == "kubernetes" {
+		return d.Backend + "/" + d.Namespace
+	}
+	return d.Backend + "/" + d.Project
+}
+
+type Registry struct {
+	Deployments []Deployment `json:"deployments"`
+}
+
+// LoadRegistry reads the registry from the config dir, an empty one is returned when it doesn't exist yet.
+func LoadRegistry() (*Registry, error) {
+	content, _, err := homedir.ReadFromConfigDir(RegistryFileName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Registry{}, nil
+		}
+		return nil, err
+	}
+
+	var registry Registry
+	if err := json.Unmarshal(content, &registry); err != nil {
+		return nil, err
+	}
+	return &registry, nil
+}
+
+func (r *Registry) Save() error {
+	sort.Slice(r.Deployments, func(i, j int) bool {
+		return r.Deployments[i].ID() < r.Deployments[j].ID()
+	})
+
+	content, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = homedir.WriteToConfigDir(RegistryFileName, append(content, '\n'), true)
+	return err
+}
+
+// Find returns the deployment with the given ID, if recorded.
+func (r *Registry) Find(id string) (Deployment, bool) {
+	for _, d := range r.Deployments {
+		if d.ID() == id {
+			return d, true
+		}
+	}
+	return Deployment{}, false
+}
+
+// Record adds the deployment or replaces the one with the same ID, keeping its creation time.
+func (r *Registry) Record(d Deployment, now time.Time) {
+	d.UpdatedAt = now
+	for i, existing := range r.Deployments {
+		if existing.ID() == d.ID() {
+			d.CreatedAt = existing.CreatedAt
+			r.Deployments[i] = d
+			return
+		}
+	}
+	d.CreatedAt = now
+	r.Deployments = append(r.Deployments, d)
+}
+
+// Remove deletes the deployment with the given ID, reporting whether it was recorded.
+func (r *Registry) Remove(id string) bool {
+	for i, d := range r.Deployments {
+		if d.ID() == id {
+			r.Deployments = append(r.Deployments[:i], r.Deployments[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateRegistry loads the registry, applies update and saves it back.
+func UpdateRegistry(update func(r *Registry)) error {
+	registry, err := LoadRegistry()
+	if err != nil {
+		return err
+	}
+	update(registry)
+	return registry.Save()
+}
+
+// RecordDeployment records the deployment in the registry of the config dir.
+func RecordDeployment(d Deployment) error {
+	return UpdateRegistry(func(r *Registry) {
+		r.Record(d, time.Now().UTC())
+	})
+}
+
+// ForgetDeployment removes the deployment with the given ID from the registry of the config dir.
+func ForgetDeployment(id string) error {
+	return UpdateRegistry(func(r *Registry) {
+		r.Remove(id)
+	})
+}
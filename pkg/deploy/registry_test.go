@@ -0,0 +1,90 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/funlessdev/fl-cli/pkg/homedir"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	homedirPath, err := os.MkdirTemp("", "funless-test-homedir-")
+	require.NoError(t, err)
+
+	homedir.GetHomeDir = func() (string, error) {
+		return homedirPath, nil
+	}
+	defer func() {
+		homedir.GetHomeDir = os.UserHomeDir
+		os.RemoveAll(homedirPath)
+	}()
+
+	created := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	updated := created.Add(time.Hour)
+
+	t.Run("should be empty when the file does not exist", func(t *testing.T) {
+		registry, err := LoadRegistry()
+		require.NoError(t, err)
+		require.Empty(t, registry.Deployments)
+	})
+
+	t.Run("should record and reload deployments", func(t *testing.T) {
+		registry, err := LoadRegistry()
+		require.NoError(t, err)
+
+		registry.Record(Deployment{Backend: "kubernetes", Namespace: "fl", KubeConfig: "/kube/config"}, created)
+		registry.Record(Deployment{Backend: "docker", Project: "fl", CoreImage: "core:1"}, created)
+		require.NoError(t, registry.Save())
+
+		registry, err = LoadRegistry()
+		require.NoError(t, err)
+		require.Len(t, registry.Deployments, 2)
+		require.Equal(t, "docker/fl", registry.Deployments[0].ID())
+		require.Equal(t, "kubernetes/fl", registry.Deployments[1].ID())
+
+		d, found := registry.Find("kubernetes/fl")
+		require.True(t, found)
+		require.Equal(t, "/kube/config", d.KubeConfig)
+		require.True(t, created.Equal(d.CreatedAt))
+	})
+
+	t.Run("should keep the creation time when recording again", func(t *testing.T) {
+		registry, err := LoadRegistry()
+		require.NoError(t, err)
+
+		registry.Record(Deployment{Backend: "docker", Project: "fl", CoreImage: "core:2"}, updated)
+
+		d, found := registry.Find("docker/fl")
+		require.True(t, found)
+		require.Equal(t, "core:2", d.CoreImage)
+		require.True(t, created.Equal(d.CreatedAt))
+		require.True(t, updated.Equal(d.UpdatedAt))
+		require.Len(t, registry.Deployments, 2)
+	})
+
+	t.Run("should forget removed deployments", func(t *testing.T) {
+		require.NoError(t, ForgetDeployment("docker/fl"))
+
+		registry, err := LoadRegistry()
+		require.NoError(t, err)
+		_, found := registry.Find("docker/fl")
+		require.False(t, found)
+		require.False(t, registry.Remove("docker/fl"))
+	})
+}
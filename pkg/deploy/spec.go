@@ -0,0 +1,248 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v2"
+	apiCoreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	BackendDocker     = "docker"
+	BackendKubernetes = "kubernetes"
+)
+
+// Spec describes a FunLess deployment independently of the backend, as read from a deploy.yaml file.
+// Empty fields keep the defaults of the backend.
+type Spec struct {
+	Core          CoreSpec          `yaml:"core"`
+	Worker        WorkerSpec        `yaml:"worker"`
+	Database      DatabaseSpec      `yaml:"database"`
+	Observability ObservabilitySpec `yaml:"observability"`
+	Exposure      ExposureSpec      `yaml:"exposure"`
+}
+
+type CoreSpec struct {
+	Image     string        `yaml:"image"`
+	Resources ResourcesSpec `yaml:"resources"`
+}
+
+type WorkerSpec struct {
+	Image     string        `yaml:"image"`
+	Replicas  int           `yaml:"replicas"`
+	Resources ResourcesSpec `yaml:"resources"`
+}
+
+// ResourcesSpec holds the limits of a component, as Kubernetes quantities (e.g. cpu: 500m, memory: 512Mi)
+type ResourcesSpec struct {
+	CPU    string `yaml:"cpu"`
+	Memory string `yaml:"memory"`
+}
+
+type DatabaseSpec struct {
+	URL string `yaml:"url"`
+}
+
+// ObservabilitySpec lists the stacks to deploy (see Stacks). When unset the defaults are deployed.
+type ObservabilitySpec struct {
+	Stacks []string `yaml:"stacks"`
+}
+
+type ExposureSpec struct {
+	Port         int     `yaml:"port"`
+	Host         string  `yaml:"host"`
+	IngressClass string  `yaml:"ingress_class"`
+	TLS          TLSSpec `yaml:"tls"`
+}
+
+type TLSSpec struct {
+	Secret     string `yaml:"secret"`
+	SelfSigned bool   `yaml:"self_signed"`
+}
+
+// Deployer stands up the deployment described by a Spec on a backend
+type Deployer interface {
+	Backend() string
+	Deploy(ctx context.Context, spec Spec) error
+}
+
+// LoadSpec reads and validates a deployment spec, failing on unknown keys
+func LoadSpec(path string) (Spec, error) {
+	var spec Spec
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return spec, err
+	}
+	if err := yaml.UnmarshalStrict(content, &spec); err != nil {
+		return spec, fmt.Errorf("invalid deployment spec %s: %w", path, err)
+	}
+	if err := spec.Validate(); err != nil {
+		return spec, fmt.Errorf("invalid deployment spec %s: %w", path, err)
+	}
+	return spec, nil
+}
+
+// ApplySpec validates the spec, checks that the backend of the deployer supports all of it and deploys it
+func ApplySpec(ctx context.Context, deployer Deployer, spec Spec) error {
+	if err := spec.Validate(); err != nil {
+		return err
+	}
+	if unsupported := spec.Unsupported(deployer.Backend()); len(unsupported) > 0 {
+		return fmt.Errorf("the %s backend does not support %s", deployer.Backend(), strings.Join(unsupported, ", "))
+	}
+	return deployer.Deploy(ctx, spec)
+}
+
+// Validate checks the values of the spec, regardless of the backend
+func (s Spec) Validate() error {
+	if s.Worker.Replicas < 0 {
+		return fmt.Errorf("worker.replicas must not be negative, got %d", s.Worker.Replicas)
+	}
+	if err := s.Core.Resources.validate("core"); err != nil {
+		return err
+	}
+	if err := s.Worker.Resources.validate("worker"); err != nil {
+		return err
+	}
+	if s.Database.URL != "" {
+		if _, err := DatabaseEnv(s.Database.URL); err != nil {
+			return err
+		}
+	}
+	if s.Observability.Stacks != nil {
+		if _, err := ResolveStacks(s.Observability.Stacks, excluded(s.Observability.Stacks)); err != nil {
+			return err
+		}
+	}
+	if s.Exposure.Port < 0 || s.Exposure.Port > 65535 {
+		return fmt.Errorf("exposure.port must be between 1 and 65535, got %d", s.Exposure.Port)
+	}
+	tls := s.Exposure.TLS
+	if tls.Secret != "" && tls.SelfSigned {
+		return errors.New("exposure.tls.secret and exposure.tls.self_signed are mutually exclusive")
+	}
+	if s.Exposure.Host == "" && (tls.Secret != "" || tls.SelfSigned || s.Exposure.IngressClass != "") {
+		return errors.New("exposure.tls and exposure.ingress_class require exposure.host")
+	}
+	return nil
+}
+
+// Unsupported returns the settings of the spec the backend cannot apply
+func (s Spec) Unsupported(backend string) []string {
+	unsupported := []string{}
+	switch backend {
+	case BackendDocker:
+		if s.Exposure.Host != "" {
+			unsupported = append(unsupported, "exposure.host (the core is published on the Docker host, see exposure.port)")
+		}
+		if s.Exposure.IngressClass != "" {
+			unsupported = append(unsupported, "exposure.ingress_class")
+		}
+		if s.Exposure.TLS != (TLSSpec{}) {
+			unsupported = append(unsupported, "exposure.tls")
+		}
+	case BackendKubernetes:
+		if s.Worker.Replicas > 0 {
+			unsupported = append(unsupported, "worker.replicas (the workers run as a DaemonSet, one per node)")
+		}
+		if s.Exposure.Port != 0 {
+			unsupported = append(unsupported, "exposure.port (the core is exposed through exposure.host)")
+		}
+		if s.Observability.Stacks != nil && !slices.Equal(s.Observability.Stacks, []string{StackMetrics}) {
+			unsupported = append(unsupported, fmt.Sprintf("observability.stacks other than %s (always deployed)", StackMetrics))
+		}
+	default:
+		unsupported = append(unsupported, "any setting (unknown backend)")
+	}
+	return unsupported
+}
+
+// excluded returns the default stacks missing from stacks, so that ResolveStacks yields exactly stacks
+func excluded(stacks []string) []string {
+	without := []string{}
+	for _, s := range DefaultStacks {
+		if !slices.Contains(stacks, s) {
+			without = append(without, s)
+		}
+	}
+	return without
+}
+
+// ResolvedStacks returns the stacks of the spec in the order of Stacks
+func (o ObservabilitySpec) ResolvedStacks() ([]string, error) {
+	return ResolveStacks(o.Stacks, excluded(o.Stacks))
+}
+
+func (r ResourcesSpec) validate(component string) error {
+	if r.CPU != "" {
+		if q, err := resource.ParseQuantity(r.CPU); err != nil || q.Sign() <= 0 {
+			return fmt.Errorf("invalid %s.resources.cpu %q: expected a positive quantity, e.g. 500m or 2", component, r.CPU)
+		}
+	}
+	if r.Memory != "" {
+		if q, err := resource.ParseQuantity(r.Memory); err != nil || q.Sign() <= 0 {
+			return fmt.Errorf("invalid %s.resources.memory %q: expected a positive quantity, e.g. 512Mi or 2Gi", component, r.Memory)
+		}
+	}
+	return nil
+}
+
+// KubernetesLimits returns the limits as a Kubernetes resource list
+func (r ResourcesSpec) KubernetesLimits() (apiCoreV1.ResourceList, error) {
+	limits := apiCoreV1.ResourceList{}
+	if r.CPU != "" {
+		q, err := resource.ParseQuantity(r.CPU)
+		if err != nil {
+			return nil, err
+		}
+		limits[apiCoreV1.ResourceCPU] = q
+	}
+	if r.Memory != "" {
+		q, err := resource.ParseQuantity(r.Memory)
+		if err != nil {
+			return nil, err
+		}
+		limits[apiCoreV1.ResourceMemory] = q
+	}
+	return limits, nil
+}
+
+// ComposeLimits returns the limits in the compose format: cpus as a decimal number of cores, memory in bytes
+func (r ResourcesSpec) ComposeLimits() (map[string]string, error) {
+	limits := map[string]string{}
+	if r.CPU != "" {
+		q, err := resource.ParseQuantity(r.CPU)
+		if err != nil {
+			return nil, err
+		}
+		limits["cpus"] = strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.3f", float64(q.MilliValue())/1000), "0"), ".")
+	}
+	if r.Memory != "" {
+		q, err := resource.ParseQuantity(r.Memory)
+		if err != nil {
+			return nil, err
+		}
+		limits["memory"] = fmt.Sprintf("%d", q.Value())
+	}
+	return limits, nil
+}
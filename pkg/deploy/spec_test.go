@@ -0,0 +1,164 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	apiCoreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const testSpecYml = `core:
+  image: core:spec
+  resources:
+    cpu: 500m
+    memory: 512Mi
+worker:
+  image: worker:spec
+  replicas: 2
+database:
+  url: postgres://fl:pw@db:5432/funless
+observability:
+  stacks: [metrics, dashboards]
+exposure:
+  port: 4100
+`
+
+type testDeployer struct {
+	backend  string
+	deployed *Spec
+}
+
+func (d *testDeployer) Backend() string {
+	return d.backend
+}
+
+func (d *testDeployer) Deploy(ctx context.Context, spec Spec) error {
+	d.deployed = &spec
+	return nil
+}
+
+func writeTestSpec(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "deploy.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoadSpec(t *testing.T) {
+	t.Run("should read every section", func(t *testing.T) {
+		spec, err := LoadSpec(writeTestSpec(t, testSpecYml))
+		require.NoError(t, err)
+		require.Equal(t, "core:spec", spec.Core.Image)
+		require.Equal(t, ResourcesSpec{CPU: "500m", Memory: "512Mi"}, spec.Core.Resources)
+		require.Equal(t, 2, spec.Worker.Replicas)
+		require.Equal(t, "postgres://fl:pw@db:5432/funless", spec.Database.URL)
+		require.Equal(t, []string{StackMetrics, StackDashboards}, spec.Observability.Stacks)
+		require.Equal(t, 4100, spec.Exposure.Port)
+	})
+
+	t.Run("should reject unknown keys", func(t *testing.T) {
+		_, err := LoadSpec(writeTestSpec(t, "worker:\n  count: 2\n"))
+		require.ErrorContains(t, err, "field count not found")
+	})
+
+	t.Run("should reject invalid values", func(t *testing.T) {
+		_, err := LoadSpec(writeTestSpec(t, "core:\n  resources:\n    memory: lots\n"))
+		require.ErrorContains(t, err, "invalid core.resources.memory")
+	})
+
+	t.Run("should return error when the file is missing", func(t *testing.T) {
+		_, err := LoadSpec(filepath.Join(t.TempDir(), "deploy.yaml"))
+		require.True(t, os.IsNotExist(err))
+	})
+}
+
+func TestSpecValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		spec Spec
+		err  string
+	}{
+		{name: "empty", spec: Spec{}},
+		{name: "negative replicas", spec: Spec{Worker: WorkerSpec{Replicas: -1}}, err: "worker.replicas"},
+		{name: "invalid cpu", spec: Spec{Worker: WorkerSpec{Resources: ResourcesSpec{CPU: "-1"}}}, err: "invalid worker.resources.cpu"},
+		{name: "invalid database", spec: Spec{Database: DatabaseSpec{URL: "mysql://db"}}, err: "postgres"},
+		{name: "unknown stack", spec: Spec{Observability: ObservabilitySpec{Stacks: []string{"tracing"}}}, err: `unknown stack "tracing"`},
+		{name: "dashboards without metrics", spec: Spec{Observability: ObservabilitySpec{Stacks: []string{StackDashboards}}}, err: "needs the metrics stack"},
+		{name: "invalid port", spec: Spec{Exposure: ExposureSpec{Port: 70000}}, err: "exposure.port"},
+		{name: "tls without host", spec: Spec{Exposure: ExposureSpec{TLS: TLSSpec{SelfSigned: true}}}, err: "require exposure.host"},
+		{name: "both tls", spec: Spec{Exposure: ExposureSpec{Host: "fl.example.com", TLS: TLSSpec{Secret: "s", SelfSigned: true}}}, err: "mutually exclusive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.spec.Validate()
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestSpecUnsupported(t *testing.T) {
+	ingress := Spec{Exposure: ExposureSpec{Host: "fl.example.com", IngressClass: "nginx", TLS: TLSSpec{SelfSigned: true}}}
+	require.Len(t, ingress.Unsupported(BackendDocker), 3)
+	require.Empty(t, ingress.Unsupported(BackendKubernetes))
+
+	local := Spec{Worker: WorkerSpec{Replicas: 2}, Exposure: ExposureSpec{Port: 4100}, Observability: ObservabilitySpec{Stacks: []string{StackLogging, StackMetrics}}}
+	require.Empty(t, local.Unsupported(BackendDocker))
+	require.Len(t, local.Unsupported(BackendKubernetes), 3)
+
+	metrics := Spec{Observability: ObservabilitySpec{Stacks: []string{StackMetrics}}}
+	require.Empty(t, metrics.Unsupported(BackendKubernetes))
+}
+
+func TestApplySpec(t *testing.T) {
+	t.Run("should deploy a supported spec", func(t *testing.T) {
+		d := &testDeployer{backend: BackendDocker}
+		spec := Spec{Worker: WorkerSpec{Replicas: 2}}
+		require.NoError(t, ApplySpec(context.TODO(), d, spec))
+		require.Equal(t, &spec, d.deployed)
+	})
+
+	t.Run("should report the unsupported settings without deploying", func(t *testing.T) {
+		d := &testDeployer{backend: BackendKubernetes}
+		err := ApplySpec(context.TODO(), d, Spec{Worker: WorkerSpec{Replicas: 2}})
+		require.ErrorContains(t, err, "the kubernetes backend does not support worker.replicas")
+		require.Nil(t, d.deployed)
+	})
+}
+
+func TestResourcesSpecLimits(t *testing.T) {
+	r := ResourcesSpec{CPU: "1500m", Memory: "512Mi"}
+
+	compose, err := r.ComposeLimits()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"cpus": "1.5", "memory": "536870912"}, compose)
+
+	k8s, err := r.KubernetesLimits()
+	require.NoError(t, err)
+	require.Equal(t, resource.MustParse("1500m"), k8s[apiCoreV1.ResourceCPU])
+	require.Equal(t, resource.MustParse("512Mi"), k8s[apiCoreV1.ResourceMemory])
+
+	empty, err := ResourcesSpec{}.ComposeLimits()
+	require.NoError(t, err)
+	require.Empty(t, empty)
+}
@@ -0,0 +1,89 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+const (
+	// StackLogging is the Elasticsearch, Kibana and Filebeat stack of the filebeat compose file
+	StackLogging = "logging"
+	// StackMetrics is the Prometheus service of the compose file
+	StackMetrics = "metrics"
+	// StackDashboards is a Grafana service with the FunLess dashboard, reading from Prometheus
+	StackDashboards = "dashboards"
+
+	// PrometheusService is the service of the metrics stack in the compose file
+	PrometheusService = "prometheus"
+	// GrafanaDir is the directory, next to the compose file, holding the Grafana provisioning files
+	GrafanaDir = "grafana"
+)
+
+// Stacks are the optional stacks of the local deployment, each started through the compose profile of the same name
+var Stacks = []string{StackLogging, StackMetrics, StackDashboards}
+
+// DefaultStacks are the stacks deployed when none is asked for or excluded
+var DefaultStacks = []string{StackMetrics}
+
+//go:embed stacks/grafana.compose.yml
+var GrafanaComposeYml []byte
+
+//go:embed stacks/grafana
+var grafanaAssets embed.FS
+
+// ResolveStacks returns the default stacks plus with and minus without, in the order of Stacks.
+func ResolveStacks(with []string, without []string) ([]string, error) {
+	for _, s := range append(append([]string{}, with...), without...) {
+		if !slices.Contains(Stacks, s) {
+			return nil, fmt.Errorf("unknown stack %q: use %s", s, strings.Join(Stacks, ", "))
+		}
+		if slices.Contains(with, s) && slices.Contains(without, s) {
+			return nil, fmt.Errorf("stack %q is both included and excluded", s)
+		}
+	}
+
+	enabled := []string{}
+	for _, s := range Stacks {
+		if (slices.Contains(DefaultStacks, s) || slices.Contains(with, s)) && !slices.Contains(without, s) {
+			enabled = append(enabled, s)
+		}
+	}
+
+	if slices.Contains(enabled, StackDashboards) && !slices.Contains(enabled, StackMetrics) {
+		return nil, fmt.Errorf("the %s stack reads from Prometheus, it needs the %s stack", StackDashboards, StackMetrics)
+	}
+	return enabled, nil
+}
+
+// WalkGrafanaAssets calls write with the path, relative to GrafanaDir, and the content of every Grafana provisioning file
+func WalkGrafanaAssets(write func(path string, content []byte) error) error {
+	root := "stacks/" + GrafanaDir
+	return fs.WalkDir(grafanaAssets, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := grafanaAssets.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		return write(strings.TrimPrefix(p, root+"/"), content)
+	})
+}
@@ -0,0 +1,105 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveStacks(t *testing.T) {
+	tests := []struct {
+		name    string
+		with    []string
+		without []string
+		want    []string
+		err     string
+	}{
+		{name: "defaults", want: []string{StackMetrics}},
+		{name: "with", with: []string{StackDashboards, StackLogging}, want: []string{StackLogging, StackMetrics, StackDashboards}},
+		{name: "without", without: []string{StackMetrics}, want: []string{}},
+		{name: "unknown", with: []string{"tracing"}, err: `unknown stack "tracing"`},
+		{name: "both", with: []string{StackLogging}, without: []string{StackLogging}, err: "both included and excluded"},
+		{name: "dashboards without metrics", with: []string{StackDashboards}, without: []string{StackMetrics}, err: "needs the metrics stack"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveStacks(tt.with, tt.without)
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWalkGrafanaAssets(t *testing.T) {
+	files := map[string][]byte{}
+	err := WalkGrafanaAssets(func(path string, content []byte) error {
+		files[path] = content
+		return nil
+	})
+	require.NoError(t, err)
+	require.Contains(t, files, "provisioning/datasources/prometheus.yml")
+	require.Contains(t, files, "provisioning/dashboards/funless.yml")
+	require.Contains(t, files, "dashboards/funless.json")
+
+	// the compose file mounts the provisioning files
+	stack, err := ParseCompose(GrafanaComposeYml)
+	require.NoError(t, err)
+	grafana, err := stack.Service("grafana")
+	require.NoError(t, err)
+	for _, v := range grafana.Volumes {
+		require.Contains(t, v.Source, GrafanaDir+"/")
+	}
+}
+
+func TestComposeAddStack(t *testing.T) {
+	compose := parseTestCompose(t)
+	compose.PortOffset = 2
+
+	stack, err := ParseCompose([]byte(`services:
+  kibana:
+    image: kibana:8.6.0
+    ports:
+      - "5601:5601"
+    volumes:
+      - ./kibana.yml:/usr/share/kibana/config/kibana.yml
+      - esdata:/usr/share/elasticsearch/data
+volumes:
+  esdata:
+networks:
+  logs:
+`))
+	require.NoError(t, err)
+
+	compose.AddStack(stack, StackLogging, "filebeat")
+
+	kibana, err := compose.Service("kibana")
+	require.NoError(t, err)
+	require.Equal(t, []string{StackLogging}, kibana.Profiles)
+	require.Equal(t, "5603:5601", kibana.Ports[0].String())
+	require.Equal(t, "./filebeat/kibana.yml", kibana.Volumes[0].Source)
+	require.Equal(t, "esdata", kibana.Volumes[1].Source)
+
+	out, err := compose.Marshal()
+	require.NoError(t, err)
+	require.Contains(t, string(out), "pgdata:")
+	require.Contains(t, string(out), "esdata:")
+	require.Contains(t, string(out), "logs:")
+}
@@ -0,0 +1,56 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_generateSelfSignedCert(t *testing.T) {
+	t.Run("should return error when host is empty", func(t *testing.T) {
+		_, _, err := generateSelfSignedCert("")
+		require.Error(t, err)
+	})
+
+	t.Run("should generate a valid key pair for a DNS host", func(t *testing.T) {
+		certPEM, keyPEM, err := generateSelfSignedCert("fl.example.com")
+		require.NoError(t, err)
+
+		_, err = tls.X509KeyPair(certPEM, keyPEM)
+		require.NoError(t, err)
+
+		block, _ := pem.Decode(certPEM)
+		cert, err := x509.ParseCertificate(block.Bytes)
+		require.NoError(t, err)
+		assert.NoError(t, cert.VerifyHostname("fl.example.com"))
+	})
+
+	t.Run("should use IP SANs when host is an IP address", func(t *testing.T) {
+		certPEM, _, err := generateSelfSignedCert("10.0.0.1")
+		require.NoError(t, err)
+
+		block, _ := pem.Decode(certPEM)
+		cert, err := x509.ParseCertificate(block.Bytes)
+		require.NoError(t, err)
+		assert.Len(t, cert.IPAddresses, 1)
+		assert.Empty(t, cert.DNSNames)
+	})
+}
@@ -0,0 +1,237 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/funlessdev/fl-cli/pkg"
+	"github.com/funlessdev/fl-cli/pkg/homedir"
+)
+
+// dockerHubRegistry is the registry of the images without one, and the key of its credentials in the Docker config
+const dockerHubRegistry = "docker.io"
+const dockerHubServer = "https://index.docker.io/v1/"
+
+// dockerConfig is the part of the Docker CLI configuration (~/.docker/config.json) holding the registry credentials
+type dockerConfig struct {
+	Auths       map[string]dockerConfigAuth `json:"auths"`
+	CredsStore  string                      `json:"credsStore"`
+	CredHelpers map[string]string           `json:"credHelpers"`
+}
+
+type dockerConfigAuth struct {
+	Auth          string `json:"auth"`
+	IdentityToken string `json:"identitytoken"`
+}
+
+// helperCredentials is the output of "docker-credential-<helper> get"
+type helperCredentials struct {
+	Username string `json:"Username"`
+	Secret   string `json:"Secret"`
+}
+
+// runCredentialHelper runs "docker-credential-<helper> get" for the server, swapped in tests
+var runCredentialHelper = func(ctx context.Context, helper string, server string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(server)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		// the helpers report missing credentials on stdout
+		if output := strings.TrimSpace(stdout.String() + stderr.String()); output != "" {
+			return nil, fmt.Errorf("docker-credential-%s: %s: %w", helper, output, err)
+		}
+		return nil, fmt.Errorf("docker-credential-%s: %w", helper, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// warningOutput receives the warnings on the credentials the pulls do without, swapped in tests
+var warningOutput io.Writer = os.Stderr
+
+// WithRegistryAuth makes the pulls run with ctx use the given credentials, as registry=user:password,
+// instead of the ones of the Docker config
+func WithRegistryAuth(ctx context.Context, overrides map[string]string) (context.Context, error) {
+	auths := map[string]types.AuthConfig{}
+	for registry, credentials := range overrides {
+		username, password, ok := strings.Cut(credentials, ":")
+		if !ok || username == "" {
+			return ctx, fmt.Errorf("invalid credentials for registry %s: expected user:password", registry)
+		}
+		registry = normalizeRegistry(registry)
+		auths[registry] = types.AuthConfig{Username: username, Password: password, ServerAddress: registry}
+	}
+	return context.WithValue(ctx, pkg.FLContextKey("registry_auth"), auths), nil
+}
+
+// RegistryAuth returns the encoded credentials to pull the image, as expected by the Engine API, or "" to pull
+// it anonymously. The credentials come from the overrides of ctx, the credential helpers and credential store of
+// the Docker config, or its auths section, in this order. A failing credential helper only prints a warning, the
+// image is then pulled anonymously as public images need no credentials.
+func RegistryAuth(ctx context.Context, image string) (string, error) {
+	registry := imageRegistry(image)
+
+	auth, found, err := registryCredentials(ctx, registry)
+	if err != nil {
+		return "", fmt.Errorf("resolving the credentials of %s: %w", registry, err)
+	}
+	if !found {
+		return "", nil
+	}
+	encoded, err := json.Marshal(auth)
+	if err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(encoded), nil
+}
+
+func registryCredentials(ctx context.Context, registry string) (types.AuthConfig, bool, error) {
+	if overrides, ok := ctx.Value(pkg.FLContextKey("registry_auth")).(map[string]types.AuthConfig); ok {
+		if auth, ok := overrides[registry]; ok {
+			return auth, true, nil
+		}
+	}
+
+	config, err := readDockerConfig()
+	if err != nil {
+		return types.AuthConfig{}, false, err
+	}
+
+	server := registry
+	if registry == dockerHubRegistry {
+		server = dockerHubServer
+	}
+	helper := config.CredsStore
+	for key, h := range config.CredHelpers {
+		if normalizeRegistry(key) == registry {
+			helper = h
+		}
+	}
+	if helper != "" {
+		return helperAuth(ctx, helper, server, registry)
+	}
+
+	for key, entry := range config.Auths {
+		if normalizeRegistry(key) == registry {
+			return fileAuth(entry, registry)
+		}
+	}
+	return types.AuthConfig{}, false, nil
+}
+
+// helperAuth asks a credential helper for the credentials of the server, falling back to no credentials when the
+// helper has none or fails
+func helperAuth(ctx context.Context, helper, server, registry string) (types.AuthConfig, bool, error) {
+	out, err := runCredentialHelper(ctx, helper, server)
+	if err != nil {
+		if !strings.Contains(err.Error(), "credentials not found") {
+			fmt.Fprintf(warningOutput, "Warning: %v, pulling from %s anonymously\n", err, registry)
+		}
+		return types.AuthConfig{}, false, nil
+	}
+
+	var creds helperCredentials
+	if err := json.Unmarshal(out, &creds); err != nil {
+		fmt.Fprintf(warningOutput, "Warning: docker-credential-%s: %v, pulling from %s anonymously\n", helper, err, registry)
+		return types.AuthConfig{}, false, nil
+	}
+	auth := types.AuthConfig{ServerAddress: registry}
+	if creds.Username == "<token>" {
+		auth.IdentityToken = creds.Secret
+	} else {
+		auth.Username, auth.Password = creds.Username, creds.Secret
+	}
+	return auth, true, nil
+}
+
+// fileAuth decodes the credentials stored in the auths section of the Docker config
+func fileAuth(entry dockerConfigAuth, registry string) (types.AuthConfig, bool, error) {
+	auth := types.AuthConfig{ServerAddress: registry, IdentityToken: entry.IdentityToken}
+	if entry.Auth != "" {
+		decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+		if err != nil {
+			return auth, false, fmt.Errorf("invalid auth of %s in the docker config: %w", registry, err)
+		}
+		username, password, ok := strings.Cut(string(decoded), ":")
+		if !ok {
+			return auth, false, fmt.Errorf("invalid auth of %s in the docker config", registry)
+		}
+		auth.Username, auth.Password = username, password
+	}
+	if auth.Username == "" && auth.IdentityToken == "" {
+		return auth, false, nil
+	}
+	return auth, true, nil
+}
+
+// readDockerConfig reads the config of the Docker CLI, in $DOCKER_CONFIG or ~/.docker, if there is one
+func readDockerConfig() (dockerConfig, error) {
+	var config dockerConfig
+
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := homedir.GetHomeDir()
+		if err != nil {
+			return config, err
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return config, err
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("invalid docker config %s: %w", filepath.Join(dir, "config.json"), err)
+	}
+	return config, nil
+}
+
+// imageRegistry returns the registry an image is pulled from
+func imageRegistry(image string) string {
+	first, _, found := strings.Cut(image, "/")
+	if found && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		return normalizeRegistry(first)
+	}
+	return dockerHubRegistry
+}
+
+// normalizeRegistry strips the scheme and the path from a registry address, as found in the Docker config
+func normalizeRegistry(registry string) string {
+	registry = strings.TrimPrefix(registry, "https://")
+	registry = strings.TrimPrefix(registry, "http://")
+	registry, _, _ = strings.Cut(registry, "/")
+	switch registry {
+	case "index.docker.io", "registry-1.docker.io":
+		return dockerHubRegistry
+	}
+	return registry
+}
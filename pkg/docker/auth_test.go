@@ -0,0 +1,153 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/require"
+)
+
+func useDockerConfig(t *testing.T, config string) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("DOCKER_CONFIG", dir)
+	if config != "" {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "config.json"), []byte(config), 0600))
+	}
+
+	defaultHelper := runCredentialHelper
+	t.Cleanup(func() { runCredentialHelper = defaultHelper })
+	runCredentialHelper = func(ctx context.Context, helper string, server string) ([]byte, error) {
+		switch helper + " " + server {
+		case "pass ghcr.io":
+			return []byte(`{"ServerURL":"ghcr.io","Username":"octocat","Secret":"ghp_token"}`), nil
+		case "desktop https://index.docker.io/v1/":
+			return []byte(`{"ServerURL":"https://index.docker.io/v1/","Username":"<token>","Secret":"identity"}`), nil
+		case "missing ghcr.io":
+			return nil, fmt.Errorf("docker-credential-missing: %w", exec.ErrNotFound)
+		}
+		return nil, errors.New("docker-credential-" + helper + ": credentials not found in native keychain")
+	}
+}
+
+func decodeAuth(t *testing.T, encoded string) types.AuthConfig {
+	t.Helper()
+	var auth types.AuthConfig
+	data, err := base64.URLEncoding.DecodeString(encoded)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &auth))
+	return auth
+}
+
+func TestRegistryAuth(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("should pull anonymously without a docker config", func(t *testing.T) {
+		useDockerConfig(t, "")
+		auth, err := RegistryAuth(ctx, "ghcr.io/funlessdev/fl-rust-builder:latest")
+		require.NoError(t, err)
+		require.Empty(t, auth)
+	})
+
+	t.Run("should use the auths of the docker config", func(t *testing.T) {
+		basic := base64.StdEncoding.EncodeToString([]byte("robot$fl:secret"))
+		useDockerConfig(t, `{"auths":{"https://harbor.example.com":{"auth":"`+basic+`"}}}`)
+
+		auth, err := RegistryAuth(ctx, "harbor.example.com/fl/fl-rust-builder:latest")
+		require.NoError(t, err)
+		require.Equal(t, types.AuthConfig{Username: "robot$fl", Password: "secret", ServerAddress: "harbor.example.com"}, decodeAuth(t, auth))
+
+		auth, err = RegistryAuth(ctx, "ghcr.io/funlessdev/fl-rust-builder:latest")
+		require.NoError(t, err)
+		require.Empty(t, auth)
+	})
+
+	t.Run("should ask the credential helpers", func(t *testing.T) {
+		useDockerConfig(t, `{"credsStore":"desktop","credHelpers":{"ghcr.io":"pass"}}`)
+
+		auth, err := RegistryAuth(ctx, "ghcr.io/funlessdev/fl-rust-builder:latest")
+		require.NoError(t, err)
+		require.Equal(t, types.AuthConfig{Username: "octocat", Password: "ghp_token", ServerAddress: "ghcr.io"}, decodeAuth(t, auth))
+
+		auth, err = RegistryAuth(ctx, "funlessdev/core")
+		require.NoError(t, err)
+		require.Equal(t, types.AuthConfig{IdentityToken: "identity", ServerAddress: "docker.io"}, decodeAuth(t, auth))
+
+		auth, err = RegistryAuth(ctx, "quay.io/funlessdev/core")
+		require.NoError(t, err)
+		require.Empty(t, auth)
+	})
+
+	t.Run("should pull anonymously with a warning when the credential helper fails", func(t *testing.T) {
+		useDockerConfig(t, `{"credsStore":"missing"}`)
+		var warnings bytes.Buffer
+		warningOutput = &warnings
+		t.Cleanup(func() { warningOutput = os.Stderr })
+
+		auth, err := RegistryAuth(ctx, "ghcr.io/funlessdev/fl-rust-builder:latest")
+		require.NoError(t, err)
+		require.Empty(t, auth)
+		require.Equal(t, "Warning: docker-credential-missing: executable file not found in $PATH, pulling from ghcr.io anonymously\n", warnings.String())
+
+		warnings.Reset()
+		_, err = RegistryAuth(ctx, "quay.io/funlessdev/core")
+		require.NoError(t, err)
+		require.Empty(t, warnings.String())
+	})
+
+	t.Run("should prefer the credentials of the flags", func(t *testing.T) {
+		useDockerConfig(t, `{"credHelpers":{"ghcr.io":"pass"}}`)
+
+		ctx, err := WithRegistryAuth(ctx, map[string]string{"https://ghcr.io": "ci:token:with:colons"})
+		require.NoError(t, err)
+		auth, err := RegistryAuth(ctx, "ghcr.io/funlessdev/fl-rust-builder:latest")
+		require.NoError(t, err)
+		require.Equal(t, types.AuthConfig{Username: "ci", Password: "token:with:colons", ServerAddress: "ghcr.io"}, decodeAuth(t, auth))
+
+		_, err = WithRegistryAuth(ctx, map[string]string{"ghcr.io": "token"})
+		require.ErrorContains(t, err, "expected user:password")
+	})
+}
+
+func Test_runCredentialHelper(t *testing.T) {
+	_, err := runCredentialHelper(context.TODO(), "fl-test-missing", "ghcr.io")
+	require.ErrorIs(t, err, exec.ErrNotFound)
+	require.ErrorContains(t, err, "docker-credential-fl-test-missing")
+}
+
+func Test_imageRegistry(t *testing.T) {
+	tests := map[string]string{
+		"ghcr.io/funlessdev/core:latest": "ghcr.io",
+		"localhost:5000/core":            "localhost:5000",
+		"localhost/core":                 "localhost",
+		"funlessdev/core":                "docker.io",
+		"postgres":                       "docker.io",
+		"docker.io/library/postgres":     "docker.io",
+	}
+	for image, registry := range tests {
+		require.Equal(t, registry, imageRegistry(image), image)
+	}
+}
@@ -19,11 +19,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
 	"github.com/docker/docker/client"
 )
 
@@ -61,6 +63,83 @@ func (c *DockerClient) ImageExists(ctx context.Context, image string) (bool, err
 	return true, nil
 }
 
+// ImageID returns the content-addressable ID of a local image
+func (c *DockerClient) ImageID(ctx context.Context, image string) (string, error) {
+	inspect, _, err := c.innerClient.ImageInspectWithRaw(ctx, image)
+	if err != nil {
+		return "", err
+	}
+	return inspect.ID, nil
+}
+
+// Returns the version and the API version of the Docker Engine, failing if it cannot be reached
+func (c *DockerClient) ServerVersion(ctx context.Context) (string, string, error) {
+	v, err := c.innerClient.ServerVersion(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	return v.Version, v.APIVersion, nil
+}
+
+// Engine describes the container engine behind the API
+type Engine struct {
+	Podman   bool
+	Rootless bool
+}
+
+// Returns whether the engine is Podman and whether it runs rootless
+func (c *DockerClient) Engine(ctx context.Context) (Engine, error) {
+	var engine Engine
+
+	info, err := c.innerClient.Info(ctx)
+	if err != nil {
+		return engine, err
+	}
+	for _, opt := range info.SecurityOptions {
+		if strings.Contains(opt, "name=rootless") {
+			engine.Rootless = true
+		}
+	}
+
+	v, err := c.innerClient.ServerVersion(ctx)
+	if err != nil {
+		return engine, err
+	}
+	for _, component := range v.Components {
+		if strings.HasPrefix(component.Name, "Podman") {
+			engine.Podman = true
+		}
+	}
+	return engine, nil
+}
+
+// ImageDigest returns the registry digest of a local image, as a repository@sha256:... reference
+func (c *DockerClient) ImageDigest(ctx context.Context, image string) (string, error) {
+	inspect, _, err := c.innerClient.ImageInspectWithRaw(ctx, image)
+	if err != nil {
+		return "", err
+	}
+	repository := imageRepository(image)
+	for _, digest := range inspect.RepoDigests {
+		if imageRepository(digest) == repository {
+			return digest, nil
+		}
+	}
+	return "", fmt.Errorf("image %s has no registry digest, it was not pulled from a registry", image)
+}
+
+// imageRepository strips the tag or the digest from an image reference
+func imageRepository(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	// a colon after the last slash starts the tag, one before it is the port of the registry
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
+
 func (c *DockerClient) Pull(ctx context.Context, image string) error {
 	exists, err := c.ImageExists(ctx, image)
 	if err != nil {
@@ -69,8 +148,17 @@ func (c *DockerClient) Pull(ctx context.Context, image string) error {
 	if exists {
 		return nil
 	}
+	return c.ForcePull(ctx, image)
+}
+
+// ForcePull pulls the image even when it exists locally, updating a tag to the image it points to in the registry
+func (c *DockerClient) ForcePull(ctx context.Context, image string) error {
+	auth, err := RegistryAuth(ctx, image)
+	if err != nil {
+		return err
+	}
 
-	out, err := c.innerClient.ImagePull(ctx, image, types.ImagePullOptions{})
+	out, err := c.innerClient.ImagePull(ctx, image, types.ImagePullOptions{RegistryAuth: auth})
 	if err != nil {
 		return err
 	}
@@ -78,8 +166,12 @@ func (c *DockerClient) Pull(ctx context.Context, image string) error {
 
 	d := json.NewDecoder(out)
 
+	report := pullProgressReporter(ctx)
+	tracker := newPullTracker(image)
 	var event *dockerEvent
 	for {
+		// a fresh event each time, the fields missing from the next one must not keep their value
+		event = nil
 		if err := d.Decode(&event); err != nil {
 			if err == io.EOF {
 				break
@@ -90,12 +182,17 @@ func (c *DockerClient) Pull(ctx context.Context, image string) error {
 		if event.Error != "" {
 			return fmt.Errorf("pulling image: %s", event.Error)
 		}
+		if progress, changed := tracker.update(event); changed && report != nil {
+			report(progress)
+		}
 	}
 	return nil
 }
 
 // struct for decoding docker events, used in PullImage to check if an error occurred during pulling
+// and to report the progress of the layers
 type dockerEvent struct {
+	ID             string `json:"id"`
 	Status         string `json:"status"`
 	Error          string `json:"error"`
 	Progress       string `json:"progress"`
@@ -209,3 +306,77 @@ func (c *DockerClient) RemoveNetwork(ctx context.Context, networkName string) er
 	}
 	return c.innerClient.NetworkRemove(ctx, id)
 }
+
+// Creates a named volume with the given labels, if it does not exist yet
+func (c *DockerClient) CreateVolume(ctx context.Context, name string, labels map[string]string) error {
+	_, err := c.innerClient.VolumeCreate(ctx, volume.VolumeCreateBody{Name: name, Labels: labels})
+	return err
+}
+
+// Returns the names of the volumes having the given label
+func (c *DockerClient) ListVolumes(ctx context.Context, label string) ([]string, error) {
+	res, err := c.innerClient.VolumeList(ctx, filters.NewArgs(filters.KeyValuePair{Key: "label", Value: label}))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(res.Volumes))
+	for _, v := range res.Volumes {
+		names = append(names, v.Name)
+	}
+	return names, nil
+}
+
+func (c *DockerClient) RemoveVolume(ctx context.Context, name string) error {
+	return c.innerClient.VolumeRemove(ctx, name, false)
+}
+
+// Writes a tarball with the given images (as produced by "docker save") to dest
+func (c *DockerClient) SaveImages(ctx context.Context, images []string, dest io.Writer) error {
+	out, err := c.innerClient.ImageSave(ctx, images)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(dest, out)
+	return err
+}
+
+// Imports the images in a tarball produced by SaveImages (or "docker save") and returns the loaded references
+func (c *DockerClient) LoadImages(ctx context.Context, src io.Reader) ([]string, error) {
+	res, err := c.innerClient.ImageLoad(ctx, src, true)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	d := json.NewDecoder(res.Body)
+
+	loaded := []string{}
+	for {
+		var event loadEvent
+		if err := d.Decode(&event); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+
+		if event.Error != "" {
+			return nil, fmt.Errorf("loading images: %s", event.Error)
+		}
+		if msg := strings.TrimSpace(event.Stream); strings.HasPrefix(msg, loadedImagePrefix) {
+			loaded = append(loaded, strings.TrimPrefix(msg, loadedImagePrefix))
+		}
+	}
+	return loaded, nil
+}
+
+// message the engine sends for every tagged image it loads
+const loadedImagePrefix = "Loaded image: "
+
+// struct for decoding the messages of an image load
+type loadEvent struct {
+	Stream string `json:"stream"`
+	Error  string `json:"error"`
+}
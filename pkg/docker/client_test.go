@@ -0,0 +1,34 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_imageRepository(t *testing.T) {
+	tests := map[string]string{
+		"ghcr.io/funlessdev/fl-rust-builder:latest":        "ghcr.io/funlessdev/fl-rust-builder",
+		"ghcr.io/funlessdev/fl-rust-builder@sha256:0123":   "ghcr.io/funlessdev/fl-rust-builder",
+		"ghcr.io/funlessdev/fl-rust-builder":               "ghcr.io/funlessdev/fl-rust-builder",
+		"localhost:5000/fl-js-builder":                     "localhost:5000/fl-js-builder",
+		"localhost:5000/fl-js-builder:0.1@sha256:0123abcd": "localhost:5000/fl-js-builder",
+	}
+	for image, repository := range tests {
+		require.Equal(t, repository, imageRepository(image), image)
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/docker/go-units"
+	"github.com/funlessdev/fl-cli/pkg"
+)
+
+// PullProgress is the progress of an image pull, summed over its layers
+type PullProgress struct {
+	Image string
+	// Current and Total are the downloaded and the overall bytes of the layers whose size is known
+	Current int64
+	Total   int64
+	Layers  int
+	Done    int
+}
+
+// Percent returns the downloaded percentage of the known bytes
+func (p PullProgress) Percent() int {
+	if p.Total == 0 {
+		return 0
+	}
+	return int(p.Current * 100 / p.Total)
+}
+
+func (p PullProgress) String() string {
+	if p.Total == 0 {
+		return fmt.Sprintf("%d/%d layers", p.Done, p.Layers)
+	}
+	return fmt.Sprintf("%s/%s (%d%%)", units.HumanSize(float64(p.Current)), units.HumanSize(float64(p.Total)), p.Percent())
+}
+
+// WithPullProgress makes the pulls run with ctx report their progress to report, on every change
+func WithPullProgress(ctx context.Context, report func(PullProgress)) context.Context {
+	return context.WithValue(ctx, pkg.FLContextKey("pull_progress"), report)
+}
+
+func pullProgressReporter(ctx context.Context) func(PullProgress) {
+	report, _ := ctx.Value(pkg.FLContextKey("pull_progress")).(func(PullProgress))
+	return report
+}
+
+type layerProgress struct {
+	current int64
+	total   int64
+	done    bool
+}
+
+// pullTracker sums the progress of the layers from the events of a pull
+type pullTracker struct {
+	image  string
+	layers map[string]*layerProgress
+}
+
+func newPullTracker(image string) *pullTracker {
+	return &pullTracker{image: image, layers: map[string]*layerProgress{}}
+}
+
+// update records an event, returning the progress and whether it changed
+func (t *pullTracker) update(event *dockerEvent) (PullProgress, bool) {
+	if event.ID == "" || strings.HasPrefix(event.Status, "Pulling from") {
+		// events about the whole image: tag, digest, status
+		return t.progress(), false
+	}
+
+	layer, ok := t.layers[event.ID]
+	if !ok {
+		layer = &layerProgress{}
+		t.layers[event.ID] = layer
+	}
+	before := *layer
+
+	switch event.Status {
+	case "Downloading":
+		layer.current = int64(event.ProgressDetail.Current)
+		if event.ProgressDetail.Total > 0 {
+			layer.total = int64(event.ProgressDetail.Total)
+		}
+	case "Download complete", "Verifying Checksum", "Extracting", "Pull complete", "Already exists":
+		// the extraction progress is not part of the download
+		layer.current = layer.total
+		layer.done = event.Status != "Verifying Checksum"
+	}
+	return t.progress(), !ok || *layer != before
+}
+
+func (t *pullTracker) progress() PullProgress {
+	p := PullProgress{Image: t.image, Layers: len(t.layers)}
+	for _, layer := range t.layers {
+		p.Current += layer.current
+		p.Total += layer.total
+		if layer.done {
+			p.Done++
+		}
+	}
+	return p
+}
@@ -0,0 +1,64 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docker
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testPullEvents = `{"status":"Pulling from funlessdev/fl-rust-builder","id":"latest"}
+{"status":"Already exists","progressDetail":{},"id":"a1"}
+{"status":"Pulling fs layer","progressDetail":{},"id":"b2"}
+{"status":"Pulling fs layer","progressDetail":{},"id":"c3"}
+{"status":"Downloading","progressDetail":{"current":1000,"total":4000},"progress":"[=>   ]","id":"b2"}
+{"status":"Downloading","progressDetail":{"current":500,"total":1000},"progress":"[==>  ]","id":"c3"}
+{"status":"Download complete","progressDetail":{},"id":"c3"}
+{"status":"Downloading","progressDetail":{"current":3000,"total":4000},"progress":"[===> ]","id":"b2"}
+{"status":"Extracting","progressDetail":{"current":100,"total":4000},"progress":"[>    ]","id":"b2"}
+{"status":"Pull complete","progressDetail":{},"id":"b2"}
+{"status":"Pull complete","progressDetail":{},"id":"c3"}
+{"status":"Digest: sha256:0123"}
+{"status":"Status: Downloaded newer image for ghcr.io/funlessdev/fl-rust-builder:latest"}
+`
+
+func Test_pullTracker(t *testing.T) {
+	tracker := newPullTracker("ghcr.io/funlessdev/fl-rust-builder:latest")
+
+	reported := []string{}
+	d := json.NewDecoder(strings.NewReader(testPullEvents))
+	for d.More() {
+		var event *dockerEvent
+		require.NoError(t, d.Decode(&event))
+		if progress, changed := tracker.update(event); changed {
+			reported = append(reported, progress.String())
+		}
+	}
+
+	require.Equal(t, []string{
+		"1/1 layers",
+		"1/2 layers",
+		"1/3 layers",
+		"1kB/4kB (25%)",
+		"1.5kB/5kB (30%)",
+		"2kB/5kB (40%)",
+		"4kB/5kB (80%)",
+		"5kB/5kB (100%)",
+	}, reported)
+	require.Equal(t, PullProgress{Image: "ghcr.io/funlessdev/fl-rust-builder:latest", Current: 5000, Total: 5000, Layers: 3, Done: 3}, tracker.progress())
+}
@@ -0,0 +1,101 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docker
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Runtime is the container engine fl works with, through its Docker compatible API and command line
+type Runtime string
+
+const (
+	RuntimeDocker Runtime = "docker"
+	RuntimePodman Runtime = "podman"
+
+	// RuntimeAuto picks the runtime available on the machine
+	RuntimeAuto = "auto"
+)
+
+// dockerSocket, podmanSockets and lookPath are swapped in tests
+var dockerSocket = "/var/run/docker.sock"
+
+var podmanSockets = func() []string {
+	runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+	if runtimeDir == "" {
+		runtimeDir = fmt.Sprintf("/run/user/%d", os.Getuid())
+	}
+	// rootless first
+	return []string{filepath.Join(runtimeDir, "podman", "podman.sock"), "/run/podman/podman.sock"}
+}
+
+var lookPath = exec.LookPath
+
+// DetectRuntime returns the runtime called name or, when name is "auto" or empty, the one available on the machine:
+// the one DOCKER_HOST points to, Docker when its socket exists, Podman when one of its sockets exists, and
+// otherwise the one whose command line is installed.
+func DetectRuntime(name string) (Runtime, error) {
+	switch name {
+	case string(RuntimeDocker), string(RuntimePodman):
+		return Runtime(name), nil
+	case RuntimeAuto, "":
+	default:
+		return "", fmt.Errorf("unknown container runtime %q: use %s, %s or %s", name, RuntimeAuto, RuntimeDocker, RuntimePodman)
+	}
+
+	if host := os.Getenv(HostEnv); host != "" {
+		if strings.Contains(host, "podman") {
+			return RuntimePodman, nil
+		}
+		return RuntimeDocker, nil
+	}
+	if _, err := os.Stat(dockerSocket); err == nil {
+		return RuntimeDocker, nil
+	}
+	if podmanSocket() != "" {
+		return RuntimePodman, nil
+	}
+	if _, err := lookPath("docker"); err == nil {
+		return RuntimeDocker, nil
+	}
+	if _, err := lookPath("podman"); err == nil {
+		return RuntimePodman, nil
+	}
+	return RuntimeDocker, nil
+}
+
+// Host returns the API endpoint of the runtime when DOCKER_HOST does not set one, empty for the default one
+func (r Runtime) Host() string {
+	if r != RuntimePodman || os.Getenv(HostEnv) != "" {
+		return ""
+	}
+	if socket := podmanSocket(); socket != "" {
+		return "unix://" + socket
+	}
+	return ""
+}
+
+func podmanSocket() string {
+	for _, socket := range podmanSockets() {
+		if _, err := os.Stat(socket); err == nil {
+			return socket
+		}
+	}
+	return ""
+}
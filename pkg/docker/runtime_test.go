@@ -0,0 +1,102 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docker
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func useTestSockets(t *testing.T, docker bool, podman bool, clis ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+
+	defaultDockerSocket, defaultPodmanSockets, defaultLookPath := dockerSocket, podmanSockets, lookPath
+	t.Cleanup(func() {
+		dockerSocket, podmanSockets, lookPath = defaultDockerSocket, defaultPodmanSockets, defaultLookPath
+	})
+
+	dockerSocket = filepath.Join(dir, "docker.sock")
+	podmanSocket := filepath.Join(dir, "podman", "podman.sock")
+	podmanSockets = func() []string { return []string{podmanSocket} }
+	lookPath = func(file string) (string, error) {
+		for _, cli := range clis {
+			if cli == file {
+				return "/usr/bin/" + file, nil
+			}
+		}
+		return "", errors.New("not found")
+	}
+
+	if docker {
+		require.NoError(t, os.WriteFile(dockerSocket, nil, 0600))
+	}
+	if podman {
+		require.NoError(t, os.MkdirAll(filepath.Dir(podmanSocket), 0700))
+		require.NoError(t, os.WriteFile(podmanSocket, nil, 0600))
+	}
+	return podmanSocket
+}
+
+func TestDetectRuntime(t *testing.T) {
+	t.Setenv(HostEnv, "")
+
+	t.Run("should use the given runtime", func(t *testing.T) {
+		useTestSockets(t, true, false)
+		rt, err := DetectRuntime("podman")
+		require.NoError(t, err)
+		require.Equal(t, RuntimePodman, rt)
+
+		_, err = DetectRuntime("containerd")
+		require.Error(t, err)
+	})
+
+	t.Run("should prefer docker when both sockets exist", func(t *testing.T) {
+		useTestSockets(t, true, true)
+		rt, err := DetectRuntime(RuntimeAuto)
+		require.NoError(t, err)
+		require.Equal(t, RuntimeDocker, rt)
+	})
+
+	t.Run("should find the podman socket", func(t *testing.T) {
+		socket := useTestSockets(t, false, true)
+		rt, err := DetectRuntime(RuntimeAuto)
+		require.NoError(t, err)
+		require.Equal(t, RuntimePodman, rt)
+		require.Equal(t, "unix://"+socket, rt.Host())
+	})
+
+	t.Run("should fall back to the installed command line", func(t *testing.T) {
+		useTestSockets(t, false, false, "podman")
+		rt, err := DetectRuntime("")
+		require.NoError(t, err)
+		require.Equal(t, RuntimePodman, rt)
+		require.Empty(t, rt.Host())
+	})
+
+	t.Run("should follow DOCKER_HOST", func(t *testing.T) {
+		useTestSockets(t, true, false)
+		t.Setenv(HostEnv, "unix:///run/user/1000/podman/podman.sock")
+		rt, err := DetectRuntime(RuntimeAuto)
+		require.NoError(t, err)
+		require.Equal(t, RuntimePodman, rt)
+		// DOCKER_HOST wins over the socket of the runtime
+		require.Empty(t, rt.Host())
+	})
+}
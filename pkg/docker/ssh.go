@@ -0,0 +1,157 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/client"
+)
+
+// HostEnv is the environment variable selecting the Docker host, read by the docker CLI too
+const HostEnv = "DOCKER_HOST"
+
+// NewClient creates a client of the runtime API at host or, when host is empty, at the one of the environment
+// (DOCKER_HOST etc.) or of the runtime. The API version is negotiated with the engine.
+// ssh:// hosts are reached by running "docker system dial-stdio" on them over ssh, as the docker CLI does.
+func NewClient(rt Runtime, host string) (*client.Client, error) {
+	if host == "" {
+		host = os.Getenv(HostEnv)
+	}
+	if host == "" {
+		host = rt.Host()
+	}
+
+	opts := []client.Opt{client.FromEnv, client.WithAPIVersionNegotiation()}
+	if IsSSHHost(host) {
+		args, err := SSHArgs(host, "docker", "system", "dial-stdio")
+		if err != nil {
+			return nil, err
+		}
+		// the host is only used to build the request URLs, the connection goes through ssh
+		opts = append(opts, client.WithHost("http://docker.example.com"), client.WithDialContext(func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dialCommand("ssh", args...)
+		}))
+	} else if host != "" {
+		opts = append(opts, client.WithHost(host))
+	}
+	return client.NewClientWithOpts(opts...)
+}
+
+// IsSSHHost reports whether the Docker host is reached over ssh
+func IsSSHHost(host string) bool {
+	return strings.HasPrefix(host, "ssh://")
+}
+
+// SSHArgs returns the arguments of the ssh command running cmd on the ssh://[user@]host[:port] Docker host
+func SSHArgs(host string, cmd ...string) ([]string, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "ssh" {
+		return nil, fmt.Errorf("%s is not an ssh:// Docker host", host)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("no host name in %s", host)
+	}
+	if u.Path != "" && u.Path != "/" {
+		return nil, fmt.Errorf("unexpected path in %s, use ssh://[user@]host[:port]", host)
+	}
+
+	var args []string
+	if u.User != nil {
+		args = append(args, "-l", u.User.Username())
+	}
+	if u.Port() != "" {
+		args = append(args, "-p", u.Port())
+	}
+	args = append(args, "--", u.Hostname())
+	return append(args, cmd...), nil
+}
+
+// commandConn is a connection to the standard input and output of a command
+type commandConn struct {
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	stdout    io.ReadCloser
+	closeOnce sync.Once
+}
+
+func dialCommand(name string, args ...string) (net.Conn, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &commandConn{cmd: cmd, stdin: stdin, stdout: stdout}, nil
+}
+
+func (c *commandConn) Read(p []byte) (int, error) {
+	return c.stdout.Read(p)
+}
+
+func (c *commandConn) Write(p []byte) (int, error) {
+	return c.stdin.Write(p)
+}
+
+func (c *commandConn) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		err = c.stdin.Close()
+		if killErr := c.cmd.Process.Kill(); killErr != nil && !errors.Is(killErr, os.ErrProcessDone) {
+			err = killErr
+		}
+		_ = c.cmd.Wait()
+	})
+	return err
+}
+
+func (c *commandConn) LocalAddr() net.Addr {
+	return commandAddr{}
+}
+
+func (c *commandConn) RemoteAddr() net.Addr {
+	return commandAddr{}
+}
+
+// deadlines are not supported, the requests are bound by their context
+func (c *commandConn) SetDeadline(t time.Time) error      { return nil }
+func (c *commandConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *commandConn) SetWriteDeadline(t time.Time) error { return nil }
+
+type commandAddr struct{}
+
+func (commandAddr) Network() string { return "command" }
+func (commandAddr) String() string  { return "command" }
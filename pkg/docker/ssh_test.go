@@ -0,0 +1,46 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSHArgs(t *testing.T) {
+	args, err := SSHArgs("ssh://fl@test-box:2222", "docker", "system", "dial-stdio")
+	require.NoError(t, err)
+	require.Equal(t, []string{"-l", "fl", "-p", "2222", "--", "test-box", "docker", "system", "dial-stdio"}, args)
+
+	args, err = SSHArgs("ssh://test-box", "pwd")
+	require.NoError(t, err)
+	require.Equal(t, []string{"--", "test-box", "pwd"}, args)
+
+	_, err = SSHArgs("tcp://test-box:2376")
+	require.Error(t, err)
+	_, err = SSHArgs("ssh://test-box/var/run/docker.sock")
+	require.Error(t, err)
+}
+
+func TestNewClient(t *testing.T) {
+	c, err := NewClient(RuntimeDocker, "ssh://fl@test-box")
+	require.NoError(t, err)
+	require.Equal(t, "http://docker.example.com", c.DaemonHost())
+
+	c, err = NewClient(RuntimeDocker, "tcp://test-box:2376")
+	require.NoError(t, err)
+	require.Equal(t, "tcp://test-box:2376", c.DaemonHost())
+}
@@ -92,7 +92,7 @@ func CreateDirInConfigDir(dirName string) (string, error) {
 		return path, nil
 	}
 
-	err = os.Mkdir(path, 0755)
+	err = os.MkdirAll(path, 0755)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,123 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"path"
+	"strings"
+)
+
+// IgnoreMatcher matches slash separated relative paths against patterns with the semantics of .gitignore files
+type IgnoreMatcher struct {
+	rules []ignoreRule
+}
+
+type ignoreRule struct {
+	segments []string
+	negate   bool
+	dirOnly  bool
+}
+
+// NewIgnoreMatcher compiles the patterns, the lines of an ignore file: blank lines and comments starting with #
+// are skipped, a leading ! re-includes what earlier patterns exclude, a trailing / only matches directories,
+// a pattern with a / elsewhere is relative to the root and one without matches at any depth, and ** matches
+// any number of directories.
+func NewIgnoreMatcher(patterns []string) *IgnoreMatcher {
+	m := &IgnoreMatcher{}
+	for _, p := range patterns {
+		if rule, ok := parseIgnoreRule(p); ok {
+			m.rules = append(m.rules, rule)
+		}
+	}
+	return m
+}
+
+func parseIgnoreRule(pattern string) (ignoreRule, bool) {
+	var rule ignoreRule
+
+	pattern = strings.TrimRight(pattern, " \t\r")
+	if pattern == "" || strings.HasPrefix(pattern, "#") {
+		return rule, false
+	}
+	if strings.HasPrefix(pattern, "!") {
+		rule.negate = true
+		pattern = pattern[1:]
+	} else if strings.HasPrefix(pattern, `\!`) || strings.HasPrefix(pattern, `\#`) {
+		pattern = pattern[1:]
+	}
+	if strings.HasSuffix(pattern, "/") {
+		rule.dirOnly = true
+		pattern = strings.TrimRight(pattern, "/")
+	}
+	if pattern == "" {
+		return rule, false
+	}
+
+	if strings.Contains(pattern, "/") {
+		// anchored to the root
+		pattern = strings.TrimPrefix(pattern, "/")
+	} else {
+		pattern = "**/" + pattern
+	}
+	rule.segments = strings.Split(pattern, "/")
+	return rule, true
+}
+
+// Ignored reports whether the path, relative to the root of the patterns, is excluded. The last matching
+// pattern decides. The paths inside an excluded directory are excluded with it by the callers, which do not
+// descend into it, so they cannot be re-included, as with git.
+func (m *IgnoreMatcher) Ignored(rel string, isDir bool) bool {
+	if m == nil {
+		return false
+	}
+	parts := strings.Split(strings.Trim(rel, "/"), "/")
+
+	ignored := false
+	for _, rule := range m.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if matchSegments(rule.segments, parts) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// matchSegments matches the path elements against the pattern elements, where ** matches zero or more
+// elements, or one or more when it ends the pattern
+func matchSegments(pattern []string, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		if len(pattern) == 1 {
+			return len(parts) > 0
+		}
+		for i := 0; i <= len(parts); i++ {
+			if matchSegments(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	if matched, _ := path.Match(pattern[0], parts[0]); !matched {
+		return false
+	}
+	return matchSegments(pattern[1:], parts[1:])
+}
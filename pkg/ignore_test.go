@@ -0,0 +1,60 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIgnoreMatcher(t *testing.T) {
+	t.Run("matches names at any depth and anchored paths from the root", func(t *testing.T) {
+		m := NewIgnoreMatcher([]string{"*.md", "build/", "/docs/*.txt"})
+		require.True(t, m.Ignored("README.md", false))
+		require.True(t, m.Ignored("src/NOTES.md", false))
+		require.True(t, m.Ignored("src/build", true))
+		require.False(t, m.Ignored("src/build", false))
+		require.True(t, m.Ignored("docs/a.txt", false))
+		require.False(t, m.Ignored("src/docs/a.txt", false))
+		require.False(t, m.Ignored("src/lib.rs", false))
+	})
+
+	t.Run("lets the last matching pattern decide", func(t *testing.T) {
+		m := NewIgnoreMatcher([]string{"*.log", "!keep.log", "target/", "!target/"})
+		require.True(t, m.Ignored("debug.log", false))
+		require.False(t, m.Ignored("logs/keep.log", false))
+		require.False(t, m.Ignored("target", true))
+	})
+
+	t.Run("matches any number of directories with **", func(t *testing.T) {
+		m := NewIgnoreMatcher([]string{"**/fixtures/*.json", "tmp/**", "a/**/z"})
+		require.True(t, m.Ignored("fixtures/x.json", false))
+		require.True(t, m.Ignored("test/deep/fixtures/x.json", false))
+		require.True(t, m.Ignored("tmp/x/y", false))
+		require.False(t, m.Ignored("tmp", true))
+		require.True(t, m.Ignored("a/z", false))
+		require.True(t, m.Ignored("a/b/c/z", false))
+		require.False(t, m.Ignored("b/a/z", false))
+	})
+
+	t.Run("skips comments and blank lines and honours escapes", func(t *testing.T) {
+		m := NewIgnoreMatcher([]string{"# comment", "", "   ", `\#hash`, `\!bang`})
+		require.False(t, m.Ignored("# comment", false))
+		require.True(t, m.Ignored("#hash", false))
+		require.True(t, m.Ignored("!bang", false))
+		require.False(t, m.Ignored("comment", false))
+	})
+}
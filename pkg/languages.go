@@ -0,0 +1,205 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// languageID is the form of the language names, which end up in the --language flags
+var languageID = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// languagesFile is the format of the language registry files
+type languagesFile struct {
+	Languages map[string]Language `yaml:"languages"`
+}
+
+// LoadLanguages adds the languages of the registry files to SupportedLanguages, skipping the files that do
+// not exist. A language already registered is overridden field by field, so a file can swap the builder
+// image of a built-in language only. Later files take precedence.
+func LoadLanguages(paths ...string) error {
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		var file languagesFile
+		if err := yaml.UnmarshalStrict(data, &file); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		for id, lang := range file.Languages {
+			base, known := SupportedLanguages[id]
+			merged, err := mergeLanguage(id, base, lang, known)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			SupportedLanguages[id] = merged
+		}
+	}
+	return nil
+}
+
+// mergeLanguage overrides the fields of base set in lang. Only the languages not known yet need a builder image,
+// the known ones may be built natively only.
+func mergeLanguage(id string, base, lang Language, known bool) (Language, error) {
+	if !languageID.MatchString(id) {
+		return base, fmt.Errorf("invalid language name %q: use lowercase letters, digits, - and _", id)
+	}
+	if lang.Name != "" {
+		base.Name = lang.Name
+	}
+	if lang.BuilderImage != "" {
+		base.BuilderImage = lang.BuilderImage
+	}
+	if lang.Extensions != nil {
+		base.Extensions = lang.Extensions
+	}
+	if lang.MustContainFiles != nil {
+		base.MustContainFiles = lang.MustContainFiles
+	}
+	if lang.OutputPath != "" {
+		base.OutputPath = lang.OutputPath
+	}
+
+	if base.BuilderImage == "" && !known {
+		return base, fmt.Errorf("language %s has no builder_image", id)
+	}
+	if base.Name == "" {
+		base.Name = id
+	}
+	return base, nil
+}
+
+// LanguageIDs returns the names of the registered languages, sorted
+func LanguageIDs() []string {
+	ids := make([]string, 0, len(SupportedLanguages))
+	for id := range SupportedLanguages {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// DetectLanguage infers the language of the function in srcDir: the language whose required files are all in the
+// directory or, when several or none of them are, the one with source files. It fails listing the candidates when
+// the source could be of more than one language.
+func DetectLanguage(srcDir string) (string, error) {
+	byFiles := []string{}
+	for _, id := range LanguageIDs() {
+		if hasRequiredFiles(srcDir, SupportedLanguages[id].MustContainFiles) {
+			byFiles = append(byFiles, id)
+		}
+	}
+	if len(byFiles) == 1 {
+		return byFiles[0], nil
+	}
+
+	candidates := byFiles
+	if len(candidates) == 0 {
+		candidates = LanguageIDs()
+	}
+	extensions, err := sourceExtensions(srcDir)
+	if err != nil {
+		return "", err
+	}
+	bySources := []string{}
+	for _, id := range candidates {
+		for _, ext := range SupportedLanguages[id].Extensions {
+			if extensions[ext] {
+				bySources = append(bySources, id)
+				break
+			}
+		}
+	}
+	if len(bySources) == 1 {
+		return bySources[0], nil
+	}
+
+	if len(bySources) > 0 {
+		candidates = bySources
+	} else if len(byFiles) == 0 {
+		return "", fmt.Errorf("cannot detect the language of %s: none of the files of the languages found (%s), use --language",
+			srcDir, describeLanguages(LanguageIDs()))
+	}
+	return "", fmt.Errorf("cannot detect the language of %s, it could be %s: use --language to choose",
+		srcDir, describeLanguages(candidates))
+}
+
+func hasRequiredFiles(dir string, files []string) bool {
+	if len(files) == 0 {
+		return false
+	}
+	for _, f := range files {
+		if _, err := os.Stat(filepath.Join(dir, f)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// sourceExtensions returns the extensions of the files in dir, skipping hidden, dependency and build directories
+func sourceExtensions(dir string) (map[string]bool, error) {
+	extensions := map[string]bool{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != dir && (strings.HasPrefix(name, ".") || name == "node_modules" || name == "target" || name == "vendor") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ext := filepath.Ext(path); ext != "" {
+			extensions[ext] = true
+		}
+		return nil
+	})
+	return extensions, err
+}
+
+// describeLanguages lists the languages with their required files, e.g. "js (package.json), rust (Cargo.toml)"
+func describeLanguages(ids []string) string {
+	described := make([]string, len(ids))
+	for i, id := range ids {
+		described[i] = id
+		if files := SupportedLanguages[id].MustContainFiles; len(files) > 0 {
+			described[i] += " (" + strings.Join(files, ", ") + ")"
+		}
+	}
+	return strings.Join(described, ", ")
+}
+
+// WasmOutputPath returns the directory where the builder image of the language writes code.wasm
+func (l Language) WasmOutputPath() string {
+	if l.OutputPath == "" {
+		return DefaultOutputPath
+	}
+	return l.OutputPath
+}
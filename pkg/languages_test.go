@@ -0,0 +1,158 @@
+// Copyright 2023 Giuseppe De Palma, Matteo Trentin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// useLanguages restores the built-in registry after the test
+func useLanguages(t *testing.T) {
+	t.Helper()
+	builtin := map[string]Language{}
+	for id, lang := range SupportedLanguages {
+		builtin[id] = lang
+	}
+	t.Cleanup(func() { SupportedLanguages = builtin })
+}
+
+func writeLanguages(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), LanguagesFileName)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoadLanguages(t *testing.T) {
+	t.Run("should skip the files that do not exist", func(t *testing.T) {
+		useLanguages(t)
+		require.NoError(t, LoadLanguages(filepath.Join(t.TempDir(), LanguagesFileName)))
+		require.Equal(t, []string{"go", "js", "rust"}, LanguageIDs())
+	})
+
+	t.Run("should add new languages and override the fields of the known ones", func(t *testing.T) {
+		useLanguages(t)
+		user := writeLanguages(t, `languages:
+  mysdk:
+    name: My SDK
+    builder_image: registry.example.com/mysdk-builder:1.0
+    extensions: [.ts]
+    required_files: [sdk.json]
+    output_path: /out
+  rust:
+    builder_image: registry.example.com/rust-builder:1.70
+`)
+		project := writeLanguages(t, `languages:
+  mysdk:
+    builder_image: registry.example.com/mysdk-builder:2.0
+`)
+
+		require.NoError(t, LoadLanguages(user, project))
+		require.Equal(t, []string{"go", "js", "mysdk", "rust"}, LanguageIDs())
+		require.Equal(t, Language{
+			Name:             "My SDK",
+			BuilderImage:     "registry.example.com/mysdk-builder:2.0",
+			Extensions:       []string{".ts"},
+			MustContainFiles: []string{"sdk.json"},
+			OutputPath:       "/out",
+		}, SupportedLanguages["mysdk"])
+		require.Equal(t, "/out", SupportedLanguages["mysdk"].WasmOutputPath())
+
+		rust := SupportedLanguages["rust"]
+		require.Equal(t, "Rust", rust.Name)
+		require.Equal(t, "registry.example.com/rust-builder:1.70", rust.BuilderImage)
+		require.Equal(t, []string{"Cargo.toml"}, rust.MustContainFiles)
+		require.Equal(t, DefaultOutputPath, rust.WasmOutputPath())
+	})
+
+	t.Run("should name a language after its id by default", func(t *testing.T) {
+		useLanguages(t)
+		require.NoError(t, LoadLanguages(writeLanguages(t, "languages:\n  zig:\n    builder_image: zig-builder\n")))
+		require.Equal(t, "zig", SupportedLanguages["zig"].Name)
+	})
+
+	t.Run("should only require a builder image for new languages", func(t *testing.T) {
+		useLanguages(t)
+		require.NoError(t, LoadLanguages(writeLanguages(t, "languages:\n  go:\n    extensions: [.go, .tinygo]\n")))
+		require.Empty(t, SupportedLanguages["go"].BuilderImage)
+		require.Equal(t, []string{".go", ".tinygo"}, SupportedLanguages["go"].Extensions)
+	})
+
+	t.Run("should reject invalid languages", func(t *testing.T) {
+		useLanguages(t)
+		err := LoadLanguages(writeLanguages(t, "languages:\n  zig:\n    name: Zig\n"))
+		require.ErrorContains(t, err, "language zig has no builder_image")
+
+		err = LoadLanguages(writeLanguages(t, "languages:\n  Zig,go:\n    builder_image: zig-builder\n"))
+		require.ErrorContains(t, err, "invalid language name")
+
+		err = LoadLanguages(writeLanguages(t, "languages:\n  zig:\n    image: zig-builder\n"))
+		require.ErrorContains(t, err, "field image not found")
+		require.NotContains(t, SupportedLanguages, "zig")
+	})
+}
+
+func TestDetectLanguage(t *testing.T) {
+	writeFiles := func(t *testing.T, files ...string) string {
+		t.Helper()
+		dir := t.TempDir()
+		for _, f := range files {
+			require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, f)), 0755))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, f), []byte{}, 0644))
+		}
+		return dir
+	}
+
+	t.Run("should detect the language from its required files", func(t *testing.T) {
+		for files, expected := range map[string]string{"Cargo.toml": "rust", "package.json": "js", "go.mod": "go"} {
+			lang, err := DetectLanguage(writeFiles(t, files))
+			require.NoError(t, err)
+			require.Equal(t, expected, lang)
+		}
+	})
+
+	t.Run("should choose among the candidates with their source files", func(t *testing.T) {
+		// a rust crate with a package.json for its tooling
+		lang, err := DetectLanguage(writeFiles(t, "Cargo.toml", "package.json", "src/lib.rs", "node_modules/dep/index.js"))
+		require.NoError(t, err)
+		require.Equal(t, "rust", lang)
+	})
+
+	t.Run("should list the candidates when ambiguous", func(t *testing.T) {
+		_, err := DetectLanguage(writeFiles(t, "Cargo.toml", "package.json"))
+		require.ErrorContains(t, err, "it could be js (package.json), rust (Cargo.toml): use --language to choose")
+
+		_, err = DetectLanguage(writeFiles(t, "Cargo.toml", "package.json", "src/lib.rs", "index.js"))
+		require.ErrorContains(t, err, "it could be js (package.json), rust (Cargo.toml)")
+	})
+
+	t.Run("should fail when no language matches", func(t *testing.T) {
+		_, err := DetectLanguage(writeFiles(t, "README.md"))
+		require.ErrorContains(t, err, "none of the files of the languages found (go (go.mod), js (package.json), rust (Cargo.toml))")
+	})
+
+	t.Run("should detect the languages of the registry", func(t *testing.T) {
+		useLanguages(t)
+		require.NoError(t, LoadLanguages(writeLanguages(t, "languages:\n  zig:\n    builder_image: zig-builder\n    extensions: [.zig]\n")))
+
+		lang, err := DetectLanguage(writeFiles(t, "build.zig", "src/main.zig"))
+		require.NoError(t, err)
+		require.Equal(t, "zig", lang)
+	})
+}
@@ -106,6 +106,7 @@ func (l *loggerBuilder) Build() (FLogger, error) {
 		spinner:          s,
 		writer:           l.writer,
 		disableAnimation: l.disableAnimation,
+		plain:            !isTerminal(l.writer),
 	}
 	return logger, nil
 }
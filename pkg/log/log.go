@@ -17,20 +17,40 @@ package log
 import (
 	"fmt"
 	"io"
+	"os"
+	"time"
 
+	"github.com/mattn/go-isatty"
 	"github.com/theckman/yacspin"
 )
 
+// plainMessageInterval is the minimum time between two spinner messages printed as lines
+const plainMessageInterval = 2 * time.Second
+
 type FLoggerImpl struct {
 	disableAnimation bool // used mostly for testing
 	debug            bool
 	currentMessage   string
 	spinner          *yacspin.Spinner
 	writer           io.Writer
+
+	// plain is set when the writer is not a terminal: the spinner messages are printed as lines
+	plain        bool
+	lastLineTime time.Time
 }
 
+// SpinnerMessage updates the message of the running spinner. When the output is not a terminal,
+// the message is printed on its own line instead, at most once every plainMessageInterval.
 func (l *FLoggerImpl) SpinnerMessage(msg string) {
 	l.currentMessage = msg
+	if l.plain && !l.disableAnimation {
+		// the spinner would print a line for each message, flooding logs with progress updates
+		if time.Since(l.lastLineTime) >= plainMessageInterval {
+			l.lastLineTime = time.Now()
+			fmt.Fprintln(l.writer, msg)
+		}
+		return
+	}
 	l.spinner.Message(msg)
 }
 
@@ -87,3 +107,9 @@ func (l *FLoggerImpl) Debugf(format string, args ...interface{}) {
 		fmt.Fprintf(l.writer, format, args...)
 	}
 }
+
+// isTerminal returns whether the writer is a terminal
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && (isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd()))
+}
@@ -187,6 +187,20 @@ func TestSpinner(t *testing.T) {
 		assert.Equal(t, "failed\n", outbuf.String())
 	})
 
+	t.Run("SpinnerMessage prints lines at most every plainMessageInterval when not on a terminal", func(t *testing.T) {
+		logger, outbuf := setupWriterLogger(false)
+		assert.True(t, logger.(*FLoggerImpl).plain)
+
+		logger.SpinnerMessage("pulling 10%")
+		logger.SpinnerMessage("pulling 20%")
+		assert.Equal(t, "pulling 10%\n", outbuf.String())
+		assert.Equal(t, "pulling 20%", logger.(*FLoggerImpl).currentMessage)
+
+		logger.(*FLoggerImpl).lastLineTime = time.Now().Add(-plainMessageInterval)
+		logger.SpinnerMessage("pulling 30%")
+		assert.Equal(t, "pulling 10%\npulling 30%\n", outbuf.String())
+	})
+
 }
 
 func ExampleFLoggerImpl_Info() {
@@ -52,6 +52,9 @@ type FLError struct {
 
 type FLContextKey string
 
+// CLIVersion is the version of the running fl binary
+type CLIVersion string
+
 func ExtractError(err error) error {
 	var e FLError
 	openApiError, castOk := err.(*openapi.GenericOpenAPIError)
@@ -18,6 +18,7 @@ package mocks
 
 import (
 	context "context"
+	io "io"
 
 	mock "github.com/stretchr/testify/mock"
 )
@@ -27,13 +28,13 @@ type DockerShell struct {
 	mock.Mock
 }
 
-// ComposeDown provides a mock function with given fields: ctx, composeFilePath
-func (_m *DockerShell) ComposeDown(ctx context.Context, composeFilePath string) error {
-	ret := _m.Called(ctx, composeFilePath)
+// ComposeDown provides a mock function with given fields: ctx, project, composeFilePath
+func (_m *DockerShell) ComposeDown(ctx context.Context, project string, composeFilePath string) error {
+	ret := _m.Called(ctx, project, composeFilePath)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
-		r0 = rf(ctx, composeFilePath)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, project, composeFilePath)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -67,13 +68,20 @@ func (_m *DockerShell) ComposeList(ctx context.Context) ([]string, error) {
 	return r0, r1
 }
 
-// ComposeUp provides a mock function with given fields: ctx, composeFilePath
-func (_m *DockerShell) ComposeUp(ctx context.Context, composeFilePath string) error {
-	ret := _m.Called(ctx, composeFilePath)
+// ComposeRecreate provides a mock function with given fields: ctx, project, composeFilePath, services
+func (_m *DockerShell) ComposeRecreate(ctx context.Context, project string, composeFilePath string, services ...string) error {
+	_va := make([]interface{}, len(services))
+	for _i := range services {
+		_va[_i] = services[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, project, composeFilePath)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
-		r0 = rf(ctx, composeFilePath)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, ...string) error); ok {
+		r0 = rf(ctx, project, composeFilePath, services...)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -81,13 +89,34 @@ func (_m *DockerShell) ComposeUp(ctx context.Context, composeFilePath string) er
 	return r0
 }
 
-// LogTokens provides a mock function with given fields: ctx
-func (_m *DockerShell) LogTokens(ctx context.Context) error {
-	ret := _m.Called(ctx)
+// ComposeRemove provides a mock function with given fields: ctx, project, composeFilePath, profiles, services
+func (_m *DockerShell) ComposeRemove(ctx context.Context, project string, composeFilePath string, profiles []string, services []string) error {
+	ret := _m.Called(ctx, project, composeFilePath, profiles, services)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
-		r0 = rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []string, []string) error); ok {
+		r0 = rf(ctx, project, composeFilePath, profiles, services)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ComposeRun provides a mock function with given fields: ctx, project, composeFilePath, service, args
+func (_m *DockerShell) ComposeRun(ctx context.Context, project string, composeFilePath string, service string, args ...string) error {
+	_va := make([]interface{}, len(args))
+	for _i := range args {
+		_va[_i] = args[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, project, composeFilePath, service)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, ...string) error); ok {
+		r0 = rf(ctx, project, composeFilePath, service, args...)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -95,6 +124,79 @@ func (_m *DockerShell) LogTokens(ctx context.Context) error {
 	return r0
 }
 
+// ComposeUp provides a mock function with given fields: ctx, project, composeFilePath
+func (_m *DockerShell) ComposeUp(ctx context.Context, project string, composeFilePath string) error {
+	ret := _m.Called(ctx, project, composeFilePath)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, project, composeFilePath)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Exec provides a mock function with given fields: ctx, container, stdin, stdout, args
+func (_m *DockerShell) Exec(ctx context.Context, container string, stdin io.Reader, stdout io.Writer, args ...string) error {
+	_va := make([]interface{}, len(args))
+	for _i := range args {
+		_va[_i] = args[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, container, stdin, stdout)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, io.Reader, io.Writer, ...string) error); ok {
+		r0 = rf(ctx, container, stdin, stdout, args...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// LogTokens provides a mock function with given fields: ctx, project
+func (_m *DockerShell) LogTokens(ctx context.Context, project string) error {
+	ret := _m.Called(ctx, project)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, project)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UploadAssets provides a mock function with given fields: ctx, host, localDir, paths, remoteDir
+func (_m *DockerShell) UploadAssets(ctx context.Context, host string, localDir string, paths []string, remoteDir string) (string, error) {
+	ret := _m.Called(ctx, host, localDir, paths, remoteDir)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []string, string) (string, error)); ok {
+		return rf(ctx, host, localDir, paths, remoteDir)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []string, string) string); ok {
+		r0 = rf(ctx, host, localDir, paths, remoteDir)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, []string, string) error); ok {
+		r1 = rf(ctx, host, localDir, paths, remoteDir)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 type mockConstructorTestingTNewDockerShell interface {
 	mock.TestingT
 	Cleanup(func())
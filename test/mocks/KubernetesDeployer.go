@@ -20,6 +20,7 @@ import (
 	bytes "bytes"
 	context "context"
 
+	deploy "github.com/funlessdev/fl-cli/pkg/deploy"
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -98,6 +99,32 @@ func (_m *KubernetesDeployer) CreateRoleBinding(ctx context.Context) error {
 	return r0
 }
 
+// CreateSelfSignedTLSSecret provides a mock function with given fields: ctx, name, host
+func (_m *KubernetesDeployer) CreateSelfSignedTLSSecret(ctx context.Context, name string, host string) ([]byte, error) {
+	ret := _m.Called(ctx, name, host)
+
+	var r0 []byte
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) ([]byte, error)); ok {
+		return rf(ctx, name, host)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []byte); ok {
+		r0 = rf(ctx, name, host)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, name, host)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreateSvcAccount provides a mock function with given fields: ctx
 func (_m *KubernetesDeployer) CreateSvcAccount(ctx context.Context) error {
 	ret := _m.Called(ctx)
@@ -112,13 +139,27 @@ func (_m *KubernetesDeployer) CreateSvcAccount(ctx context.Context) error {
 	return r0
 }
 
-// DeployCore provides a mock function with given fields: ctx
-func (_m *KubernetesDeployer) DeployCore(ctx context.Context) error {
-	ret := _m.Called(ctx)
+// DeployCore provides a mock function with given fields: ctx, core
+func (_m *KubernetesDeployer) DeployCore(ctx context.Context, core deploy.CoreSpec) error {
+	ret := _m.Called(ctx, core)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
-		r0 = rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, deploy.CoreSpec) error); ok {
+		r0 = rf(ctx, core)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeployCoreIngress provides a mock function with given fields: ctx, host, tlsSecret, ingressClass
+func (_m *KubernetesDeployer) DeployCoreIngress(ctx context.Context, host string, tlsSecret string, ingressClass string) error {
+	ret := _m.Called(ctx, host, tlsSecret, ingressClass)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) error); ok {
+		r0 = rf(ctx, host, tlsSecret, ingressClass)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -196,13 +237,13 @@ func (_m *KubernetesDeployer) DeployPrometheusService(ctx context.Context) error
 	return r0
 }
 
-// DeployWorker provides a mock function with given fields: ctx
-func (_m *KubernetesDeployer) DeployWorker(ctx context.Context) error {
-	ret := _m.Called(ctx)
+// DeployWorker provides a mock function with given fields: ctx, worker
+func (_m *KubernetesDeployer) DeployWorker(ctx context.Context, worker deploy.WorkerSpec) error {
+	ret := _m.Called(ctx, worker)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
-		r0 = rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, deploy.WorkerSpec) error); ok {
+		r0 = rf(ctx, worker)
 	} else {
 		r0 = ret.Error(0)
 	}